@@ -1,3 +1,7 @@
 module go-usip
 
 go 1.22.3
+
+require go-usip/usip v0.0.0
+
+replace go-usip/usip => ../usip
@@ -1,23 +1,37 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"log"
 	"net/http"
+	"os"
+
+	"go-usip/usip"
 )
 
 func main() {
-	http.HandleFunc("/userinfo", BatchGetUserInfo)
-	http.HandleFunc("/collaborators", BatchGetCollaborators)
-	http.HandleFunc("/role", GetUnitCollaboratorRole)
-	http.HandleFunc("/credential", CredentialVerify)
+	secret := os.Getenv("USIP_SECRET")
+	if secret == "" {
+		log.Println("USIP_SECRET is empty; requests are not authenticated")
+	}
 
 	log.Println("Server started at :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
+	if err := http.ListenAndServe(":8080", newHandler(secret)); err != nil {
 		log.Fatal(err)
 	}
 }
 
+// newHandler serves the usip endpoints from the in-memory data, signed with secret unless it is empty.
+func newHandler(secret string) http.Handler {
+	p := &provider{}
+	return usip.NewHandler(usip.Config{
+		Credentials:   p,
+		Users:         p,
+		Collaborators: p,
+		Secret:        secret,
+	})
+}
+
 const (
 	RoleOwner  = "owner"
 	RoleEditor = "editor"
@@ -25,138 +39,70 @@ const (
 )
 
 type User struct {
-	UserID string `json:"userID,omitempty"`
-	Name   string `json:"name,omitempty"`
-	Avatar string `json:"avatar,omitempty"`
+	UserID string
+	Name   string
+	Avatar string
 }
 
 type Collaborator struct {
-	UserID string `json:"userID,omitempty"`
-	Role   string `json:"role,omitempty"`
+	UserID string
+	Role   string
 }
 
-// handles the batch get user info request
-func BatchGetUserInfo(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		Users []*User `json:"users"`
-	}
+// provider serves the usip endpoints from the in-memory data.
+type provider struct{}
+
+func toUsipUser(u *User) usip.UsipUser {
+	return usip.UsipUser{UserId: u.UserID, Name: u.Name, Avatar: u.Avatar}
+}
 
-	// use post body
-	var req struct {
-		UserIDs []string `json:"userIDs"`
+// handles the credential verify request
+func (p *provider) VerifyCredential(r *http.Request) (usip.UsipUser, error) {
+	userID, ok := VerifyToken(r.Header.Get("x-authorization"))
+	if !ok {
+		return usip.UsipUser{}, usip.ErrUnauthorized
 	}
-	_ = json.NewDecoder(r.Body).Decode(&req)
-	userIDs := req.UserIDs
 
-	result := response{}
+	return toUsipUser(Users[userID]), nil
+}
+
+// handles the batch get user info request
+func (p *provider) GetUsers(_ context.Context, userIDs []string) ([]usip.UsipUser, error) {
+	var users []usip.UsipUser
 	for _, userID := range userIDs {
 		if u, ok := Users[userID]; ok {
-			result.Users = append(result.Users, u)
+			users = append(users, toUsipUser(u))
 		}
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
+	return users, nil
 }
 
 // handles the batch get collaborators request
-func BatchGetCollaborators(w http.ResponseWriter, r *http.Request) {
-	type subject struct {
-		ID     string `json:"id,omitempty"`
-		Name   string `json:"name,omitempty"`
-		Avatar string `json:"avatar,omitempty"`
-		// Type   string `json:"type,omitempty"`
-	}
-	type response struct {
-		Collaborators []*struct {
-			UnitID   string `json:"unitID,omitempty"`
-			Subjects []*struct {
-				Subject *subject `json:"subject,omitempty"`
-				Role    string   `json:"role,omitempty"`
-			} `json:"subjects,omitempty"`
-		} `json:"collaborators"`
+func (p *provider) GetCollaborators(_ context.Context, unitID string) ([]usip.UsipCollaborator, error) {
+	cs, ok := UnitCollaborators[unitID]
+	if !ok {
+		return nil, usip.ErrUnitNotFound
 	}
 
-	// use post body
-	var req struct {
-		UnitIDs []string `json:"unitIDs"`
-	}
-	_ = json.NewDecoder(r.Body).Decode(&req)
-	unitIDs := req.UnitIDs
-
-	result := response{}
-	for _, unitID := range unitIDs {
-		if cs, ok := UnitCollaborators[unitID]; ok {
-			item := struct {
-				UnitID   string `json:"unitID,omitempty"`
-				Subjects []*struct {
-					Subject *subject `json:"subject,omitempty"`
-					Role    string   `json:"role,omitempty"`
-				} `json:"subjects,omitempty"`
-			}{UnitID: unitID}
-			for _, c := range cs {
-				s := &subject{}
-				if u, ok := Users[c.UserID]; ok {
-					s.ID = u.UserID
-					s.Name = u.Name
-					s.Avatar = u.Avatar
-				}
-				item.Subjects = append(item.Subjects, &struct {
-					Subject *subject `json:"subject,omitempty"`
-					Role    string   `json:"role,omitempty"`
-				}{Subject: s, Role: c.Role})
-			}
-			result.Collaborators = append(result.Collaborators, &item)
+	var subjects []usip.UsipCollaborator
+	for _, c := range cs {
+		s := usip.UsipSubject{}
+		if u, ok := Users[c.UserID]; ok {
+			s.ID = u.UserID
+			s.Name = u.Name
+			s.Avatar = u.Avatar
 		}
+		subjects = append(subjects, usip.UsipCollaborator{Subject: s, Role: usip.Role(c.Role)})
 	}
-
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
+	return subjects, nil
 }
 
 // handles the get unit collaborator role request
-func GetUnitCollaboratorRole(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		UserID string `json:"userID,omitempty"`
-		Role   string `json:"role,omitempty"`
-	}
-
-	_ = r.ParseForm()
-	userID := r.FormValue("userID")
-	unitID := r.FormValue("unitID")
-
-	role := ""
-	if cs, ok := UnitCollaborators[unitID]; ok {
-		for _, c := range cs {
-			if c.UserID == userID {
-				role = c.Role
-				break
-			}
+func (p *provider) GetRole(_ context.Context, unitID, userID string) (usip.Role, error) {
+	for _, c := range UnitCollaborators[unitID] {
+		if c.UserID == userID {
+			return usip.Role(c.Role), nil
 		}
 	}
-
-	result := response{Role: role, UserID: userID}
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
-}
-
-// handles the credential verify request
-func CredentialVerify(w http.ResponseWriter, r *http.Request) {
-	type response struct {
-		User *User `json:"user,omitempty"`
-	}
-
-	userID, ok := VerifyToken(r.Header.Get("x-authorization"))
-	if !ok {
-		w.WriteHeader(http.StatusUnauthorized)
-		return
-	}
-
-	result := response{User: Users[userID]}
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
+	return "", nil
 }
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"go-usip/usip/conformance"
+	"go-usip/usip/conformance/conformancetest"
+)
+
+func TestUsipConformance(t *testing.T) {
+	srv := httptest.NewServer(newHandler("s3cret"))
+	defer srv.Close()
+
+	conformancetest.Test(t, conformance.Config{
+		BaseURL:           srv.URL,
+		Secret:            "s3cret",
+		CredentialHeaders: map[string]string{"x-authorization": "token:1"},
+		UnitID:            "unit1",
+		UserID:            "2",
+	})
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"go-usip/datasource"
+	"go-usip/fakeuniverser"
+	"go-usip/migrations"
+	"go-usip/repositories"
+	"go-usip/services"
+)
+
+// commands are run as `server <command> [flags]` instead of starting the web server.
+var commands = map[string]func(args []string) error{
+	"fake-universer": runFakeUniverser,
+	"migrate":        runMigrate,
+	"reconcile":      runReconcile,
+}
+
+func runCommand(name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", name)
+		os.Exit(2)
+	}
+
+	if err := cmd(args); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func runFakeUniverser(args []string) error {
+	fs := flag.NewFlagSet("fake-universer", flag.ExitOnError)
+	addr := fs.String("addr", ":8000", "listen address, point universer.host at it")
+	latency := fs.Duration("latency", 0, "delay added to every response")
+	pendingPolls := fs.Int("pending-polls", 2, "times a task reports pending before it finishes")
+	failTasks := fs.Bool("fail-tasks", false, "finish every import and export task as failed")
+	_ = fs.Parse(args)
+
+	fake := fakeuniverser.New(fakeuniverser.Options{
+		Latency:      *latency,
+		PendingPolls: *pendingPolls,
+		FailTasks:    *failTasks,
+	})
+
+	log.Printf("fake universer listening on %s", *addr)
+	return http.ListenAndServe(*addr, fake)
+}
+
+const migrateUsage = "usage: migrate status | up | down | to <version>"
+
+// runMigrate applies or reverts the schema migrations of the configured database.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	db, err := datasource.LoadDB()
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	case "up":
+		return migrator.Up()
+	case "down":
+		return migrator.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return migrator.To(version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// runReconcile checks the files of the database against universer once and prints the issues found.
+func runReconcile(args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	repair := fs.Bool("repair", false, "repair the issues instead of only reporting them")
+	_ = fs.Parse(args)
+
+	if err := loadConfig(); err != nil {
+		return err
+	}
+	db, err := datasource.LoadDB()
+	if err != nil {
+		return err
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Check(); err != nil {
+		return err
+	}
+
+	reconciler := services.NewReconcileService(
+		services.LoadReconcileConfig(),
+		repositories.NewFileRepository(db),
+		repositories.NewFileCollaboratorRepository(db),
+		repositories.NewOrphanUnitRepository(db),
+		services.NewUniverseService(services.LoadUniverserConfig()),
+	)
+	report, err := reconciler.Run(context.Background(), *repair)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tFILE\tUNIT\tDETAIL\tSTATUS")
+	for _, issue := range report.Issues {
+		status := "found"
+		switch {
+		case issue.Err != nil:
+			status = "repair failed: " + issue.Err.Error()
+		case issue.Repaired:
+			status = "repaired"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", issue.Kind, issue.FileId, issue.UnitId, issue.Detail, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d files checked in %s, %d issues", report.Files, report.Duration.Round(time.Millisecond), len(report.Issues))
+	if report.Unchecked > 0 {
+		fmt.Printf(", %d files not checked against universer", report.Unchecked)
+	}
+	if report.DeletedUnits > 0 {
+		fmt.Printf(", %d orphan units deleted", report.DeletedUnits)
+	}
+	if report.OrphanUnits > 0 {
+		fmt.Printf(", %d orphan units still queued", report.OrphanUnits)
+	}
+	fmt.Println()
+	if report.UnitCheckDisabled {
+		fmt.Println("missing units not checked: universer.unitLifecycle is off")
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package datamodels
+
+import "gorm.io/gorm"
+
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// AccessRequest asks the owners of a file for a role on it, made by a user who opened it without access.
+type AccessRequest struct {
+	gorm.Model
+	FileId uint `json:"file_id" gorm:"index"`
+	// UserId is the user asking for access.
+	UserId  string              `json:"user_id" gorm:"index;type:varchar(255)"`
+	Role    Role                `json:"role" gorm:"type:varchar(255)"`
+	Message string              `json:"message" gorm:"type:varchar(1000)"`
+	Status  AccessRequestStatus `json:"status" gorm:"type:varchar(32)"`
+	// AnsweredBy is the owner who approved or denied the request, empty while it is pending.
+	AnsweredBy string `json:"answered_by" gorm:"type:varchar(255)"`
+}
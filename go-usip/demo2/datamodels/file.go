@@ -14,6 +14,12 @@ type File struct {
 	Name     string `json:"name" gorm:"type:varchar(255)"`
 	UnitId   string `json:"unit_id" gorm:"type:varchar(255)"`
 	UnitType int    `json:"unit_type"`
+	// WorkspaceId is the workspace the file belongs to, NoWorkspaceId for personal files.
+	WorkspaceId uint `json:"workspace_id" gorm:"index"`
+	// SourceFileId is the file this one is a copy of, 0 for originals.
+	SourceFileId uint `json:"source_file_id" gorm:"index"`
+	// Published files can be read by anyone with their link, guests without an account included.
+	Published bool `json:"published"`
 }
 
 func FileTypeStr(unitType int) string {
@@ -1,17 +1,91 @@
 package datamodels
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
 type Role string
 
 const (
 	RoleOwner  Role = "owner"
 	RoleEditor Role = "editor"
-	RoleReader Role = "reader"
+	// RoleCommenter views and comments without editing.
+	RoleCommenter Role = "commenter"
+	RoleReader    Role = "reader"
 )
 
+// RoleLever ranks the roles, custom roles rank with the built-in role they act as.
 var RoleLever = map[Role]int{
-	RoleOwner:  3,
-	RoleEditor: 2,
-	RoleReader: 1,
+	RoleOwner:     4,
+	RoleEditor:    3,
+	RoleCommenter: 2,
+	RoleReader:    1,
+}
+
+// baseRoles maps the custom roles onto the built-in role they act as.
+var baseRoles = map[Role]Role{}
+
+// DefineRole adds a custom role acting as the built-in role base,
+// it must be called before serving requests. Custom owners are refused, a file has a single kind of owner.
+func DefineRole(name Role, base Role) error {
+	if name == "" {
+		return errors.New("custom role name is empty")
+	}
+	if name.Valid() {
+		return fmt.Errorf("role %q is already defined", name)
+	}
+	if _, custom := baseRoles[base]; custom || !base.Valid() || base == RoleOwner {
+		return fmt.Errorf("custom role %q must act as editor, commenter or reader, not %q", name, base)
+	}
+
+	RoleLever[name] = RoleLever[base]
+	baseRoles[name] = base
+	return nil
+}
+
+// Valid tells whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := RoleLever[r]
+	return ok
+}
+
+// Base returns the built-in role r acts as, r itself for the built-in roles.
+func (r Role) Base() Role {
+	if base, ok := baseRoles[r]; ok {
+		return base
+	}
+	return r
+}
+
+// Roles lists the known roles, highest first.
+func Roles() []Role {
+	roles := make([]Role, 0, len(RoleLever))
+	for role := range RoleLever {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if RoleLever[roles[i]] != RoleLever[roles[j]] {
+			return RoleLever[roles[i]] > RoleLever[roles[j]]
+		}
+		// the built-in role comes before the custom ones acting as it.
+		customI, customJ := baseRoles[roles[i]] != "", baseRoles[roles[j]] != ""
+		if customI != customJ {
+			return customJ
+		}
+		return roles[i] < roles[j]
+	})
+	return roles
+}
+
+// MaxRole returns the role granting more, the empty role grants nothing.
+func MaxRole(a, b Role) Role {
+	if RoleLever[b] > RoleLever[a] {
+		return b
+	}
+	return a
 }
 
 type FileCollaborator struct {
@@ -19,4 +93,10 @@ type FileCollaborator struct {
 	UserId string `json:"user_id" gorm:"uniqueIndex:uqe_file_id_user_id,piroity:2;type:varchar(255)"`
 	FileId uint   `json:"file_id" gorm:"uniqueIndex:uqe_file_id_user_id,piroity:1;index;"`
 	Role   Role   `json:"role" gorm:"type:varchar(255)"`
+	// FolderId is where the collaborator keeps the file, RootFolderId by default.
+	FolderId uint `json:"folder_id" gorm:"index"`
+	// ExpiresAt ends the grant, nil for one which never expires. Owners never expire.
+	ExpiresAt *time.Time `json:"expires_at" gorm:"index"`
+	// ExpiryNotified is set once the owners were told the grant is about to expire.
+	ExpiryNotified bool `json:"expiry_notified"`
 }
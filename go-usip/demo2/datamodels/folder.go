@@ -0,0 +1,17 @@
+package datamodels
+
+import (
+	"gorm.io/gorm"
+)
+
+// RootFolderId is the folder id of files and folders at the top of a user's tree.
+const RootFolderId uint = 0
+
+// Folder organizes the files of its owner. Folders are personal,
+// a shared file sits in a folder of each collaborator, see FileCollaborator.FolderId.
+type Folder struct {
+	gorm.Model
+	Name     string `json:"name" gorm:"type:varchar(255)"`
+	UserId   string `json:"user_id" gorm:"index;type:varchar(255)"`
+	ParentId uint   `json:"parent_id" gorm:"index"`
+}
@@ -0,0 +1,28 @@
+package datamodels
+
+import "gorm.io/gorm"
+
+type InviteStatus string
+
+const (
+	InvitePending  InviteStatus = "pending"
+	InviteAccepted InviteStatus = "accepted"
+	InviteDeclined InviteStatus = "declined"
+)
+
+// Invite offers a role on a file to a user who accepts or declines it,
+// or to an email address without account which registers with its Token.
+type Invite struct {
+	gorm.Model
+	FileId uint `json:"file_id" gorm:"index"`
+	Role   Role `json:"role" gorm:"type:varchar(255)"`
+	// InvitedBy is the user who sent the invite.
+	InvitedBy string `json:"invited_by" gorm:"type:varchar(255)"`
+	// UserId is the invited user, empty until the invited email registers.
+	UserId string `json:"user_id" gorm:"index;type:varchar(255)"`
+	// Email is where the invite was sent, empty for users without email.
+	Email string `json:"email" gorm:"type:varchar(255)"`
+	// Token lets the invited email register and get the role, empty for invites of existing users.
+	Token  string       `json:"-" gorm:"index;type:varchar(64)"`
+	Status InviteStatus `json:"status" gorm:"type:varchar(32)"`
+}
@@ -0,0 +1,72 @@
+package datamodels
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type JobKind string
+
+const (
+	JobKindImport JobKind = "import"
+	JobKindExport JobKind = "export"
+	// JobKindCopy exports a unit then imports the result as a new unit.
+	JobKindCopy JobKind = "copy"
+)
+
+type JobStatus string
+
+const (
+	// JobStatusUploaded imports have their file uploaded to universer
+	// and wait for a worker to submit the import task.
+	JobStatusUploaded JobStatus = "uploaded"
+	// JobStatusQueued jobs wait for their universer task to start,
+	// exports start out queued.
+	JobStatusQueued JobStatus = "queued"
+	// JobStatusPending jobs have a universer task reported as running.
+	JobStatusPending JobStatus = "pending"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed"
+)
+
+// Job is an import, export or copy running in the background,
+// it is persisted so unfinished jobs are resumed after a restart.
+type Job struct {
+	gorm.Model
+	JobId    string    `json:"job_id" gorm:"uniqueIndex;type:varchar(64)"`
+	Kind     JobKind   `json:"kind" gorm:"type:varchar(32)"`
+	Status   JobStatus `json:"status" gorm:"index;type:varchar(32)"`
+	UserId   string    `json:"user_id" gorm:"index;type:varchar(255)"`
+	UnitType int       `json:"unit_type"`
+	// WorkspaceId is the workspace an import or copy creates its file in.
+	WorkspaceId uint `json:"workspace_id"`
+	// FileName is the uploaded file name of an import, the name of the file a copy creates.
+	FileName string `json:"file_name" gorm:"type:varchar(255)"`
+	// SourceId is the universer file id of an import or the unit id of an export or copy.
+	SourceId string `json:"source_id" gorm:"type:varchar(255)"`
+	// SourceFileId is the file a copy is made of.
+	SourceFileId uint `json:"source_file_id"`
+	// ExportedId is the universer file id a copy exported its source to,
+	// set once the export is done and the import of the copy starts from it.
+	ExportedId string `json:"exported_id" gorm:"type:varchar(255)"`
+	// FileId is the exported file, or the file created by an import or copy.
+	FileId uint   `json:"file_id"`
+	TaskId string `json:"task_id" gorm:"type:varchar(255)"`
+	// Result is the unit id of an import or copy or the universer file id of an export.
+	Result   string    `json:"result" gorm:"type:varchar(255)"`
+	Error    string    `json:"error" gorm:"type:text"`
+	Deadline time.Time `json:"deadline"`
+	// Cookie is forwarded to universer when the job resumes after a restart,
+	// it is cleared once the job is done or failed.
+	Cookie string `json:"-" gorm:"type:text"`
+}
+
+func (j Job) Finished() bool {
+	return j.Status == JobStatusDone || j.Status == JobStatusFailed
+}
+
+func GenerateJobId() string {
+	return uuid.New().String()
+}
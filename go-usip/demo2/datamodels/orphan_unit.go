@@ -0,0 +1,23 @@
+package datamodels
+
+import "gorm.io/gorm"
+
+type OrphanReason string
+
+const (
+	// OrphanPurged units belonged to a file purged from the trash.
+	OrphanPurged OrphanReason = "purged"
+	// OrphanUnrecorded units were created in universer but the host failed to record their file.
+	OrphanUnrecorded OrphanReason = "unrecorded"
+)
+
+// OrphanUnit is a universer unit no file refers to anymore, queued until universer deletes it.
+// Units are queued while universer.unitLifecycle is off, the reconciliation deletes them once it is on.
+type OrphanUnit struct {
+	gorm.Model
+	UnitId   string       `json:"unit_id" gorm:"index;type:varchar(255)"`
+	UnitType int          `json:"unit_type"`
+	Reason   OrphanReason `json:"reason" gorm:"type:varchar(32)"`
+	// Error is why the last deletion failed.
+	Error string `json:"error" gorm:"type:text"`
+}
@@ -0,0 +1,44 @@
+package datamodels
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ShareLink grants a role on a file to the logged in users opening it,
+// revoking the link soft deletes it, the access already granted stays.
+type ShareLink struct {
+	gorm.Model
+	Token  string `json:"token" gorm:"uniqueIndex;type:varchar(64)"`
+	FileId uint   `json:"file_id" gorm:"index"`
+	Role   Role   `json:"role" gorm:"type:varchar(255)"`
+	// CreatedBy is the owner who made the link.
+	CreatedBy string `json:"created_by" gorm:"type:varchar(255)"`
+	// ExpiresAt is nil for a link which never expires.
+	ExpiresAt *time.Time `json:"expires_at"`
+	// HashedPassword is empty for a link without password.
+	HashedPassword []byte `json:"-"`
+	// MaxUses is how many users may be granted access through the link, 0 for no limit.
+	MaxUses int `json:"max_uses"`
+	Uses    int `json:"uses"`
+}
+
+func (l ShareLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+func (l ShareLink) UsedUp() bool {
+	return l.MaxUses > 0 && l.Uses >= l.MaxUses
+}
+
+// GenerateShareToken returns a random url safe token, too long to be guessed.
+func GenerateShareToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -1,6 +1,8 @@
 package datamodels
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -19,6 +21,8 @@ type User struct {
 	Nickname       string `json:"nickname" form:"nickname" gorm:"type:varchar(255)"`
 	Username       string `json:"-" form:"-" gorm:"unique" gorm:"type:varchar(255)"`
 	HashedPassword []byte `json:"-" form:"-"`
+	// Email is optional and not verified, invites sent to it are offered to the user.
+	Email string `json:"email" form:"email" gorm:"index;type:varchar(255)"`
 }
 
 // IsValid can do some very very simple "low-level" data validations.
@@ -43,3 +47,19 @@ func ValidatePassword(userPassword string, hashed []byte) (bool, error) {
 func GenerateUserId() string {
 	return uuid.New().String()
 }
+
+// guestIdPrefix marks the ids handed to visitors without an account, user ids are bare uuids.
+const guestIdPrefix = "guest-"
+
+// GuestNickname is the name universer shows for guests.
+const GuestNickname = "Guest"
+
+// GenerateGuestId returns the id of a visitor without an account,
+// guests only get to read published files.
+func GenerateGuestId() string {
+	return guestIdPrefix + uuid.New().String()
+}
+
+func IsGuestId(userId string) bool {
+	return strings.HasPrefix(userId, guestIdPrefix)
+}
@@ -0,0 +1,22 @@
+package datamodels
+
+import (
+	"gorm.io/gorm"
+)
+
+// NoWorkspaceId is the workspace id of files outside any workspace.
+const NoWorkspaceId uint = 0
+
+// Workspace groups files of a team, every member can access
+// the files inside at their workspace role.
+type Workspace struct {
+	gorm.Model
+	Name string `json:"name" gorm:"type:varchar(255)"`
+}
+
+type WorkspaceMember struct {
+	ID          int64  `json:"id" gorm:"primary_key;AUTO_INCREMENT"`
+	WorkspaceId uint   `json:"workspace_id" gorm:"uniqueIndex:uqe_workspace_id_user_id"`
+	UserId      string `json:"user_id" gorm:"uniqueIndex:uqe_workspace_id_user_id;index;type:varchar(255)"`
+	Role        Role   `json:"role" gorm:"type:varchar(255)"`
+}
@@ -0,0 +1,416 @@
+// Package fakeuniverser is an in-process stand-in for the universer endpoints
+// demo2 calls, so the host can be developed and tested without the docker stack.
+//
+// It can be started with `go run . fake-universer` or used from tests:
+//
+//	fake := fakeuniverser.New(fakeuniverser.Options{PendingPolls: 2})
+//	srv := httptest.NewServer(fake)
+//	defer srv.Close()
+//	uSvc := services.NewUniverseService(services.UniverserConfig{Host: srv.URL})
+package fakeuniverser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	codeOK       = 1
+	codeNotFound = 2
+	codeInvalid  = 3
+)
+
+// Task states as reported by /exchange/task/{id}.
+const (
+	TaskPending = "pending"
+	TaskDone    = "done"
+	TaskFailed  = "failed"
+)
+
+type Options struct {
+	// Latency delays every response.
+	Latency time.Duration
+	// PendingPolls is how many times a task reports pending before it finishes.
+	PendingPolls int
+	// FailTasks finishes every import and export task as failed.
+	FailTasks bool
+}
+
+type Unit struct {
+	ID      string
+	Type    int
+	Name    string
+	Creator string
+	// Content is the imported file, empty for units created from scratch.
+	Content []byte
+}
+
+type Blob struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+type task struct {
+	id       string
+	export   bool
+	polls    int
+	status   string
+	fileId   string
+	unitType int
+	unitId   string
+}
+
+// Server implements the universer endpoints used by services.UniverserService.
+type Server struct {
+	mu    sync.Mutex
+	opts  Options
+	units map[string]*Unit
+	blobs map[string]*Blob
+	tasks map[string]*task
+}
+
+func New(opts Options) *Server {
+	return &Server{
+		opts:  opts,
+		units: map[string]*Unit{},
+		blobs: map[string]*Blob{},
+		tasks: map[string]*task{},
+	}
+}
+
+// SetOptions changes the behaviour of subsequent requests.
+func (s *Server) SetOptions(opts Options) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opts = opts
+}
+
+// Unit returns a copy of a stored unit.
+func (s *Server) Unit(unitId string) (Unit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.units[unitId]
+	if !ok {
+		return Unit{}, false
+	}
+	return *u, true
+}
+
+// Blob returns a copy of a stored file.
+func (s *Server) Blob(fileId string) (Blob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[fileId]
+	if !ok {
+		return Blob{}, false
+	}
+	return *b, true
+}
+
+// ServeHTTP routes the universer api paths:
+//
+//	POST /universer-api/snapshot/{type}/unit/-/create
+//	GET  /universer-api/snapshot/{type}/unit/{id}
+//	POST /universer-api/snapshot/{type}/unit/{id}/delete
+//	POST /universer-api/stream/file/upload?size=
+//	POST /universer-api/exchange/{type}/import
+//	POST /universer-api/exchange/{type}/export
+//	GET  /universer-api/exchange/task/{id}
+//	GET  /universer-api/file/{id}/sign-url
+//	GET  /universer-api/file/{id}/blob
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	latency := s.opts.Latency
+	s.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/universer-api"), "/"), "/")
+	switch {
+	case r.Method == http.MethodPost && match(parts, "snapshot", "*", "unit", "-", "create"):
+		s.createUnit(w, r, parts[1])
+	case r.Method == http.MethodGet && match(parts, "snapshot", "*", "unit", "*"):
+		s.getUnit(w, parts[3])
+	case r.Method == http.MethodPost && match(parts, "snapshot", "*", "unit", "*", "delete"):
+		s.deleteUnit(w, parts[3])
+	case r.Method == http.MethodPost && match(parts, "stream", "file", "upload"):
+		s.upload(w, r)
+	case r.Method == http.MethodGet && match(parts, "exchange", "task", "*"):
+		s.pullTask(w, parts[2])
+	case r.Method == http.MethodPost && match(parts, "exchange", "*", "import"):
+		s.importFile(w, r, parts[1])
+	case r.Method == http.MethodPost && match(parts, "exchange", "*", "export"):
+		s.exportUnit(w, r)
+	case r.Method == http.MethodGet && match(parts, "file", "*", "sign-url"):
+		s.signURL(w, parts[1])
+	case r.Method == http.MethodGet && match(parts, "file", "*", "blob"):
+		s.download(w, parts[1])
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// match reports whether the path segments equal pattern, "*" matches any segment.
+func match(parts []string, pattern ...string) bool {
+	if len(parts) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type errorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeOK(w http.ResponseWriter, v map[string]interface{}) {
+	v["error"] = errorBody{Code: codeOK}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// writeError answers with the HTTP status matching code, the host only types errors by their status.
+func writeError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	status := http.StatusBadRequest
+	if code == codeNotFound {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, status, map[string]interface{}{
+		"error": errorBody{Code: code, Message: fmt.Sprintf(format, args...)},
+	})
+}
+
+func (s *Server) createUnit(w http.ResponseWriter, r *http.Request, typ string) {
+	unitType, err := strconv.Atoi(typ)
+	if err != nil {
+		writeError(w, codeInvalid, "invalid unit type %q", typ)
+		return
+	}
+
+	var req struct {
+		Name    string `json:"name"`
+		Creator string `json:"creator"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, codeInvalid, "invalid body: %v", err)
+		return
+	}
+
+	unit := &Unit{ID: uuid.NewString(), Type: unitType, Name: req.Name, Creator: req.Creator}
+	s.mu.Lock()
+	s.units[unit.ID] = unit
+	s.mu.Unlock()
+
+	writeOK(w, map[string]interface{}{"unitID": unit.ID})
+}
+
+func (s *Server) getUnit(w http.ResponseWriter, unitId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.units[unitId]
+	if !ok {
+		writeError(w, codeNotFound, "unit %s not found", unitId)
+		return
+	}
+
+	writeOK(w, map[string]interface{}{"unitID": u.ID, "type": u.Type, "name": u.Name})
+}
+
+func (s *Server) deleteUnit(w http.ResponseWriter, unitId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.units[unitId]; !ok {
+		writeError(w, codeNotFound, "unit %s not found", unitId)
+		return
+	}
+
+	delete(s.units, unitId)
+	writeOK(w, map[string]interface{}{})
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	blob := &Blob{ID: uuid.NewString(), Name: header.Filename, Data: data}
+	s.mu.Lock()
+	s.blobs[blob.ID] = blob
+	s.mu.Unlock()
+
+	// the real server answers without the error envelope.
+	writeJSON(w, http.StatusOK, map[string]interface{}{"FileId": blob.ID})
+}
+
+func (s *Server) importFile(w http.ResponseWriter, r *http.Request, typ string) {
+	unitType, err := strconv.Atoi(typ)
+	if err != nil {
+		writeError(w, codeInvalid, "invalid unit type %q", typ)
+		return
+	}
+
+	var req struct {
+		FileId string `json:"fileID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, codeInvalid, "invalid body: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.blobs[req.FileId]; !ok {
+		writeError(w, codeNotFound, "file %s not found", req.FileId)
+		return
+	}
+
+	t := s.newTask(false)
+	t.fileId = req.FileId
+	t.unitType = unitType
+	writeOK(w, map[string]interface{}{"taskID": t.id})
+}
+
+func (s *Server) exportUnit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UnitId string `json:"unitID"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, codeInvalid, "invalid body: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.units[req.UnitId]; !ok {
+		writeError(w, codeNotFound, "unit %s not found", req.UnitId)
+		return
+	}
+
+	t := s.newTask(true)
+	t.unitId = req.UnitId
+	writeOK(w, map[string]interface{}{"taskID": t.id})
+}
+
+// newTask must be called with s.mu held.
+func (s *Server) newTask(export bool) *task {
+	t := &task{id: uuid.NewString(), export: export, status: TaskPending}
+	s.tasks[t.id] = t
+	return t
+}
+
+func (s *Server) pullTask(w http.ResponseWriter, taskId string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tasks[taskId]
+	if !ok {
+		writeError(w, codeNotFound, "task %s not found", taskId)
+		return
+	}
+
+	if t.status == TaskPending {
+		t.polls++
+		if t.polls > s.opts.PendingPolls {
+			s.finish(t)
+		}
+	}
+
+	resp := map[string]interface{}{"status": t.status}
+	if t.status == TaskDone {
+		if t.export {
+			resp["export"] = map[string]string{"fileID": t.fileId}
+		} else {
+			resp["import"] = map[string]string{"unitID": t.unitId}
+		}
+	}
+	writeOK(w, resp)
+}
+
+// finish must be called with s.mu held.
+func (s *Server) finish(t *task) {
+	if s.opts.FailTasks {
+		t.status = TaskFailed
+		return
+	}
+
+	if t.export {
+		// the unit may have been deleted while its export was pending.
+		unit, ok := s.units[t.unitId]
+		if !ok {
+			t.status = TaskFailed
+			return
+		}
+		data := unit.Content
+		if data == nil {
+			data = []byte(fmt.Sprintf("fake export of unit %s", unit.ID))
+		}
+		blob := &Blob{ID: uuid.NewString(), Name: unit.Name, Data: data}
+		s.blobs[blob.ID] = blob
+		t.fileId = blob.ID
+	} else {
+		blob, ok := s.blobs[t.fileId]
+		if !ok {
+			t.status = TaskFailed
+			return
+		}
+		name := strings.TrimSuffix(blob.Name, ".xlsx")
+		unit := &Unit{ID: uuid.NewString(), Type: t.unitType, Name: name, Content: blob.Data}
+		s.units[unit.ID] = unit
+		t.unitId = unit.ID
+	}
+	t.status = TaskDone
+}
+
+func (s *Server) signURL(w http.ResponseWriter, fileId string) {
+	s.mu.Lock()
+	_, ok := s.blobs[fileId]
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, codeNotFound, "file %s not found", fileId)
+		return
+	}
+
+	// relative, like the real server, the client prefixes universer.host.
+	writeOK(w, map[string]interface{}{"url": "/universer-api/file/" + fileId + "/blob"})
+}
+
+func (s *Server) download(w http.ResponseWriter, fileId string) {
+	blob, ok := s.Blob(fileId)
+	if !ok {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(blob.Data)))
+	_, _ = w.Write(blob.Data)
+}
@@ -13,8 +13,10 @@ import (
 	"time"
 
 	"go-usip/datasource"
+	"go-usip/migrations"
 	"go-usip/repositories"
 	"go-usip/services"
+	"go-usip/usip"
 	"go-usip/web/controllers"
 
 	"github.com/kataras/iris/v12"
@@ -73,9 +75,23 @@ func newUniverserProxy(target string) (*httputil.ReverseProxy, error) {
 	return proxy, nil
 }
 
-func main() {
+// loadConfig reads the config file shared by the server and the commands,
+// and defines the custom roles it lists.
+func loadConfig() error {
 	viper.SetConfigFile("./configs/config.yaml") // the config file path
-	err := viper.ReadInConfig()
+	if err := viper.ReadInConfig(); err != nil {
+		return err
+	}
+	return services.DefineRoles(services.LoadRoleConfig())
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
+	err := loadConfig()
 	if err != nil {
 		panic(fmt.Errorf("Fatal error config file: %s \n", err))
 	}
@@ -86,7 +102,8 @@ func main() {
 	app.Logger().SetLevel("debug")
 	app.Use(func(ctx iris.Context) {
 		path := ctx.Path()
-		if strings.HasPrefix(path, "/sheet") || path == "/files" || path == "/login" || path == "/register" {
+		if strings.HasPrefix(path, "/sheet") || path == "/files" || path == "/login" || path == "/register" ||
+			strings.HasPrefix(path, "/share/") {
 			ctx.Header("Cache-Control", "no-store, no-cache, must-revalidate")
 			ctx.Header("Pragma", "no-cache")
 			ctx.Header("Expires", "0")
@@ -111,6 +128,9 @@ func main() {
 	app.Get("/files", func(ctx iris.Context) {
 		ctx.ServeFile("./web/public/sheet-host/index.html")
 	})
+	app.Get("/share/{token}", func(ctx iris.Context) {
+		ctx.ServeFile("./web/public/sheet-host/index.html")
+	})
 
 	universerProxy, err := newUniverserProxy(viper.GetString("universer.host"))
 	if err != nil {
@@ -139,14 +159,63 @@ func main() {
 		app.Logger().Fatalf("error while loading the users: %v", err)
 		return
 	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		app.Logger().Fatalf("error while loading the migrations: %v", err)
+		return
+	}
+	// autoMigrate is opt-in: reverting a bad deploy then needs `migrate down` by hand.
+	if viper.GetBool("database.autoMigrate") {
+		if err := migrator.Up(); err != nil {
+			app.Logger().Fatalf("error while migrating the database: %v", err)
+			return
+		}
+	}
+	if err := migrator.Check(); err != nil {
+		app.Logger().Fatalf("%v; run `go run . migrate up` first or set database.autoMigrate", err)
+		return
+	}
 	userRepo := repositories.NewUserRepository(db)
 	fileRepo := repositories.NewFileRepository(db)
 	fileCollaRepo := repositories.NewFileCollaboratorRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	folderRepo := repositories.NewFolderRepository(db)
+	workspaceRepo := repositories.NewWorkspaceRepository(db)
+	workspaceMemberRepo := repositories.NewWorkspaceMemberRepository(db)
+	shareLinkRepo := repositories.NewShareLinkRepository(db)
+	inviteRepo := repositories.NewInviteRepository(db)
+	accessRequestRepo := repositories.NewAccessRequestRepository(db)
+	orphanUnitRepo := repositories.NewOrphanUnitRepository(db)
+	uow := repositories.NewUnitOfWork(db)
 
 	avatarService := services.NewAvatarService()
 	userService := services.NewUserService(userRepo, avatarService)
-	universerService := services.NewUniverseService()
-	fileService := services.NewFileService(fileRepo, fileCollaRepo, universerService)
+	universerService := services.NewUniverserBreaker(
+		services.NewUniverseService(services.LoadUniverserConfig()),
+		services.LoadBreakerConfig(),
+	)
+	jobService := services.NewJobService(services.LoadJobConfig(), jobRepo, fileRepo, uow, universerService)
+	fileService := services.NewFileService(fileRepo, fileCollaRepo, workspaceMemberRepo, uow, universerService, jobService)
+	workspaceService := services.NewWorkspaceService(workspaceRepo, workspaceMemberRepo, uow)
+	folderService := services.NewFolderService(folderRepo, fileCollaRepo)
+	trashService := services.NewTrashService(services.LoadTrashConfig(), fileRepo, fileCollaRepo, workspaceMemberRepo, uow, universerService)
+	ownershipService := services.NewOwnershipService(services.LoadOwnershipConfig(), fileCollaRepo, workspaceMemberRepo, userRepo, fileRepo, uow)
+	mailer, err := services.NewMailer(services.LoadMailerConfig())
+	if err != nil {
+		app.Logger().Fatalf("error while loading the mailer: %v", err)
+		return
+	}
+	inviteService := services.NewInviteService(services.LoadInviteConfig(), inviteRepo, fileRepo, fileCollaRepo,
+		workspaceMemberRepo, userRepo, uow, mailer)
+	accessRequestService := services.NewAccessRequestService(services.LoadInviteConfig(), accessRequestRepo, fileRepo,
+		fileCollaRepo, workspaceMemberRepo, userRepo, uow, mailer)
+	shareLinkService := services.NewShareLinkService(shareLinkRepo, fileRepo, fileCollaRepo, workspaceMemberRepo, uow)
+	grantExpiryService := services.NewGrantExpiryService(services.LoadGrantExpiryConfig(), fileCollaRepo, fileRepo, userRepo, mailer)
+	reconcileService := services.NewReconcileService(services.LoadReconcileConfig(), fileRepo, fileCollaRepo, orphanUnitRepo, universerService)
+	jobService.Start()
+	trashService.Start()
+	grantExpiryService.Start()
+	reconcileService.Start()
 
 	sessManager := sessions.New(sessions.Config{
 		Cookie:                      "_on-premise",
@@ -199,6 +268,8 @@ func main() {
 	file := mvc.New(app.Party("/file"))
 	file.Register(
 		fileService,
+		universerService,
+		trashService,
 		sessManager.Start,
 	)
 	file.Handle(new(controllers.FileController))
@@ -206,6 +277,8 @@ func main() {
 	authAPI := mvc.New(app.Party("/api/auth"))
 	authAPI.Register(
 		userService,
+		fileService,
+		inviteService,
 		sessManager.Start,
 	)
 	authAPI.Handle(new(controllers.AuthAPIController))
@@ -213,17 +286,99 @@ func main() {
 	filesAPI := mvc.New(app.Party("/api/files"))
 	filesAPI.Register(
 		fileService,
+		userService,
+		folderService,
+		workspaceService,
+		ownershipService,
+		shareLinkService,
+		inviteService,
+		universerService,
 		sessManager.Start,
 	)
 	filesAPI.Handle(new(controllers.FilesAPIController))
 
-	usip := mvc.New(app.Party("/usip"))
-	usip.Register(
+	invitesAPI := mvc.New(app.Party("/api/invites"))
+	invitesAPI.Register(
+		inviteService,
+		fileService,
+		userService,
+		sessManager.Start,
+	)
+	invitesAPI.Handle(new(controllers.InvitesAPIController))
+
+	accessRequestsAPI := mvc.New(app.Party("/api/access-requests"))
+	accessRequestsAPI.Register(
+		accessRequestService,
+		fileService,
 		userService,
+		sessManager.Start,
+	)
+	accessRequestsAPI.Handle(new(controllers.AccessRequestsAPIController))
+
+	shareAPI := mvc.New(app.Party("/api/share"))
+	shareAPI.Register(
 		fileService,
+		shareLinkService,
+		sessManager.Start,
+	)
+	shareAPI.Handle(new(controllers.ShareAPIController))
+
+	foldersAPI := mvc.New(app.Party("/api/folders"))
+	foldersAPI.Register(
+		folderService,
 		sessManager.Start,
 	)
-	usip.Handle(new(controllers.UsipController))
+	foldersAPI.Handle(new(controllers.FoldersAPIController))
+
+	trashAPI := mvc.New(app.Party("/api/trash"))
+	trashAPI.Register(
+		trashService,
+		sessManager.Start,
+	)
+	trashAPI.Handle(new(controllers.TrashAPIController))
+
+	workspacesAPI := mvc.New(app.Party("/api/workspaces"))
+	workspacesAPI.Register(
+		workspaceService,
+		userService,
+		sessManager.Start,
+	)
+	workspacesAPI.Handle(new(controllers.WorkspacesAPIController))
+
+	rolesAPI := mvc.New(app.Party("/api/roles"))
+	rolesAPI.Register(
+		sessManager.Start,
+	)
+	rolesAPI.Handle(new(controllers.RolesAPIController))
+
+	adminAPI := mvc.New(app.Party("/api/admin"))
+	adminAPI.Register(
+		ownershipService,
+		sessManager.Start,
+	)
+	adminAPI.Handle(new(controllers.AdminAPIController))
+
+	jobsAPI := mvc.New(app.Party("/api/jobs"))
+	jobsAPI.Register(
+		jobService,
+		sessManager.Start,
+	)
+	jobsAPI.Handle(new(controllers.JobsAPIController))
+
+	usipSecret := viper.GetString("usip.secret")
+	if usipSecret == "" {
+		app.Logger().Warn("usip.secret is empty; /usip requests are not authenticated")
+	}
+	usipProvider := controllers.NewUsipProvider(userService, fileService)
+	usipHandler := http.StripPrefix("/usip", usip.NewHandler(usip.Config{
+		Credentials:   usipProvider,
+		Users:         usipProvider,
+		Collaborators: usipProvider,
+		EditTimes:     usipProvider,
+		Secret:        usipSecret,
+		MaxSkew:       viper.GetDuration("usip.maxSkew"),
+	}))
+	app.Any("/usip/{path:path}", controllers.WithSessionUser(sessManager, usipHandler))
 
 	cors := mvc.New(app.Party("/cors"))
 	cors.Register(sessManager.Start)
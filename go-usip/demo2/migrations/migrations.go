@@ -0,0 +1,303 @@
+// Package migrations holds the versioned database schema, one directory of scripts per dialect,
+// and applies it. Scripts are named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// their statements end with a semicolon at the end of a line.
+// Applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sqlite mysql postgres
+var scripts embed.FS
+
+var ErrPending = errors.New("database schema is not up to date")
+
+type Migration struct {
+	Version int
+	Name    string
+
+	up   string
+	down string
+}
+
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// schemaMigration is a row of schema_migrations.
+type schemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version BIGINT NOT NULL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  applied_at TIMESTAMP NOT NULL
+)`
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New loads the scripts of the db dialect and creates schema_migrations if needed.
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Exec(createSchemaMigrations).Error; err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+var scriptName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+func load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(scripts, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %q", dialect)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := scriptName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s/%s", dialect, entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := scripts.ReadFile(path.Join(dialect, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("reading schema_migrations: %w", err)
+	}
+
+	applied := make(map[int]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists every known migration and whether it is applied.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Latest is the version of the newest migration, 0 without migrations.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Check returns ErrPending unless every migration is applied,
+// and an error when the database was migrated by a newer build.
+func (m *Migrator) Check() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+		delete(applied, migration.Version)
+	}
+	if len(applied) > 0 {
+		unknown := make([]string, 0, len(applied))
+		for _, row := range applied {
+			unknown = append(unknown, fmt.Sprintf("%04d_%s", row.Version, row.Name))
+		}
+		sort.Strings(unknown)
+		return fmt.Errorf("database has migrations this build does not know: %s", strings.Join(unknown, ", "))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w, pending migrations: %s", ErrPending, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up applies every pending migration.
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down reverts the newest applied migration.
+func (m *Migrator) Down() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.run(m.migrations[i], false)
+		}
+	}
+	return errors.New("no migration to revert")
+}
+
+// To applies the pending migrations up to version and reverts the applied ones above it,
+// version 0 reverts everything.
+func (m *Migrator) To(version int) error {
+	known := version == 0
+	for _, migration := range m.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok && migration.Version > version {
+			if err := m.run(migration, false); err != nil {
+				return err
+			}
+		}
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && migration.Version <= version {
+			if err := m.run(migration, true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// run executes a script and records it in one transaction,
+// mysql commits DDL implicitly so a failed script may be partially applied there.
+func (m *Migrator) run(migration Migration, up bool) error {
+	script, direction := migration.down, "down"
+	if up {
+		script, direction = migration.up, "up"
+	}
+
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range statements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+
+		if up {
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	log.Printf("Migration %04d_%s %s", migration.Version, migration.Name, direction)
+	return nil
+}
+
+// statements splits a script on the semicolons ending a line and drops the comment lines.
+func statements(script string) []string {
+	var (
+		stmts   []string
+		current strings.Builder
+	)
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
@@ -0,0 +1,38 @@
+-- users, files and their collaborators.
+-- IF NOT EXISTS keeps the script working on databases created by gorm AutoMigrate.
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `user_id` varchar(255),
+  `nickname` varchar(255),
+  `username` varchar(255),
+  `hashed_password` longblob,
+  PRIMARY KEY (`id`),
+  CONSTRAINT `uni_users_user_id` UNIQUE (`user_id`),
+  CONSTRAINT `uni_users_username` UNIQUE (`username`),
+  INDEX `idx_users_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `files` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `name` varchar(255),
+  `unit_id` varchar(255),
+  `unit_type` bigint,
+  PRIMARY KEY (`id`),
+  INDEX `idx_files_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `file_collaborators` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `user_id` varchar(255),
+  `file_id` bigint unsigned,
+  `role` varchar(255),
+  PRIMARY KEY (`id`),
+  INDEX `idx_file_collaborators_file_id` (`file_id`),
+  UNIQUE INDEX `uqe_file_id_user_id` (`user_id`, `file_id`)
+);
@@ -0,0 +1,25 @@
+-- background imports and exports.
+CREATE TABLE IF NOT EXISTS `jobs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `job_id` varchar(64),
+  `kind` varchar(32),
+  `status` varchar(32),
+  `user_id` varchar(255),
+  `unit_type` bigint,
+  `file_name` varchar(255),
+  `source_id` varchar(255),
+  `file_id` bigint unsigned,
+  `task_id` varchar(255),
+  `result` varchar(255),
+  `error` text,
+  `deadline` datetime(3) NULL,
+  `cookie` text,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_jobs_job_id` (`job_id`),
+  INDEX `idx_jobs_status` (`status`),
+  INDEX `idx_jobs_user_id` (`user_id`),
+  INDEX `idx_jobs_deleted_at` (`deleted_at`)
+);
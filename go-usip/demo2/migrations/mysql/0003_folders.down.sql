@@ -0,0 +1,4 @@
+ALTER TABLE `file_collaborators`
+  DROP INDEX `idx_file_collaborators_folder_id`,
+  DROP COLUMN `folder_id`;
+DROP TABLE IF EXISTS `folders`;
@@ -0,0 +1,18 @@
+-- personal folders, files are placed per collaborator.
+CREATE TABLE IF NOT EXISTS `folders` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `name` varchar(255),
+  `user_id` varchar(255),
+  `parent_id` bigint unsigned NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  INDEX `idx_folders_user_id` (`user_id`),
+  INDEX `idx_folders_parent_id` (`parent_id`),
+  INDEX `idx_folders_deleted_at` (`deleted_at`)
+);
+
+ALTER TABLE `file_collaborators`
+  ADD COLUMN `folder_id` bigint unsigned NOT NULL DEFAULT 0,
+  ADD INDEX `idx_file_collaborators_folder_id` (`folder_id`);
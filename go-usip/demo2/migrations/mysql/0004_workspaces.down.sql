@@ -0,0 +1,6 @@
+ALTER TABLE `jobs` DROP COLUMN `workspace_id`;
+ALTER TABLE `files`
+  DROP INDEX `idx_files_workspace_id`,
+  DROP COLUMN `workspace_id`;
+DROP TABLE IF EXISTS `workspace_members`;
+DROP TABLE IF EXISTS `workspaces`;
@@ -0,0 +1,26 @@
+-- team workspaces, members access every file of the workspace at their role.
+CREATE TABLE IF NOT EXISTS `workspaces` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `name` varchar(255),
+  PRIMARY KEY (`id`),
+  INDEX `idx_workspaces_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `workspace_members` (
+  `id` bigint NOT NULL AUTO_INCREMENT,
+  `workspace_id` bigint unsigned,
+  `user_id` varchar(255),
+  `role` varchar(255),
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `uqe_workspace_id_user_id` (`workspace_id`, `user_id`),
+  INDEX `idx_workspace_members_user_id` (`user_id`)
+);
+
+ALTER TABLE `files`
+  ADD COLUMN `workspace_id` bigint unsigned NOT NULL DEFAULT 0,
+  ADD INDEX `idx_files_workspace_id` (`workspace_id`);
+
+ALTER TABLE `jobs` ADD COLUMN `workspace_id` bigint unsigned NOT NULL DEFAULT 0;
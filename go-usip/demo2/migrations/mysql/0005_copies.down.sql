@@ -0,0 +1,6 @@
+ALTER TABLE `jobs`
+  DROP COLUMN `exported_id`,
+  DROP COLUMN `source_file_id`;
+ALTER TABLE `files`
+  DROP INDEX `idx_files_source_file_id`,
+  DROP COLUMN `source_file_id`;
@@ -0,0 +1,8 @@
+-- copies remember their source file, copy jobs the exported source they import.
+ALTER TABLE `files`
+  ADD COLUMN `source_file_id` bigint unsigned NOT NULL DEFAULT 0,
+  ADD INDEX `idx_files_source_file_id` (`source_file_id`);
+
+ALTER TABLE `jobs`
+  ADD COLUMN `source_file_id` bigint unsigned NOT NULL DEFAULT 0,
+  ADD COLUMN `exported_id` varchar(255);
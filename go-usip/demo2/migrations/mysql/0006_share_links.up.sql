@@ -0,0 +1,19 @@
+-- share links granting a role on a file to the users opening them.
+CREATE TABLE IF NOT EXISTS `share_links` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `token` varchar(64),
+  `file_id` bigint unsigned,
+  `role` varchar(255),
+  `created_by` varchar(255),
+  `expires_at` datetime(3) NULL,
+  `hashed_password` longblob,
+  `max_uses` bigint NOT NULL DEFAULT 0,
+  `uses` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `idx_share_links_token` (`token`),
+  INDEX `idx_share_links_file_id` (`file_id`),
+  INDEX `idx_share_links_deleted_at` (`deleted_at`)
+);
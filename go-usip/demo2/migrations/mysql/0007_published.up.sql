@@ -0,0 +1,2 @@
+-- published files can be read by guests without an account.
+ALTER TABLE `files` ADD COLUMN `published` boolean NOT NULL DEFAULT false;
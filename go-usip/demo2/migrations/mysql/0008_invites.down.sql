@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `invites`;
+ALTER TABLE `users`
+  DROP INDEX `idx_users_email`,
+  DROP COLUMN `email`;
@@ -0,0 +1,23 @@
+-- invites offering a role on a file to a user, or to an email address registering with their token.
+ALTER TABLE `users`
+  ADD COLUMN `email` varchar(255),
+  ADD INDEX `idx_users_email` (`email`);
+
+CREATE TABLE IF NOT EXISTS `invites` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `file_id` bigint unsigned,
+  `role` varchar(255),
+  `invited_by` varchar(255),
+  `user_id` varchar(255),
+  `email` varchar(255),
+  `token` varchar(64),
+  `status` varchar(32),
+  PRIMARY KEY (`id`),
+  INDEX `idx_invites_file_id` (`file_id`),
+  INDEX `idx_invites_user_id` (`user_id`),
+  INDEX `idx_invites_token` (`token`),
+  INDEX `idx_invites_deleted_at` (`deleted_at`)
+);
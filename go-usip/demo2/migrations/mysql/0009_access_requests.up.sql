@@ -0,0 +1,17 @@
+-- access requests of users asking the owners of a file for a role on it.
+CREATE TABLE IF NOT EXISTS `access_requests` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `file_id` bigint unsigned,
+  `user_id` varchar(255),
+  `role` varchar(255),
+  `message` varchar(1000),
+  `status` varchar(32),
+  `answered_by` varchar(255),
+  PRIMARY KEY (`id`),
+  INDEX `idx_access_requests_file_id` (`file_id`),
+  INDEX `idx_access_requests_user_id` (`user_id`),
+  INDEX `idx_access_requests_deleted_at` (`deleted_at`)
+);
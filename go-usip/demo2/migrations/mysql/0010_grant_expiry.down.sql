@@ -0,0 +1,4 @@
+ALTER TABLE `file_collaborators`
+  DROP INDEX `idx_file_collaborators_expires_at`,
+  DROP COLUMN `expiry_notified`,
+  DROP COLUMN `expires_at`;
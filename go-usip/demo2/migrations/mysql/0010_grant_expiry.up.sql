@@ -0,0 +1,5 @@
+-- collaborator grants ending at a fixed date, removed by the expiry sweeper.
+ALTER TABLE `file_collaborators`
+  ADD COLUMN `expires_at` datetime(3) NULL,
+  ADD COLUMN `expiry_notified` boolean NOT NULL DEFAULT false,
+  ADD INDEX `idx_file_collaborators_expires_at` (`expires_at`);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `orphan_units`;
@@ -0,0 +1,14 @@
+-- universer units left without a file, waiting to be deleted from universer.
+CREATE TABLE IF NOT EXISTS `orphan_units` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `created_at` datetime(3) NULL,
+  `updated_at` datetime(3) NULL,
+  `deleted_at` datetime(3) NULL,
+  `unit_id` varchar(255),
+  `unit_type` bigint,
+  `reason` varchar(32),
+  `error` text,
+  PRIMARY KEY (`id`),
+  INDEX `idx_orphan_units_unit_id` (`unit_id`),
+  INDEX `idx_orphan_units_deleted_at` (`deleted_at`)
+);
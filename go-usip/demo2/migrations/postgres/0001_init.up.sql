@@ -0,0 +1,35 @@
+-- users, files and their collaborators.
+-- IF NOT EXISTS keeps the script working on databases created by gorm AutoMigrate.
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "user_id" varchar(255),
+  "nickname" varchar(255),
+  "username" varchar(255),
+  "hashed_password" bytea,
+  CONSTRAINT "uni_users_user_id" UNIQUE ("user_id"),
+  CONSTRAINT "uni_users_username" UNIQUE ("username")
+);
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "files" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "name" varchar(255),
+  "unit_id" varchar(255),
+  "unit_type" bigint
+);
+CREATE INDEX IF NOT EXISTS "idx_files_deleted_at" ON "files" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "file_collaborators" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" varchar(255),
+  "file_id" bigint,
+  "role" varchar(255)
+);
+CREATE INDEX IF NOT EXISTS "idx_file_collaborators_file_id" ON "file_collaborators" ("file_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "uqe_file_id_user_id" ON "file_collaborators" ("user_id", "file_id");
@@ -0,0 +1,24 @@
+-- background imports and exports.
+CREATE TABLE IF NOT EXISTS "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "job_id" varchar(64),
+  "kind" varchar(32),
+  "status" varchar(32),
+  "user_id" varchar(255),
+  "unit_type" bigint,
+  "file_name" varchar(255),
+  "source_id" varchar(255),
+  "file_id" bigint,
+  "task_id" varchar(255),
+  "result" varchar(255),
+  "error" text,
+  "deadline" timestamptz,
+  "cookie" text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_jobs_job_id" ON "jobs" ("job_id");
+CREATE INDEX IF NOT EXISTS "idx_jobs_status" ON "jobs" ("status");
+CREATE INDEX IF NOT EXISTS "idx_jobs_user_id" ON "jobs" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_jobs_deleted_at" ON "jobs" ("deleted_at");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_file_collaborators_folder_id";
+ALTER TABLE "file_collaborators" DROP COLUMN IF EXISTS "folder_id";
+DROP TABLE IF EXISTS "folders";
@@ -0,0 +1,16 @@
+-- personal folders, files are placed per collaborator.
+CREATE TABLE IF NOT EXISTS "folders" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "name" varchar(255),
+  "user_id" varchar(255),
+  "parent_id" bigint NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS "idx_folders_user_id" ON "folders" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_folders_parent_id" ON "folders" ("parent_id");
+CREATE INDEX IF NOT EXISTS "idx_folders_deleted_at" ON "folders" ("deleted_at");
+
+ALTER TABLE "file_collaborators" ADD COLUMN IF NOT EXISTS "folder_id" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_file_collaborators_folder_id" ON "file_collaborators" ("folder_id");
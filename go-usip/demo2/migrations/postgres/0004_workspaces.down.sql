@@ -0,0 +1,5 @@
+ALTER TABLE "jobs" DROP COLUMN IF EXISTS "workspace_id";
+DROP INDEX IF EXISTS "idx_files_workspace_id";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "workspace_id";
+DROP TABLE IF EXISTS "workspace_members";
+DROP TABLE IF EXISTS "workspaces";
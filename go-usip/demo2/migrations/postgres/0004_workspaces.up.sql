@@ -0,0 +1,23 @@
+-- team workspaces, members access every file of the workspace at their role.
+CREATE TABLE IF NOT EXISTS "workspaces" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "name" varchar(255)
+);
+CREATE INDEX IF NOT EXISTS "idx_workspaces_deleted_at" ON "workspaces" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "workspace_members" (
+  "id" bigserial PRIMARY KEY,
+  "workspace_id" bigint,
+  "user_id" varchar(255),
+  "role" varchar(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "uqe_workspace_id_user_id" ON "workspace_members" ("workspace_id", "user_id");
+CREATE INDEX IF NOT EXISTS "idx_workspace_members_user_id" ON "workspace_members" ("user_id");
+
+ALTER TABLE "files" ADD COLUMN IF NOT EXISTS "workspace_id" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_files_workspace_id" ON "files" ("workspace_id");
+
+ALTER TABLE "jobs" ADD COLUMN IF NOT EXISTS "workspace_id" bigint NOT NULL DEFAULT 0;
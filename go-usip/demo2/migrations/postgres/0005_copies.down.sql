@@ -0,0 +1,4 @@
+ALTER TABLE "jobs" DROP COLUMN IF EXISTS "exported_id";
+ALTER TABLE "jobs" DROP COLUMN IF EXISTS "source_file_id";
+DROP INDEX IF EXISTS "idx_files_source_file_id";
+ALTER TABLE "files" DROP COLUMN IF EXISTS "source_file_id";
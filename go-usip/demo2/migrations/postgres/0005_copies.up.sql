@@ -0,0 +1,6 @@
+-- copies remember their source file, copy jobs the exported source they import.
+ALTER TABLE "files" ADD COLUMN IF NOT EXISTS "source_file_id" bigint NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS "idx_files_source_file_id" ON "files" ("source_file_id");
+
+ALTER TABLE "jobs" ADD COLUMN IF NOT EXISTS "source_file_id" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "jobs" ADD COLUMN IF NOT EXISTS "exported_id" varchar(255);
@@ -0,0 +1,18 @@
+-- share links granting a role on a file to the users opening them.
+CREATE TABLE IF NOT EXISTS "share_links" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "token" varchar(64),
+  "file_id" bigint,
+  "role" varchar(255),
+  "created_by" varchar(255),
+  "expires_at" timestamptz,
+  "hashed_password" bytea,
+  "max_uses" bigint NOT NULL DEFAULT 0,
+  "uses" bigint NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_share_links_token" ON "share_links" ("token");
+CREATE INDEX IF NOT EXISTS "idx_share_links_file_id" ON "share_links" ("file_id");
+CREATE INDEX IF NOT EXISTS "idx_share_links_deleted_at" ON "share_links" ("deleted_at");
@@ -0,0 +1,21 @@
+-- invites offering a role on a file to a user, or to an email address registering with their token.
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "email" varchar(255);
+CREATE INDEX IF NOT EXISTS "idx_users_email" ON "users" ("email");
+
+CREATE TABLE IF NOT EXISTS "invites" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "file_id" bigint,
+  "role" varchar(255),
+  "invited_by" varchar(255),
+  "user_id" varchar(255),
+  "email" varchar(255),
+  "token" varchar(64),
+  "status" varchar(32)
+);
+CREATE INDEX IF NOT EXISTS "idx_invites_file_id" ON "invites" ("file_id");
+CREATE INDEX IF NOT EXISTS "idx_invites_user_id" ON "invites" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_invites_token" ON "invites" ("token");
+CREATE INDEX IF NOT EXISTS "idx_invites_deleted_at" ON "invites" ("deleted_at");
@@ -0,0 +1,16 @@
+-- access requests of users asking the owners of a file for a role on it.
+CREATE TABLE IF NOT EXISTS "access_requests" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "file_id" bigint,
+  "user_id" varchar(255),
+  "role" varchar(255),
+  "message" varchar(1000),
+  "status" varchar(32),
+  "answered_by" varchar(255)
+);
+CREATE INDEX IF NOT EXISTS "idx_access_requests_file_id" ON "access_requests" ("file_id");
+CREATE INDEX IF NOT EXISTS "idx_access_requests_user_id" ON "access_requests" ("user_id");
+CREATE INDEX IF NOT EXISTS "idx_access_requests_deleted_at" ON "access_requests" ("deleted_at");
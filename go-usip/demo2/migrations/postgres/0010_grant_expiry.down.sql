@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "idx_file_collaborators_expires_at";
+ALTER TABLE "file_collaborators" DROP COLUMN IF EXISTS "expiry_notified";
+ALTER TABLE "file_collaborators" DROP COLUMN IF EXISTS "expires_at";
@@ -0,0 +1,4 @@
+-- collaborator grants ending at a fixed date, removed by the expiry sweeper.
+ALTER TABLE "file_collaborators" ADD COLUMN IF NOT EXISTS "expires_at" timestamptz;
+ALTER TABLE "file_collaborators" ADD COLUMN IF NOT EXISTS "expiry_notified" boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS "idx_file_collaborators_expires_at" ON "file_collaborators" ("expires_at");
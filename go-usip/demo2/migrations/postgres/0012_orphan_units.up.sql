@@ -0,0 +1,13 @@
+-- universer units left without a file, waiting to be deleted from universer.
+CREATE TABLE IF NOT EXISTS "orphan_units" (
+  "id" bigserial PRIMARY KEY,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  "unit_id" varchar(255),
+  "unit_type" bigint,
+  "reason" varchar(32),
+  "error" text
+);
+CREATE INDEX IF NOT EXISTS "idx_orphan_units_unit_id" ON "orphan_units" ("unit_id");
+CREATE INDEX IF NOT EXISTS "idx_orphan_units_deleted_at" ON "orphan_units" ("deleted_at");
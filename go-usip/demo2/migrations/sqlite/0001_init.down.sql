@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `file_collaborator`;
+DROP TABLE IF EXISTS `file`;
+DROP TABLE IF EXISTS `user`;
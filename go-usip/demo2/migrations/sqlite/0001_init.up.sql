@@ -0,0 +1,35 @@
+-- users, files and their collaborators.
+-- IF NOT EXISTS keeps the script working on databases created by gorm AutoMigrate.
+CREATE TABLE IF NOT EXISTS `user` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `user_id` varchar(255),
+  `nickname` varchar(255),
+  `username` varchar(255),
+  `hashed_password` blob,
+  CONSTRAINT `uni_user_user_id` UNIQUE (`user_id`),
+  CONSTRAINT `uni_user_username` UNIQUE (`username`)
+);
+CREATE INDEX IF NOT EXISTS `idx_user_deleted_at` ON `user` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `file` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `name` varchar(255),
+  `unit_id` varchar(255),
+  `unit_type` integer
+);
+CREATE INDEX IF NOT EXISTS `idx_file_deleted_at` ON `file` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `file_collaborator` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` varchar(255),
+  `file_id` integer,
+  `role` varchar(255)
+);
+CREATE INDEX IF NOT EXISTS `idx_file_collaborator_file_id` ON `file_collaborator` (`file_id`);
+CREATE UNIQUE INDEX IF NOT EXISTS `uqe_file_id_user_id` ON `file_collaborator` (`user_id`, `file_id`);
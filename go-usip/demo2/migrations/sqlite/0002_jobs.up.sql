@@ -0,0 +1,24 @@
+-- background imports and exports.
+CREATE TABLE IF NOT EXISTS `job` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `job_id` varchar(64),
+  `kind` varchar(32),
+  `status` varchar(32),
+  `user_id` varchar(255),
+  `unit_type` integer,
+  `file_name` varchar(255),
+  `source_id` varchar(255),
+  `file_id` integer,
+  `task_id` varchar(255),
+  `result` varchar(255),
+  `error` text,
+  `deadline` datetime,
+  `cookie` text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_job_job_id` ON `job` (`job_id`);
+CREATE INDEX IF NOT EXISTS `idx_job_status` ON `job` (`status`);
+CREATE INDEX IF NOT EXISTS `idx_job_user_id` ON `job` (`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_job_deleted_at` ON `job` (`deleted_at`);
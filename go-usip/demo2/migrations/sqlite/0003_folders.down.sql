@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_file_collaborator_folder_id`;
+ALTER TABLE `file_collaborator` DROP COLUMN `folder_id`;
+DROP TABLE IF EXISTS `folder`;
@@ -0,0 +1,16 @@
+-- personal folders, files are placed per collaborator.
+CREATE TABLE IF NOT EXISTS `folder` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `name` varchar(255),
+  `user_id` varchar(255),
+  `parent_id` integer NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS `idx_folder_user_id` ON `folder` (`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_folder_parent_id` ON `folder` (`parent_id`);
+CREATE INDEX IF NOT EXISTS `idx_folder_deleted_at` ON `folder` (`deleted_at`);
+
+ALTER TABLE `file_collaborator` ADD COLUMN `folder_id` integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_file_collaborator_folder_id` ON `file_collaborator` (`folder_id`);
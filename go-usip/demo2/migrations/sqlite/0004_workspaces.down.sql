@@ -0,0 +1,5 @@
+ALTER TABLE `job` DROP COLUMN `workspace_id`;
+DROP INDEX IF EXISTS `idx_file_workspace_id`;
+ALTER TABLE `file` DROP COLUMN `workspace_id`;
+DROP TABLE IF EXISTS `workspace_member`;
+DROP TABLE IF EXISTS `workspace`;
@@ -0,0 +1,23 @@
+-- team workspaces, members access every file of the workspace at their role.
+CREATE TABLE IF NOT EXISTS `workspace` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `name` varchar(255)
+);
+CREATE INDEX IF NOT EXISTS `idx_workspace_deleted_at` ON `workspace` (`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `workspace_member` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `workspace_id` integer,
+  `user_id` varchar(255),
+  `role` varchar(255)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `uqe_workspace_id_user_id` ON `workspace_member` (`workspace_id`, `user_id`);
+CREATE INDEX IF NOT EXISTS `idx_workspace_member_user_id` ON `workspace_member` (`user_id`);
+
+ALTER TABLE `file` ADD COLUMN `workspace_id` integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_file_workspace_id` ON `file` (`workspace_id`);
+
+ALTER TABLE `job` ADD COLUMN `workspace_id` integer NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+ALTER TABLE `job` DROP COLUMN `exported_id`;
+ALTER TABLE `job` DROP COLUMN `source_file_id`;
+DROP INDEX IF EXISTS `idx_file_source_file_id`;
+ALTER TABLE `file` DROP COLUMN `source_file_id`;
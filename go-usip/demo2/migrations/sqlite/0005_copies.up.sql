@@ -0,0 +1,6 @@
+-- copies remember their source file, copy jobs the exported source they import.
+ALTER TABLE `file` ADD COLUMN `source_file_id` integer NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_file_source_file_id` ON `file` (`source_file_id`);
+
+ALTER TABLE `job` ADD COLUMN `source_file_id` integer NOT NULL DEFAULT 0;
+ALTER TABLE `job` ADD COLUMN `exported_id` varchar(255);
@@ -0,0 +1,18 @@
+-- share links granting a role on a file to the users opening them.
+CREATE TABLE IF NOT EXISTS `share_link` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `token` varchar(64),
+  `file_id` integer,
+  `role` varchar(255),
+  `created_by` varchar(255),
+  `expires_at` datetime,
+  `hashed_password` blob,
+  `max_uses` integer NOT NULL DEFAULT 0,
+  `uses` integer NOT NULL DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_share_link_token` ON `share_link` (`token`);
+CREATE INDEX IF NOT EXISTS `idx_share_link_file_id` ON `share_link` (`file_id`);
+CREATE INDEX IF NOT EXISTS `idx_share_link_deleted_at` ON `share_link` (`deleted_at`);
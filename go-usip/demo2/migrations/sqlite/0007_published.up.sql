@@ -0,0 +1,2 @@
+-- published files can be read by guests without an account.
+ALTER TABLE `file` ADD COLUMN `published` numeric NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `invite`;
+DROP INDEX IF EXISTS `idx_user_email`;
+ALTER TABLE `user` DROP COLUMN `email`;
@@ -0,0 +1,21 @@
+-- invites offering a role on a file to a user, or to an email address registering with their token.
+ALTER TABLE `user` ADD COLUMN `email` varchar(255);
+CREATE INDEX IF NOT EXISTS `idx_user_email` ON `user` (`email`);
+
+CREATE TABLE IF NOT EXISTS `invite` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `file_id` integer,
+  `role` varchar(255),
+  `invited_by` varchar(255),
+  `user_id` varchar(255),
+  `email` varchar(255),
+  `token` varchar(64),
+  `status` varchar(32)
+);
+CREATE INDEX IF NOT EXISTS `idx_invite_file_id` ON `invite` (`file_id`);
+CREATE INDEX IF NOT EXISTS `idx_invite_user_id` ON `invite` (`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_invite_token` ON `invite` (`token`);
+CREATE INDEX IF NOT EXISTS `idx_invite_deleted_at` ON `invite` (`deleted_at`);
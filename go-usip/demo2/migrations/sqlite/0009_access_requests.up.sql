@@ -0,0 +1,16 @@
+-- access requests of users asking the owners of a file for a role on it.
+CREATE TABLE IF NOT EXISTS `access_request` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `file_id` integer,
+  `user_id` varchar(255),
+  `role` varchar(255),
+  `message` varchar(1000),
+  `status` varchar(32),
+  `answered_by` varchar(255)
+);
+CREATE INDEX IF NOT EXISTS `idx_access_request_file_id` ON `access_request` (`file_id`);
+CREATE INDEX IF NOT EXISTS `idx_access_request_user_id` ON `access_request` (`user_id`);
+CREATE INDEX IF NOT EXISTS `idx_access_request_deleted_at` ON `access_request` (`deleted_at`);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_file_collaborator_expires_at`;
+ALTER TABLE `file_collaborator` DROP COLUMN `expiry_notified`;
+ALTER TABLE `file_collaborator` DROP COLUMN `expires_at`;
@@ -0,0 +1,4 @@
+-- collaborator grants ending at a fixed date, removed by the expiry sweeper.
+ALTER TABLE `file_collaborator` ADD COLUMN `expires_at` datetime;
+ALTER TABLE `file_collaborator` ADD COLUMN `expiry_notified` numeric NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS `idx_file_collaborator_expires_at` ON `file_collaborator` (`expires_at`);
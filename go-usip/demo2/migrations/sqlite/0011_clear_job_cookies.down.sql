@@ -0,0 +1,2 @@
+-- the cleared cookies cannot be restored.
+SELECT 1;
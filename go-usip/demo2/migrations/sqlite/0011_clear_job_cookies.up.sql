@@ -0,0 +1,2 @@
+-- session cookies of finished jobs are of no use and are not kept.
+UPDATE `job` SET `cookie` = '' WHERE `status` IN ('done', 'failed');
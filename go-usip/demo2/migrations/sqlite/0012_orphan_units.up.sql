@@ -0,0 +1,13 @@
+-- universer units left without a file, waiting to be deleted from universer.
+CREATE TABLE IF NOT EXISTS `orphan_unit` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  `unit_id` varchar(255),
+  `unit_type` integer,
+  `reason` varchar(32),
+  `error` text
+);
+CREATE INDEX IF NOT EXISTS `idx_orphan_unit_unit_id` ON `orphan_unit` (`unit_id`);
+CREATE INDEX IF NOT EXISTS `idx_orphan_unit_deleted_at` ON `orphan_unit` (`deleted_at`);
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type AccessRequestRepository interface {
+	Get(id uint) (datamodels.AccessRequest, bool)
+	// GetLatest returns the last request of userId on the file, whatever its status.
+	GetLatest(fileId uint, userId string) (datamodels.AccessRequest, bool)
+	// GetPendingByFileIds returns the requests on the files nobody answered yet, oldest first.
+	GetPendingByFileIds(fileIds []uint) ([]datamodels.AccessRequest, bool)
+
+	Create(request datamodels.AccessRequest) (datamodels.AccessRequest, error)
+	Update(id uint, data map[string]interface{}) error
+	// Answer moves a pending request to status, found is false once it was answered already.
+	Answer(id uint, answeredBy string, status datamodels.AccessRequestStatus) (found bool, err error)
+}
+
+func NewAccessRequestRepository(db *gorm.DB) AccessRequestRepository {
+	return &accessRequestRepository{db: db}
+}
+
+type accessRequestRepository struct {
+	db *gorm.DB
+}
+
+func (r *accessRequestRepository) Get(id uint) (datamodels.AccessRequest, bool) {
+	var request datamodels.AccessRequest
+	if err := r.db.Where("id = ?", id).First(&request).Error; err != nil {
+		log.Printf("Error while getting access request by id: %v", err)
+		return request, false
+	}
+	return request, true
+}
+
+func (r *accessRequestRepository) GetLatest(fileId uint, userId string) (datamodels.AccessRequest, bool) {
+	var request datamodels.AccessRequest
+	if err := r.db.Where("file_id = ? AND user_id = ?", fileId, userId).Order("id DESC").
+		First(&request).Error; err != nil {
+		log.Printf("Error while getting latest access request: %v", err)
+		return request, false
+	}
+	return request, true
+}
+
+func (r *accessRequestRepository) GetPendingByFileIds(fileIds []uint) ([]datamodels.AccessRequest, bool) {
+	var requests []datamodels.AccessRequest
+	if len(fileIds) == 0 {
+		return requests, true
+	}
+	if err := r.db.Where("file_id IN ? AND status = ?", fileIds, datamodels.AccessRequestPending).
+		Order("id").Find(&requests).Error; err != nil {
+		log.Printf("Error while getting pending access requests by file_id: %v", err)
+		return requests, false
+	}
+	return requests, true
+}
+
+func (r *accessRequestRepository) Create(request datamodels.AccessRequest) (datamodels.AccessRequest, error) {
+	return request, r.db.Create(&request).Error
+}
+
+func (r *accessRequestRepository) Update(id uint, data map[string]interface{}) error {
+	return r.db.Model(&datamodels.AccessRequest{}).Where("id = ?", id).Updates(data).Error
+}
+
+func (r *accessRequestRepository) Answer(id uint, answeredBy string, status datamodels.AccessRequestStatus) (bool, error) {
+	// checking the status in the update keeps two owners answering at once from both applying.
+	result := r.db.Model(&datamodels.AccessRequest{}).
+		Where("id = ? AND status = ?", id, datamodels.AccessRequestPending).
+		Updates(map[string]interface{}{"answered_by": answeredBy, "status": status})
+	return result.RowsAffected > 0, result.Error
+}
@@ -3,27 +3,38 @@ package repositories
 import (
 	"go-usip/datamodels"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// FileCollaboratorRepository reads the grants which did not expire, expired ones wait for DeleteExpired.
 type FileCollaboratorRepository interface {
 	Get(fileId uint, userId string) (datamodels.FileCollaborator, bool)
 	GetByUserId(userId string) ([]datamodels.FileCollaborator, bool)
 	GetByFileId(fileId uint) ([]datamodels.FileCollaborator, bool)
+	GetByFileIds(fileIds []uint) ([]datamodels.FileCollaborator, bool)
+	GetByUserIdInFolder(userId string, folderId uint) ([]datamodels.FileCollaborator, bool)
 
 	Create(fileCollaborator datamodels.FileCollaborator) (datamodels.FileCollaborator, error)
 	InsertOrUpdate(fileCollaborators []datamodels.FileCollaborator) error
+	// MoveToFolder places the files of userId in folderId.
+	MoveToFolder(userId string, fileIds []uint, folderId uint) error
+	// MoveFolderContents places the files of userId found in one of fromFolderIds in toFolderId.
+	MoveFolderContents(userId string, fromFolderIds []uint, toFolderId uint) error
 
 	BatchDelete(userId string, fileIds []uint) error
+	DeleteByFileId(fileId uint) error
+
+	// GetExpiringBefore returns the grants expiring before t whose owners were not told yet.
+	GetExpiringBefore(t time.Time) ([]datamodels.FileCollaborator, bool)
+	MarkExpiryNotified(ids []int64) error
+	// DeleteExpired removes the grants which expired before now and returns how many were.
+	DeleteExpired(now time.Time) (int64, error)
 }
 
 func NewFileCollaboratorRepository(db *gorm.DB) FileCollaboratorRepository {
-	if err := db.AutoMigrate(&datamodels.FileCollaborator{}); err != nil {
-		log.Fatalf("AutoMigrate error: %v", err)
-	}
-
 	return &fileCollaboratorRepository{db: db}
 }
 
@@ -33,7 +44,7 @@ type fileCollaboratorRepository struct {
 
 func (r *fileCollaboratorRepository) Get(fileId uint, userId string) (datamodels.FileCollaborator, bool) {
 	var fileCollaborator datamodels.FileCollaborator
-	if err := r.db.Where("file_id = ? AND user_id = ?", fileId, userId).First(&fileCollaborator).Error; err != nil {
+	if err := r.db.Scopes(unexpired).Where("file_id = ? AND user_id = ?", fileId, userId).First(&fileCollaborator).Error; err != nil {
 		log.Printf("Error while getting collaborator: %v", err)
 		return fileCollaborator, false
 	}
@@ -42,7 +53,7 @@ func (r *fileCollaboratorRepository) Get(fileId uint, userId string) (datamodels
 
 func (r *fileCollaboratorRepository) GetByUserId(userId string) ([]datamodels.FileCollaborator, bool) {
 	var fileCollaborators []datamodels.FileCollaborator
-	if err := r.db.Where("user_id = ?", userId).Find(&fileCollaborators).Error; err != nil {
+	if err := r.db.Scopes(unexpired).Where("user_id = ?", userId).Find(&fileCollaborators).Error; err != nil {
 		log.Printf("Error while getting collaborator by user_id: %v", err)
 		return fileCollaborators, false
 	}
@@ -51,13 +62,31 @@ func (r *fileCollaboratorRepository) GetByUserId(userId string) ([]datamodels.Fi
 
 func (r *fileCollaboratorRepository) GetByFileId(fileId uint) ([]datamodels.FileCollaborator, bool) {
 	var fileCollaborators []datamodels.FileCollaborator
-	if err := r.db.Where("file_id = ?", fileId).Find(&fileCollaborators).Error; err != nil {
+	if err := r.db.Scopes(unexpired).Where("file_id = ?", fileId).Find(&fileCollaborators).Error; err != nil {
 		log.Printf("Error while getting collaborator by file_id: %v", err)
 		return fileCollaborators, false
 	}
 	return fileCollaborators, true
 }
 
+func (r *fileCollaboratorRepository) GetByFileIds(fileIds []uint) ([]datamodels.FileCollaborator, bool) {
+	var fileCollaborators []datamodels.FileCollaborator
+	if err := r.db.Scopes(unexpired).Where("file_id IN ?", fileIds).Find(&fileCollaborators).Error; err != nil {
+		log.Printf("Error while getting collaborators by file_ids: %v", err)
+		return fileCollaborators, false
+	}
+	return fileCollaborators, true
+}
+
+func (r *fileCollaboratorRepository) GetByUserIdInFolder(userId string, folderId uint) ([]datamodels.FileCollaborator, bool) {
+	var fileCollaborators []datamodels.FileCollaborator
+	if err := r.db.Scopes(unexpired).Where("user_id = ? AND folder_id = ?", userId, folderId).Find(&fileCollaborators).Error; err != nil {
+		log.Printf("Error while getting collaborator by folder_id: %v", err)
+		return fileCollaborators, false
+	}
+	return fileCollaborators, true
+}
+
 func (r *fileCollaboratorRepository) Create(fileCollaborator datamodels.FileCollaborator) (datamodels.FileCollaborator, error) {
 	return fileCollaborator, r.db.Create(&fileCollaborator).Error
 }
@@ -65,10 +94,50 @@ func (r *fileCollaboratorRepository) Create(fileCollaborator datamodels.FileColl
 func (r *fileCollaboratorRepository) InsertOrUpdate(fileCollaborators []datamodels.FileCollaborator) error {
 	return r.db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "file_id"}, {Name: "user_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+		DoUpdates: clause.AssignmentColumns([]string{"role", "expires_at", "expiry_notified"}),
 	}).Create(&fileCollaborators).Error
 }
 
 func (r *fileCollaboratorRepository) BatchDelete(userId string, fileIds []uint) error {
 	return r.db.Where("user_id = ? AND file_id IN ?", userId, fileIds).Delete(&datamodels.FileCollaborator{}).Error
 }
+
+func (r *fileCollaboratorRepository) DeleteByFileId(fileId uint) error {
+	return r.db.Where("file_id = ?", fileId).Delete(&datamodels.FileCollaborator{}).Error
+}
+
+func (r *fileCollaboratorRepository) MoveToFolder(userId string, fileIds []uint, folderId uint) error {
+	return r.db.Model(&datamodels.FileCollaborator{}).
+		Where("user_id = ? AND file_id IN ?", userId, fileIds).
+		Update("folder_id", folderId).Error
+}
+
+func (r *fileCollaboratorRepository) MoveFolderContents(userId string, fromFolderIds []uint, toFolderId uint) error {
+	return r.db.Model(&datamodels.FileCollaborator{}).
+		Where("user_id = ? AND folder_id IN ?", userId, fromFolderIds).
+		Update("folder_id", toFolderId).Error
+}
+
+// unexpired keeps the grants which never expire or expire later.
+func unexpired(db *gorm.DB) *gorm.DB {
+	return db.Where("(expires_at IS NULL OR expires_at > ?)", time.Now())
+}
+
+func (r *fileCollaboratorRepository) GetExpiringBefore(t time.Time) ([]datamodels.FileCollaborator, bool) {
+	var fileCollaborators []datamodels.FileCollaborator
+	if err := r.db.Scopes(unexpired).Where("expires_at <= ? AND expiry_notified = ?", t, false).
+		Find(&fileCollaborators).Error; err != nil {
+		log.Printf("Error while getting expiring collaborators: %v", err)
+		return fileCollaborators, false
+	}
+	return fileCollaborators, true
+}
+
+func (r *fileCollaboratorRepository) MarkExpiryNotified(ids []int64) error {
+	return r.db.Model(&datamodels.FileCollaborator{}).Where("id IN ?", ids).Update("expiry_notified", true).Error
+}
+
+func (r *fileCollaboratorRepository) DeleteExpired(now time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", now).Delete(&datamodels.FileCollaborator{})
+	return result.RowsAffected, result.Error
+}
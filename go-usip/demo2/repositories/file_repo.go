@@ -3,6 +3,7 @@ package repositories
 import (
 	"go-usip/datamodels"
 	"log"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -11,18 +12,26 @@ type FileRepository interface {
 	Get(id uint) (file datamodels.File, found bool)
 	GetByUnitId(unitId string) (datamodels.File, bool)
 	BatchGet(ids []uint) (files []datamodels.File, found bool)
+	GetByWorkspaceId(workspaceId uint) ([]datamodels.File, bool)
+	// GetAfter pages through every file by id, it returns up to limit files with an id above afterId.
+	GetAfter(afterId uint, limit int) ([]datamodels.File, bool)
 
 	Create(file datamodels.File) (datamodels.File, error)
 	Update(id uint, data map[string]interface{}) error
 
+	// BatchDelete soft deletes files, they are hidden from every other method but the Deleted ones.
 	BatchDelete(ids []uint) error
+
+	GetDeleted(id uint) (datamodels.File, bool)
+	// GetDeletedIn returns the soft deleted files among ids or in one of workspaceIds.
+	GetDeletedIn(ids []uint, workspaceIds []uint) ([]datamodels.File, bool)
+	GetDeletedBefore(t time.Time) ([]datamodels.File, bool)
+	Restore(id uint) error
+	// Purge removes a file row for good.
+	Purge(id uint) error
 }
 
 func NewFileRepository(db *gorm.DB) FileRepository {
-	if err := db.AutoMigrate(&datamodels.File{}); err != nil {
-		log.Fatalf("AutoMigrate error: %v", err)
-	}
-
 	return &fileRepository{db: db}
 }
 
@@ -56,6 +65,24 @@ func (r *fileRepository) BatchGet(ids []uint) (files []datamodels.File, found bo
 	return files, true
 }
 
+func (r *fileRepository) GetByWorkspaceId(workspaceId uint) ([]datamodels.File, bool) {
+	var files []datamodels.File
+	if err := r.db.Where("workspace_id = ?", workspaceId).Find(&files).Error; err != nil {
+		log.Printf("Error while getting files by workspace_id: %v", err)
+		return files, false
+	}
+	return files, true
+}
+
+func (r *fileRepository) GetAfter(afterId uint, limit int) ([]datamodels.File, bool) {
+	var files []datamodels.File
+	if err := r.db.Where("id > ?", afterId).Order("id").Limit(limit).Find(&files).Error; err != nil {
+		log.Printf("Error while getting files after id: %v", err)
+		return files, false
+	}
+	return files, true
+}
+
 func (r *fileRepository) Create(file datamodels.File) (datamodels.File, error) {
 	return file, r.db.Create(&file).Error
 }
@@ -64,6 +91,46 @@ func (r *fileRepository) BatchDelete(ids []uint) error {
 	return r.db.Where("id IN ?", ids).Delete(&datamodels.File{}).Error
 }
 
+func (r *fileRepository) GetDeleted(id uint) (datamodels.File, bool) {
+	var file datamodels.File
+	if err := r.db.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&file).Error; err != nil {
+		log.Printf("Error while getting deleted file by id: %v", err)
+		return file, false
+	}
+	return file, true
+}
+
+func (r *fileRepository) GetDeletedIn(ids []uint, workspaceIds []uint) ([]datamodels.File, bool) {
+	var files []datamodels.File
+	err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Where(r.db.Where("id IN ?", ids).Or("workspace_id IN ?", workspaceIds)).
+		Order("deleted_at DESC").
+		Find(&files).Error
+	if err != nil {
+		log.Printf("Error while getting deleted files: %v", err)
+		return files, false
+	}
+	return files, true
+}
+
+func (r *fileRepository) GetDeletedBefore(t time.Time) ([]datamodels.File, bool) {
+	var files []datamodels.File
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", t).Find(&files).Error; err != nil {
+		log.Printf("Error while getting expired deleted files: %v", err)
+		return files, false
+	}
+	return files, true
+}
+
+func (r *fileRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&datamodels.File{}).Where("id = ?", id).Update("deleted_at", nil).Error
+}
+
+func (r *fileRepository) Purge(id uint) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&datamodels.File{}).Error
+}
+
 func (r *fileRepository) Update(id uint, data map[string]interface{}) error {
 	return r.db.Model(&datamodels.File{}).Where("id = ?", id).Updates(data).Error
 }
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type FolderRepository interface {
+	Get(id uint) (datamodels.Folder, bool)
+	GetChildren(userId string, parentId uint) ([]datamodels.Folder, bool)
+
+	Create(folder datamodels.Folder) (datamodels.Folder, error)
+	Update(id uint, data map[string]interface{}) error
+
+	BatchDelete(ids []uint) error
+}
+
+func NewFolderRepository(db *gorm.DB) FolderRepository {
+	return &folderRepository{db: db}
+}
+
+type folderRepository struct {
+	db *gorm.DB
+}
+
+func (r *folderRepository) Get(id uint) (datamodels.Folder, bool) {
+	var folder datamodels.Folder
+	if err := r.db.Where("id = ?", id).First(&folder).Error; err != nil {
+		log.Printf("Error while getting folder by id: %v", err)
+		return folder, false
+	}
+	return folder, true
+}
+
+func (r *folderRepository) GetChildren(userId string, parentId uint) ([]datamodels.Folder, bool) {
+	var folders []datamodels.Folder
+	if err := r.db.Where("user_id = ? AND parent_id = ?", userId, parentId).Order("name").Find(&folders).Error; err != nil {
+		log.Printf("Error while getting folders by parent_id: %v", err)
+		return folders, false
+	}
+	return folders, true
+}
+
+func (r *folderRepository) Create(folder datamodels.Folder) (datamodels.Folder, error) {
+	return folder, r.db.Create(&folder).Error
+}
+
+func (r *folderRepository) Update(id uint, data map[string]interface{}) error {
+	return r.db.Model(&datamodels.Folder{}).Where("id = ?", id).Updates(data).Error
+}
+
+func (r *folderRepository) BatchDelete(ids []uint) error {
+	return r.db.Where("id IN ?", ids).Delete(&datamodels.Folder{}).Error
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type InviteRepository interface {
+	Get(id uint) (datamodels.Invite, bool)
+	GetByToken(token string) (datamodels.Invite, bool)
+	// GetPendingByUserId returns the invites userId has neither accepted nor declined, newest first.
+	GetPendingByUserId(userId string) ([]datamodels.Invite, bool)
+
+	BatchCreate(invites []datamodels.Invite) ([]datamodels.Invite, error)
+	// AttachByEmail gives userId the pending invites sent to email while it had no account.
+	AttachByEmail(email string, userId string) error
+	// Answer moves a pending invite to status for userId, found is false once it was answered already.
+	Answer(id uint, userId string, status datamodels.InviteStatus) (found bool, err error)
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+func (r *inviteRepository) Get(id uint) (datamodels.Invite, bool) {
+	var invite datamodels.Invite
+	if err := r.db.Where("id = ?", id).First(&invite).Error; err != nil {
+		log.Printf("Error while getting invite by id: %v", err)
+		return invite, false
+	}
+	return invite, true
+}
+
+func (r *inviteRepository) GetByToken(token string) (datamodels.Invite, bool) {
+	var invite datamodels.Invite
+	if err := r.db.Where("token = ?", token).First(&invite).Error; err != nil {
+		log.Printf("Error while getting invite by token: %v", err)
+		return invite, false
+	}
+	return invite, true
+}
+
+func (r *inviteRepository) GetPendingByUserId(userId string) ([]datamodels.Invite, bool) {
+	var invites []datamodels.Invite
+	if err := r.db.Where("user_id = ? AND status = ?", userId, datamodels.InvitePending).
+		Order("id DESC").Find(&invites).Error; err != nil {
+		log.Printf("Error while getting pending invites by user_id: %v", err)
+		return invites, false
+	}
+	return invites, true
+}
+
+func (r *inviteRepository) BatchCreate(invites []datamodels.Invite) ([]datamodels.Invite, error) {
+	return invites, r.db.Create(&invites).Error
+}
+
+func (r *inviteRepository) AttachByEmail(email string, userId string) error {
+	return r.db.Model(&datamodels.Invite{}).
+		Where("email = ? AND user_id = ? AND status = ?", email, "", datamodels.InvitePending).
+		Update("user_id", userId).Error
+}
+
+func (r *inviteRepository) Answer(id uint, userId string, status datamodels.InviteStatus) (bool, error) {
+	// checking the status in the update keeps two answers from both applying.
+	result := r.db.Model(&datamodels.Invite{}).
+		Where("id = ? AND status = ?", id, datamodels.InvitePending).
+		Updates(map[string]interface{}{"user_id": userId, "status": status})
+	return result.RowsAffected > 0, result.Error
+}
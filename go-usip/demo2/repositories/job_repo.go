@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type JobRepository interface {
+	Get(jobId string) (datamodels.Job, bool)
+	GetUnfinished() ([]datamodels.Job, bool)
+
+	Create(job datamodels.Job) (datamodels.Job, error)
+	Save(job datamodels.Job) error
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func (r *jobRepository) Get(jobId string) (datamodels.Job, bool) {
+	var job datamodels.Job
+	if err := r.db.Where("job_id = ?", jobId).First(&job).Error; err != nil {
+		log.Printf("Error while getting job: %v", err)
+		return job, false
+	}
+	return job, true
+}
+
+func (r *jobRepository) GetUnfinished() ([]datamodels.Job, bool) {
+	var jobs []datamodels.Job
+	if err := r.db.Where("status IN ?", []datamodels.JobStatus{
+		datamodels.JobStatusUploaded, datamodels.JobStatusQueued, datamodels.JobStatusPending,
+	}).
+		Order("id").Find(&jobs).Error; err != nil {
+		log.Printf("Error while getting unfinished jobs: %v", err)
+		return jobs, false
+	}
+	return jobs, true
+}
+
+func (r *jobRepository) Create(job datamodels.Job) (datamodels.Job, error) {
+	return job, r.db.Create(&job).Error
+}
+
+func (r *jobRepository) Save(job datamodels.Job) error {
+	return r.db.Save(&job).Error
+}
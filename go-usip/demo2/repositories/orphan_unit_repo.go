@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type OrphanUnitRepository interface {
+	// GetAll returns the queued units, oldest first.
+	GetAll() ([]datamodels.OrphanUnit, bool)
+
+	Create(unit datamodels.OrphanUnit) (datamodels.OrphanUnit, error)
+	// SetError records why the last deletion of a queued unit failed.
+	SetError(id uint, message string) error
+	// Delete removes a queued unit for good, once universer deleted it.
+	Delete(id uint) error
+}
+
+func NewOrphanUnitRepository(db *gorm.DB) OrphanUnitRepository {
+	return &orphanUnitRepository{db: db}
+}
+
+type orphanUnitRepository struct {
+	db *gorm.DB
+}
+
+func (r *orphanUnitRepository) GetAll() ([]datamodels.OrphanUnit, bool) {
+	var units []datamodels.OrphanUnit
+	if err := r.db.Order("id").Find(&units).Error; err != nil {
+		log.Printf("Error while getting orphan units: %v", err)
+		return units, false
+	}
+	return units, true
+}
+
+func (r *orphanUnitRepository) Create(unit datamodels.OrphanUnit) (datamodels.OrphanUnit, error) {
+	return unit, r.db.Create(&unit).Error
+}
+
+func (r *orphanUnitRepository) SetError(id uint, message string) error {
+	return r.db.Model(&datamodels.OrphanUnit{}).Where("id = ?", id).Update("error", message).Error
+}
+
+func (r *orphanUnitRepository) Delete(id uint) error {
+	return r.db.Unscoped().Where("id = ?", id).Delete(&datamodels.OrphanUnit{}).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"errors"
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// ErrShareLinkUsedUp is returned by Use once a link granted access as many times as it allows.
+var ErrShareLinkUsedUp = errors.New("share link used up")
+
+type ShareLinkRepository interface {
+	Get(id uint) (datamodels.ShareLink, bool)
+	GetByToken(token string) (datamodels.ShareLink, bool)
+	GetByFileId(fileId uint) ([]datamodels.ShareLink, bool)
+
+	Create(link datamodels.ShareLink) (datamodels.ShareLink, error)
+	// Use counts one more user granted access through the link,
+	// ErrShareLinkUsedUp when its use limit is already reached.
+	Use(id uint) error
+	Delete(id uint) error
+}
+
+func NewShareLinkRepository(db *gorm.DB) ShareLinkRepository {
+	return &shareLinkRepository{db: db}
+}
+
+type shareLinkRepository struct {
+	db *gorm.DB
+}
+
+func (r *shareLinkRepository) Get(id uint) (datamodels.ShareLink, bool) {
+	var link datamodels.ShareLink
+	if err := r.db.Where("id = ?", id).First(&link).Error; err != nil {
+		log.Printf("Error while getting share link by id: %v", err)
+		return link, false
+	}
+	return link, true
+}
+
+func (r *shareLinkRepository) GetByToken(token string) (datamodels.ShareLink, bool) {
+	var link datamodels.ShareLink
+	if err := r.db.Where("token = ?", token).First(&link).Error; err != nil {
+		log.Printf("Error while getting share link by token: %v", err)
+		return link, false
+	}
+	return link, true
+}
+
+func (r *shareLinkRepository) GetByFileId(fileId uint) ([]datamodels.ShareLink, bool) {
+	var links []datamodels.ShareLink
+	if err := r.db.Where("file_id = ?", fileId).Order("id").Find(&links).Error; err != nil {
+		log.Printf("Error while getting share links by file_id: %v", err)
+		return links, false
+	}
+	return links, true
+}
+
+func (r *shareLinkRepository) Create(link datamodels.ShareLink) (datamodels.ShareLink, error) {
+	return link, r.db.Create(&link).Error
+}
+
+func (r *shareLinkRepository) Use(id uint) error {
+	// the limit is checked by the update itself, two users redeeming the last use cannot both get it.
+	result := r.db.Model(&datamodels.ShareLink{}).
+		Where("id = ? AND (max_uses = 0 OR uses < max_uses)", id).
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrShareLinkUsedUp
+	}
+	return nil
+}
+
+func (r *shareLinkRepository) Delete(id uint) error {
+	return r.db.Delete(&datamodels.ShareLink{}, id).Error
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// Repositories hands out repositories bound to the transaction of a UnitOfWork.
+type Repositories interface {
+	Files() FileRepository
+	FileCollaborators() FileCollaboratorRepository
+	Workspaces() WorkspaceRepository
+	WorkspaceMembers() WorkspaceMemberRepository
+	ShareLinks() ShareLinkRepository
+	Invites() InviteRepository
+	AccessRequests() AccessRequestRepository
+	OrphanUnits() OrphanUnitRepository
+}
+
+// UnitOfWork groups writes to several repositories in one database transaction.
+type UnitOfWork interface {
+	// Do runs fn in a transaction, committed when fn returns nil and rolled back otherwise.
+	Do(fn func(repos Repositories) error) error
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func (u *unitOfWork) Do(fn func(repos Repositories) error) error {
+	return u.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&txRepositories{tx: tx})
+	})
+}
+
+type txRepositories struct {
+	tx *gorm.DB
+}
+
+func (r *txRepositories) Files() FileRepository {
+	return NewFileRepository(r.tx)
+}
+
+func (r *txRepositories) FileCollaborators() FileCollaboratorRepository {
+	return NewFileCollaboratorRepository(r.tx)
+}
+
+func (r *txRepositories) Workspaces() WorkspaceRepository {
+	return NewWorkspaceRepository(r.tx)
+}
+
+func (r *txRepositories) WorkspaceMembers() WorkspaceMemberRepository {
+	return NewWorkspaceMemberRepository(r.tx)
+}
+
+func (r *txRepositories) ShareLinks() ShareLinkRepository {
+	return NewShareLinkRepository(r.tx)
+}
+
+func (r *txRepositories) Invites() InviteRepository {
+	return NewInviteRepository(r.tx)
+}
+
+func (r *txRepositories) AccessRequests() AccessRequestRepository {
+	return NewAccessRequestRepository(r.tx)
+}
+
+func (r *txRepositories) OrphanUnits() OrphanUnitRepository {
+	return NewOrphanUnitRepository(r.tx)
+}
@@ -14,6 +14,7 @@ type UserRepository interface {
 	Get(userId string) (user datamodels.User, found bool)
 	BatchGet(userIds []string) (users []datamodels.User, found bool)
 	GetByUsername(username string) (user datamodels.User, found bool)
+	GetByEmail(email string) (user datamodels.User, found bool)
 	GetByPage(nextId, size uint) ([]datamodels.User, bool)
 
 	InsertOrUpdate(user datamodels.User) (updatedUser datamodels.User, err error)
@@ -23,10 +24,6 @@ type UserRepository interface {
 // NewUserRepository returns a new user memory-based repository,
 // the one and only repository type in our example.
 func NewUserRepository(db *gorm.DB) UserRepository {
-	if err := db.AutoMigrate(&datamodels.User{}); err != nil {
-		log.Fatalf("AutoMigrate error: %v", err)
-	}
-
 	return &userRepository{db: db}
 }
 
@@ -60,6 +57,15 @@ func (r *userRepository) GetByUsername(username string) (user datamodels.User, f
 	return user, true
 }
 
+func (r *userRepository) GetByEmail(email string) (user datamodels.User, found bool) {
+	user = datamodels.User{}
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		log.Printf("Error while getting user by email: %v", err)
+		return user, false
+	}
+	return user, true
+}
+
 func (r *userRepository) GetByPage(nextId, size uint) ([]datamodels.User, bool) {
 	users := []datamodels.User{}
 	if err := r.db.Where("id > ?", nextId).Order("id").Limit(int(size)).Find(&users).Error; err != nil {
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkspaceMemberRepository interface {
+	Get(workspaceId uint, userId string) (datamodels.WorkspaceMember, bool)
+	GetByWorkspaceId(workspaceId uint) ([]datamodels.WorkspaceMember, bool)
+	GetByUserId(userId string) ([]datamodels.WorkspaceMember, bool)
+
+	InsertOrUpdate(members []datamodels.WorkspaceMember) error
+
+	Delete(workspaceId uint, userId string) error
+}
+
+func NewWorkspaceMemberRepository(db *gorm.DB) WorkspaceMemberRepository {
+	return &workspaceMemberRepository{db: db}
+}
+
+type workspaceMemberRepository struct {
+	db *gorm.DB
+}
+
+func (r *workspaceMemberRepository) Get(workspaceId uint, userId string) (datamodels.WorkspaceMember, bool) {
+	var member datamodels.WorkspaceMember
+	if err := r.db.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).First(&member).Error; err != nil {
+		log.Printf("Error while getting workspace member: %v", err)
+		return member, false
+	}
+	return member, true
+}
+
+func (r *workspaceMemberRepository) GetByWorkspaceId(workspaceId uint) ([]datamodels.WorkspaceMember, bool) {
+	var members []datamodels.WorkspaceMember
+	if err := r.db.Where("workspace_id = ?", workspaceId).Find(&members).Error; err != nil {
+		log.Printf("Error while getting workspace members by workspace_id: %v", err)
+		return members, false
+	}
+	return members, true
+}
+
+func (r *workspaceMemberRepository) GetByUserId(userId string) ([]datamodels.WorkspaceMember, bool) {
+	var members []datamodels.WorkspaceMember
+	if err := r.db.Where("user_id = ?", userId).Find(&members).Error; err != nil {
+		log.Printf("Error while getting workspace members by user_id: %v", err)
+		return members, false
+	}
+	return members, true
+}
+
+func (r *workspaceMemberRepository) InsertOrUpdate(members []datamodels.WorkspaceMember) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&members).Error
+}
+
+func (r *workspaceMemberRepository) Delete(workspaceId uint, userId string) error {
+	return r.db.Where("workspace_id = ? AND user_id = ?", workspaceId, userId).Delete(&datamodels.WorkspaceMember{}).Error
+}
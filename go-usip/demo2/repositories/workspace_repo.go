@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"go-usip/datamodels"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+type WorkspaceRepository interface {
+	Get(id uint) (datamodels.Workspace, bool)
+	BatchGet(ids []uint) ([]datamodels.Workspace, bool)
+
+	Create(workspace datamodels.Workspace) (datamodels.Workspace, error)
+}
+
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+func (r *workspaceRepository) Get(id uint) (datamodels.Workspace, bool) {
+	var workspace datamodels.Workspace
+	if err := r.db.Where("id = ?", id).First(&workspace).Error; err != nil {
+		log.Printf("Error while getting workspace by id: %v", err)
+		return workspace, false
+	}
+	return workspace, true
+}
+
+func (r *workspaceRepository) BatchGet(ids []uint) ([]datamodels.Workspace, bool) {
+	var workspaces []datamodels.Workspace
+	if err := r.db.Where("id IN ?", ids).Order("name").Find(&workspaces).Error; err != nil {
+		log.Printf("Error while getting workspaces by ids: %v", err)
+		return workspaces, false
+	}
+	return workspaces, true
+}
+
+func (r *workspaceRepository) Create(workspace datamodels.Workspace) (datamodels.Workspace, error) {
+	return workspace, r.db.Create(&workspace).Error
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"unicode/utf8"
+)
+
+// maxAccessMessageLen is the longest message of an access request, in characters.
+const maxAccessMessageLen = 1000
+
+var (
+	ErrAccessRequestNotFound = errors.New("access request not found")
+	// ErrAlreadyGranted is returned when asking for a role the user has already, or a lower one.
+	ErrAlreadyGranted = errors.New("you already have this role on the file")
+	ErrMessageTooLong = fmt.Errorf("the message is longer than %d characters", maxAccessMessageLen)
+)
+
+// AccessRequestService lets users who open a file they cannot see ask its owners for a role.
+// Owners approve, granting the role like Join, or deny, and the requester is emailed the answer.
+type AccessRequestService interface {
+	// Request asks for role on the file of unitId, a pending request of the user is replaced.
+	Request(req AccessRequestReq) (datamodels.AccessRequest, error)
+	// Status returns the role of userId on the file of unitId and their last request, nil without any.
+	Status(userId string, unitId string) (AccessStatus, error)
+	// GetPending lists the pending requests on the files userId owns, oldest first.
+	GetPending(userId string) ([]datamodels.AccessRequest, error)
+	Approve(userId string, requestId uint) error
+	Deny(userId string, requestId uint) error
+}
+
+type AccessRequestReq struct {
+	UserId  string
+	UnitId  string
+	Role    datamodels.Role
+	Message string
+}
+
+type AccessStatus struct {
+	FileId uint
+	// Role is the effective role of the user, published files included, empty without access.
+	Role    datamodels.Role
+	Request *datamodels.AccessRequest
+}
+
+func NewAccessRequestService(cfg InviteConfig, repo repositories.AccessRequestRepository,
+	fileRepo repositories.FileRepository, collaRepo repositories.FileCollaboratorRepository,
+	memberRepo repositories.WorkspaceMemberRepository, userRepo repositories.UserRepository,
+	uow repositories.UnitOfWork, mailer Mailer) AccessRequestService {
+	return &accessRequestService{
+		cfg:        cfg,
+		repo:       repo,
+		fileRepo:   fileRepo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		userRepo:   userRepo,
+		uow:        uow,
+		mailer:     mailer,
+	}
+}
+
+type accessRequestService struct {
+	// cfg gives the host used in the links of the emails, the same as the invites.
+	cfg        InviteConfig
+	repo       repositories.AccessRequestRepository
+	fileRepo   repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	userRepo   repositories.UserRepository
+	uow        repositories.UnitOfWork
+	mailer     Mailer
+}
+
+// unitRole is the role of userId on the file, raised to reader when it is published like in GetUnitRole.
+func (s *accessRequestService) unitRole(file datamodels.File, userId string) datamodels.Role {
+	role := effectiveRole(s.collaRepo, s.memberRepo, file, userId)
+	if file.Published {
+		role = datamodels.MaxRole(role, datamodels.RoleReader)
+	}
+	return role
+}
+
+func (s *accessRequestService) Request(req AccessRequestReq) (datamodels.AccessRequest, error) {
+	// ownership is transferred, not requested.
+	if !req.Role.Valid() || req.Role.Base() == datamodels.RoleOwner {
+		return datamodels.AccessRequest{}, ErrInvalidRole
+	}
+	if utf8.RuneCountInString(req.Message) > maxAccessMessageLen {
+		return datamodels.AccessRequest{}, ErrMessageTooLong
+	}
+	file, found := s.fileRepo.GetByUnitId(req.UnitId)
+	if !found {
+		return datamodels.AccessRequest{}, ErrFileNotFound
+	}
+	if datamodels.RoleLever[s.unitRole(file, req.UserId)] >= datamodels.RoleLever[req.Role] {
+		return datamodels.AccessRequest{}, ErrAlreadyGranted
+	}
+
+	if latest, found := s.repo.GetLatest(file.ID, req.UserId); found && latest.Status == datamodels.AccessRequestPending {
+		latest.Role, latest.Message = req.Role, req.Message
+		if err := s.repo.Update(latest.ID, map[string]interface{}{"role": req.Role, "message": req.Message}); err != nil {
+			log.Printf("Error while updating access request %d: %v", latest.ID, err)
+			return datamodels.AccessRequest{}, err
+		}
+		return latest, nil
+	}
+
+	request, err := s.repo.Create(datamodels.AccessRequest{
+		FileId:  file.ID,
+		UserId:  req.UserId,
+		Role:    req.Role,
+		Message: req.Message,
+		Status:  datamodels.AccessRequestPending,
+	})
+	if err != nil {
+		log.Printf("Error while creating an access request to file %d: %v", file.ID, err)
+		return datamodels.AccessRequest{}, err
+	}
+	return request, nil
+}
+
+func (s *accessRequestService) Status(userId string, unitId string) (AccessStatus, error) {
+	file, found := s.fileRepo.GetByUnitId(unitId)
+	if !found {
+		return AccessStatus{}, ErrFileNotFound
+	}
+	status := AccessStatus{FileId: file.ID, Role: s.unitRole(file, userId)}
+	if request, found := s.repo.GetLatest(file.ID, userId); found {
+		status.Request = &request
+	}
+	return status, nil
+}
+
+func (s *accessRequestService) GetPending(userId string) ([]datamodels.AccessRequest, error) {
+	var fileIds []uint
+	collaborators, _ := s.collaRepo.GetByUserId(userId)
+	for _, collaborator := range collaborators {
+		if Allowed(collaborator.Role, ActionManage) {
+			fileIds = append(fileIds, collaborator.FileId)
+		}
+	}
+	members, _ := s.memberRepo.GetByUserId(userId)
+	for _, member := range members {
+		if !Allowed(member.Role, ActionManage) {
+			continue
+		}
+		files, _ := s.fileRepo.GetByWorkspaceId(member.WorkspaceId)
+		for _, file := range files {
+			fileIds = append(fileIds, file.ID)
+		}
+	}
+
+	requests, _ := s.repo.GetPendingByFileIds(fileIds)
+	return requests, nil
+}
+
+// pending returns the pending request requestId on a file userId owns.
+func (s *accessRequestService) pending(userId string, requestId uint) (datamodels.AccessRequest, datamodels.File, error) {
+	request, found := s.repo.Get(requestId)
+	if !found || request.Status != datamodels.AccessRequestPending {
+		return request, datamodels.File{}, ErrAccessRequestNotFound
+	}
+	file, found := s.fileRepo.Get(request.FileId)
+	if !found {
+		return request, file, ErrAccessRequestNotFound
+	}
+	if err := authorize(effectiveRole(s.collaRepo, s.memberRepo, file, userId), ActionManage); err != nil {
+		// users who cannot see the file do not learn about its requests.
+		if errors.Is(err, ErrFileNotFound) {
+			return request, file, ErrAccessRequestNotFound
+		}
+		return request, file, err
+	}
+	return request, file, nil
+}
+
+func (s *accessRequestService) Approve(userId string, requestId uint) error {
+	request, file, err := s.pending(userId, requestId)
+	if err != nil {
+		return err
+	}
+
+	// the role is granted the way Join does, requesters granted more meanwhile keep their role.
+	err = s.uow.Do(func(repos repositories.Repositories) error {
+		answered, err := repos.AccessRequests().Answer(request.ID, userId, datamodels.AccessRequestApproved)
+		if err != nil {
+			return err
+		}
+		if !answered {
+			return ErrAccessRequestNotFound
+		}
+		return raiseRoles(repos.FileCollaborators(), request.FileId, []string{request.UserId}, request.Role, nil)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrAccessRequestNotFound) {
+			log.Printf("Error while approving access request %d: %v", request.ID, err)
+		}
+		return err
+	}
+
+	s.notify(request, file, true)
+	return nil
+}
+
+func (s *accessRequestService) Deny(userId string, requestId uint) error {
+	request, file, err := s.pending(userId, requestId)
+	if err != nil {
+		return err
+	}
+	answered, err := s.repo.Answer(request.ID, userId, datamodels.AccessRequestDenied)
+	if err != nil {
+		return err
+	}
+	if !answered {
+		return ErrAccessRequestNotFound
+	}
+
+	s.notify(request, file, false)
+	return nil
+}
+
+// notify emails the answer to the requester, failures are logged: the sheet page shows it too.
+func (s *accessRequestService) notify(request datamodels.AccessRequest, file datamodels.File, approved bool) {
+	requester, found := s.userRepo.Get(request.UserId)
+	if !found || requester.Email == "" {
+		return
+	}
+
+	mail := Mail{To: requester.Email}
+	if approved {
+		mail.Subject = fmt.Sprintf("Your request to access %s was approved", file.Name)
+		mail.Body = fmt.Sprintf("You can now open %q as %s:\n%s/sheet?unit=%s&type=%d\n",
+			file.Name, request.Role, s.cfg.Host, file.UnitId, file.UnitType)
+	} else {
+		mail.Subject = fmt.Sprintf("Your request to access %s was denied", file.Name)
+		mail.Body = fmt.Sprintf("The owners of %q denied your request for the %s role.\n", file.Name, request.Role)
+	}
+	if err := s.mailer.Send(mail); err != nil {
+		log.Printf("Error while sending the answer of access request %d: %v", request.ID, err)
+	}
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestAccessRequestServiceAnsweredByManagers(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	memberRepo := repositories.NewWorkspaceMemberRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	mails := &sentMails{}
+	accessRequestService := NewAccessRequestService(InviteConfig{}, repositories.NewAccessRequestRepository(db),
+		fileRepo, collaRepo, memberRepo, userRepo, repositories.NewUnitOfWork(db), mails)
+
+	ann, err := NewUserService(userRepo, nil).Create("p", datamodels.User{Nickname: "Ann", Username: "ann", Email: "ann@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workspace, err := repositories.NewWorkspaceRepository(db).Create(datamodels.Workspace{Name: "Team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memberRepo.InsertOrUpdate([]datamodels.WorkspaceMember{
+		{WorkspaceId: workspace.ID, UserId: "boss", Role: datamodels.RoleOwner},
+		{WorkspaceId: workspace.ID, UserId: "carl", Role: datamodels.RoleEditor},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	personal, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: personal.ID, UserId: "owner", Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	// the workspace file has no grant, its workspace owner manages it.
+	shared, err := fileRepo.Create(datamodels.File{Name: "Plan", UnitId: "unit2", UnitType: datamodels.UnitTypeSheet, WorkspaceId: workspace.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := accessRequestService.Request(AccessRequestReq{UserId: ann.UserId, UnitId: personal.UnitId, Role: datamodels.RoleOwner}); !errors.Is(err, ErrInvalidRole) {
+		t.Fatalf("asking for ownership: got %v, want %v", err, ErrInvalidRole)
+	}
+	if _, err := accessRequestService.Request(AccessRequestReq{UserId: "carl", UnitId: shared.UnitId, Role: datamodels.RoleReader}); !errors.Is(err, ErrAlreadyGranted) {
+		t.Fatalf("asking for less than the workspace role: got %v, want %v", err, ErrAlreadyGranted)
+	}
+	toPersonal, err := accessRequestService.Request(AccessRequestReq{UserId: ann.UserId, UnitId: personal.UnitId, Role: datamodels.RoleReader})
+	if err != nil {
+		t.Fatal(err)
+	}
+	toShared, err := accessRequestService.Request(AccessRequestReq{UserId: ann.UserId, UnitId: shared.UnitId, Role: datamodels.RoleEditor})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for userId, want := range map[string][]uint{
+		"owner": {toPersonal.ID},
+		"boss":  {toShared.ID},
+		"carl":  nil,
+		"eve":   nil,
+	} {
+		pending, _ := accessRequestService.GetPending(userId)
+		var got []uint
+		for _, request := range pending {
+			got = append(got, request.ID)
+		}
+		if len(got) != len(want) || len(want) == 1 && got[0] != want[0] {
+			t.Errorf("%s sees pending requests %v, want %v", userId, got, want)
+		}
+	}
+
+	if err := accessRequestService.Approve("carl", toShared.ID); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("workspace editor approving: got %v, want %v", err, ErrForbidden)
+	}
+	if err := accessRequestService.Approve("eve", toShared.ID); !errors.Is(err, ErrAccessRequestNotFound) {
+		t.Fatalf("outsider approving: got %v, want %v", err, ErrAccessRequestNotFound)
+	}
+	if err := accessRequestService.Approve("boss", toShared.ID); err != nil {
+		t.Fatal(err)
+	}
+	if grant, _ := collaRepo.Get(shared.ID, ann.UserId); grant.Role != datamodels.RoleEditor {
+		t.Fatalf("approved request granted %+v", grant)
+	}
+	if err := accessRequestService.Approve("boss", toShared.ID); !errors.Is(err, ErrAccessRequestNotFound) {
+		t.Fatalf("approving twice: got %v, want %v", err, ErrAccessRequestNotFound)
+	}
+
+	if err := accessRequestService.Deny("owner", toPersonal.ID); err != nil {
+		t.Fatal(err)
+	}
+	if grant, found := collaRepo.Get(personal.ID, ann.UserId); found {
+		t.Fatalf("denied request granted %+v", grant)
+	}
+
+	if len(*mails) != 2 || !strings.Contains((*mails)[0].Subject, "approved") || !strings.Contains((*mails)[1].Subject, "denied") {
+		t.Fatalf("got mails %+v, want the approval then the denial", *mails)
+	}
+}
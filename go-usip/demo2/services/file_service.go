@@ -1,7 +1,10 @@
 package services
 
 import (
+	"archive/zip"
+	"context"
 	"errors"
+	"fmt"
 	"go-usip/datamodels"
 	"go-usip/repositories"
 	"io"
@@ -11,34 +14,80 @@ import (
 	"time"
 )
 
+var (
+	ErrFileNotFound         = errors.New("file not found")
+	ErrCollaboratorNotFound = errors.New("collaborator not found")
+	// ErrOwnerExpiry is returned when sharing the owner role until a date, a file cannot lose its owners.
+	ErrOwnerExpiry = errors.New("the owner role cannot expire")
+	// ErrExpiringUpgrade is returned when raising a grant which never expires until a date,
+	// the user would lose all access at the expiry instead of going back to their role.
+	ErrExpiringUpgrade = errors.New("a permanent grant cannot be raised until a date, share without an expiry")
+)
+
 type FileService interface {
 	GetByUserId(userId string) ([]datamodels.File, bool)
+	// GetByUserIdInFolder returns the files userId keeps in folderId, RootFolderId for the top level.
+	GetByUserIdInFolder(userId string, folderId uint) ([]datamodels.File, bool)
+	GetByWorkspaceId(workspaceId uint) ([]datamodels.File, bool)
 	GetByFileId(fileId uint) (datamodels.File, bool)
+	// GetCollaborators returns everyone with access to the file at their effective role:
+	// the explicit grants merged with the members of the file's workspace, the higher role wins.
 	GetCollaborators(fileId uint) ([]datamodels.FileCollaborator, bool)
 	GetCollaboratorsByUnitId(unitId string) ([]datamodels.FileCollaborator, bool)
+	// GetRole returns the effective role of userId on the file, empty without access.
+	GetRole(fileId uint, userId string) datamodels.Role
+	// GetUnitRole returns the role universer is told for userId on the file of unitId:
+	// the effective role, raised to reader on published files for anyone, guests included.
+	// found is false for unknown units.
+	GetUnitRole(unitId string, userId string) (role datamodels.Role, found bool)
 	CheckPermission(req CheckPermissionReq) bool
 
-	Create(req CreateUnitRequest) (datamodels.File, error)
-	Import(req ImportReq) (datamodels.File, error)
-	Export(req ExportReq) (resp ExportResp, err error)
+	Create(ctx context.Context, req CreateUnitRequest) (datamodels.File, error)
+	Import(ctx context.Context, req ImportReq) (datamodels.Job, error)
+	Export(req ExportReq) (datamodels.Job, error)
+	Download(ctx context.Context, req DownloadReq) (resp ExportResp, err error)
 	Join(req JoinReq) error
+	// UpdateCollaborator changes the role granted to a collaborator, owners only.
+	UpdateCollaborator(userId string, fileId uint, collaboratorId string, role datamodels.Role) error
+	// RemoveCollaborator revokes the grant of a collaborator, owners may remove anyone and collaborators themselves.
+	// Workspace members keep the access given by their workspace role.
+	RemoveCollaborator(userId string, fileId uint, collaboratorId string) error
+	// MoveToWorkspace moves a file the user owns into a workspace they can edit,
+	// NoWorkspaceId makes it personal again.
+	MoveToWorkspace(userId string, fileId uint, workspaceId uint) error
+	// Rename lets editors and owners change the name of a file.
+	Rename(userId string, fileId uint, name string) (datamodels.File, error)
+	// Publish lets anyone read the file through universer, guests included, or stops it; owners only.
+	Publish(userId string, fileId uint, published bool) error
+	// Copy queues the copy job of a file the user can open,
+	// the copy is a new unit owned by the user, created once the job is done.
+	Copy(req CopyReq) (datamodels.Job, error)
 	UpdateEditTime(unitId string, editTimeUnixMs int64) error
 
-	BatchDelete(userId string, fileIds []uint) error
+	// RemoveFromList drops files shared with userId from their list,
+	// owners move files to the trash instead.
+	RemoveFromList(userId string, fileIds []uint) error
 }
 
 type fileService struct {
-	repo      repositories.FileRepository
-	collaRepo repositories.FileCollaboratorRepository
+	repo       repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	uow        repositories.UnitOfWork
 
-	uSvc UniverserService
+	uSvc   UniverserService
+	jobSvc JobService
 }
 
-func NewFileService(repo repositories.FileRepository, collaRepo repositories.FileCollaboratorRepository, uSvc UniverserService) FileService {
+func NewFileService(repo repositories.FileRepository, collaRepo repositories.FileCollaboratorRepository,
+	memberRepo repositories.WorkspaceMemberRepository, uow repositories.UnitOfWork, uSvc UniverserService, jobSvc JobService) FileService {
 	return &fileService{
-		repo:      repo,
-		collaRepo: collaRepo,
-		uSvc:      uSvc,
+		repo:       repo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		uow:        uow,
+		uSvc:       uSvc,
+		jobSvc:     jobSvc,
 	}
 }
 
@@ -48,6 +97,19 @@ func (s *fileService) GetByUserId(userId string) ([]datamodels.File, bool) {
 		return nil, false
 	}
 
+	return s.filesOf(collaborators)
+}
+
+func (s *fileService) GetByUserIdInFolder(userId string, folderId uint) ([]datamodels.File, bool) {
+	collaborators, found := s.collaRepo.GetByUserIdInFolder(userId, folderId)
+	if !found {
+		return nil, false
+	}
+
+	return s.filesOf(collaborators)
+}
+
+func (s *fileService) filesOf(collaborators []datamodels.FileCollaborator) ([]datamodels.File, bool) {
 	var fileIds []uint
 	for _, c := range collaborators {
 		fileIds = append(fileIds, c.FileId)
@@ -61,49 +123,115 @@ func (s *fileService) GetByUserId(userId string) ([]datamodels.File, bool) {
 	return files, true
 }
 
+func (s *fileService) GetByWorkspaceId(workspaceId uint) ([]datamodels.File, bool) {
+	return s.repo.GetByWorkspaceId(workspaceId)
+}
+
 func (s *fileService) GetByFileId(fileId uint) (datamodels.File, bool) {
 	return s.repo.Get(fileId)
 }
 
-func (s *fileService) create(unitId string, req CreateUnitRequest) (datamodels.File, error) {
+// compensationTimeout bounds the deletion of a unit left without a file,
+// it does not depend on the request which may be gone already.
+const compensationTimeout = 30 * time.Second
+
+// createFile records a universer unit as a file owned by req.UserId in one transaction,
+// the unit is deleted when that fails so that no unit is left without a file.
+func createFile(uow repositories.UnitOfWork, uSvc UniverserService, unitId string, req CreateUnitRequest) (datamodels.File, error) {
 	file := datamodels.File{
-		Name:     req.Name,
-		UnitType: datamodels.FileTypeInt(req.Type),
-		UnitId:   unitId,
+		Name:         req.Name,
+		UnitType:     datamodels.FileTypeInt(req.Type),
+		UnitId:       unitId,
+		WorkspaceId:  req.WorkspaceId,
+		SourceFileId: req.SourceFileId,
 	}
 
-	var err error
-	file, err = s.repo.Create(file)
+	err := uow.Do(func(repos repositories.Repositories) error {
+		var err error
+		file, err = repos.Files().Create(file)
+		if err != nil {
+			log.Printf("Error while creating file: %v", err)
+			return err
+		}
+
+		_, err = repos.FileCollaborators().Create(datamodels.FileCollaborator{
+			FileId: file.ID,
+			UserId: req.UserId,
+			Role:   datamodels.RoleOwner,
+		})
+		if err != nil {
+			log.Printf("Error while creating file collaborator: %v", err)
+		}
+		return err
+	})
 	if err != nil {
-		log.Printf("Error while creating file: %v", err)
+		deleteUnit(uow, uSvc, unitId, datamodels.FileTypeInt(req.Type))
 		return datamodels.File{}, err
 	}
 
-	_, err = s.collaRepo.Create(datamodels.FileCollaborator{
-		FileId: file.ID,
-		UserId: req.UserId,
-		Role:   datamodels.RoleOwner,
+	return file, nil
+}
+
+// deleteUnit compensates the creation of a unit the host failed to record,
+// a unit which is not deleted is queued so that the reconciliation deletes it.
+func deleteUnit(uow repositories.UnitOfWork, uSvc UniverserService, unitId string, unitType int) {
+	ctx, cancel := context.WithTimeout(context.Background(), compensationTimeout)
+	defer cancel()
+
+	err := uSvc.DeleteUnit(ctx, UniverserDeleteUnitReq{UnitId: unitId, Type: unitType})
+	if err == nil {
+		log.Printf("Deleted unit %s left without a file", unitId)
+		return
+	}
+	unit := datamodels.OrphanUnit{UnitId: unitId, UnitType: unitType, Reason: datamodels.OrphanUnrecorded}
+	if !errors.Is(err, ErrUnitLifecycleDisabled) {
+		log.Printf("Error while deleting unit %s left without a file: %v", unitId, err)
+		unit.Error = err.Error()
+	}
+	err = uow.Do(func(repos repositories.Repositories) error {
+		_, err := repos.OrphanUnits().Create(unit)
+		return err
 	})
 	if err != nil {
-		log.Printf("Error while creating file collaborator: %v", err)
-		return datamodels.File{}, err
+		log.Printf("Error while queuing unit %s left without a file, delete it by hand: %v", unitId, err)
 	}
+}
 
-	return file, nil
+// checkWorkspaceWrite lets editors and owners of a workspace put files in it.
+func (s *fileService) checkWorkspaceWrite(userId string, workspaceId uint) error {
+	if workspaceId == datamodels.NoWorkspaceId {
+		return nil
+	}
+	member, found := s.memberRepo.Get(workspaceId, userId)
+	if !found {
+		return ErrWorkspaceNotFound
+	}
+	if datamodels.RoleLever[member.Role] < datamodels.RoleLever[datamodels.RoleEditor] {
+		return ErrForbidden
+	}
+	return nil
 }
 
-func (s *fileService) Create(req CreateUnitRequest) (datamodels.File, error) {
-	unitId, err := s.uSvc.CreateUnit(req)
+func (s *fileService) Create(ctx context.Context, req CreateUnitRequest) (datamodels.File, error) {
+	if err := s.checkWorkspaceWrite(req.UserId, req.WorkspaceId); err != nil {
+		return datamodels.File{}, err
+	}
+
+	unitId, err := s.uSvc.CreateUnit(ctx, req)
 	if err != nil {
 		log.Printf("Error while creating unit: %v", err)
 		return datamodels.File{}, err
 	}
 
-	return s.create(unitId, req)
+	return createFile(s.uow, s.uSvc, unitId, req)
 }
 
 func (s *fileService) GetCollaborators(fileId uint) ([]datamodels.FileCollaborator, bool) {
-	return s.collaRepo.GetByFileId(fileId)
+	file, found := s.repo.Get(fileId)
+	if !found {
+		return nil, false
+	}
+	return s.effectiveCollaborators(file)
 }
 
 func (s *fileService) GetCollaboratorsByUnitId(unitId string) ([]datamodels.FileCollaborator, bool) {
@@ -111,7 +239,73 @@ func (s *fileService) GetCollaboratorsByUnitId(unitId string) ([]datamodels.File
 	if !found {
 		return nil, false
 	}
-	return s.collaRepo.GetByFileId(file.ID)
+	return s.effectiveCollaborators(file)
+}
+
+// effectiveCollaborators merges the grants of the file with the members of its workspace,
+// members without a grant show up as collaborators without an id.
+func (s *fileService) effectiveCollaborators(file datamodels.File) ([]datamodels.FileCollaborator, bool) {
+	collaborators, found := s.collaRepo.GetByFileId(file.ID)
+	if !found {
+		return nil, false
+	}
+	if file.WorkspaceId == datamodels.NoWorkspaceId {
+		return collaborators, true
+	}
+
+	members, _ := s.memberRepo.GetByWorkspaceId(file.WorkspaceId)
+	index := make(map[string]int, len(collaborators))
+	for i, collaborator := range collaborators {
+		index[collaborator.UserId] = i
+	}
+	for _, member := range members {
+		if i, ok := index[member.UserId]; ok {
+			collaborators[i].Role = datamodels.MaxRole(collaborators[i].Role, member.Role)
+			continue
+		}
+		collaborators = append(collaborators, datamodels.FileCollaborator{
+			FileId: file.ID,
+			UserId: member.UserId,
+			Role:   member.Role,
+		})
+	}
+	return collaborators, true
+}
+
+func (s *fileService) GetRole(fileId uint, userId string) datamodels.Role {
+	file, found := s.repo.Get(fileId)
+	if !found {
+		return ""
+	}
+	return effectiveRole(s.collaRepo, s.memberRepo, file, userId)
+}
+
+func (s *fileService) GetUnitRole(unitId string, userId string) (datamodels.Role, bool) {
+	file, found := s.repo.GetByUnitId(unitId)
+	if !found {
+		return "", false
+	}
+	// guests have no grant nor workspace, only publishing gives them a role.
+	role := effectiveRole(s.collaRepo, s.memberRepo, file, userId)
+	if file.Published {
+		role = datamodels.MaxRole(role, datamodels.RoleReader)
+	}
+	return role, true
+}
+
+// effectiveRole is the higher of the grant of userId on the file and their role in its workspace.
+func effectiveRole(collaRepo repositories.FileCollaboratorRepository, memberRepo repositories.WorkspaceMemberRepository,
+	file datamodels.File, userId string) datamodels.Role {
+	var role datamodels.Role
+	if collaborator, found := collaRepo.Get(file.ID, userId); found {
+		role = collaborator.Role
+	}
+	if file.WorkspaceId != datamodels.NoWorkspaceId {
+		if member, found := memberRepo.Get(file.WorkspaceId, userId); found {
+			role = datamodels.MaxRole(role, member.Role)
+		}
+	}
+	return role
 }
 
 type ImportReq struct {
@@ -120,68 +314,76 @@ type ImportReq struct {
 	UserId   string
 	Type     int
 
+	// WorkspaceId is the workspace the imported file is created in.
+	WorkspaceId uint
+
 	FormFile multipart.File
 	Cookie   string
 }
 
-func (s *fileService) Import(req ImportReq) (file datamodels.File, err error) {
-	fileId, err := s.uSvc.UploadFile(req)
+// Import uploads the file to universer and queues the import job,
+// the file is created once the job is done.
+func (s *fileService) Import(ctx context.Context, req ImportReq) (datamodels.Job, error) {
+	if err := checkWorkbook(req); err != nil {
+		return datamodels.Job{}, err
+	}
+	if err := s.checkWorkspaceWrite(req.UserId, req.WorkspaceId); err != nil {
+		return datamodels.Job{}, err
+	}
+
+	fileId, err := s.uSvc.UploadFile(ctx, req)
 	if err != nil {
 		log.Printf("Error while uploading file: %v", err)
-		return
+		return datamodels.Job{}, err
 	}
 
 	if fileId == "" {
-		return file, errors.New("File upload failed, fileId is empty")
+		return datamodels.Job{}, errors.New("File upload failed, fileId is empty")
 	}
 
-	taskId, err := s.uSvc.Import(UniverserImportReq{
-		FileId:     fileId,
-		Type:       req.Type,
-		OutputType: 1,
-		Cookie:     req.Cookie,
+	return s.jobSvc.Enqueue(datamodels.Job{
+		Kind:        datamodels.JobKindImport,
+		UserId:      req.UserId,
+		UnitType:    req.Type,
+		WorkspaceId: req.WorkspaceId,
+		FileName:    req.FileName,
+		SourceId:    fileId,
+		Cookie:      req.Cookie,
 	})
+}
 
-	if err != nil {
-		log.Printf("Error while importing file: %v", err)
-		return
+// checkWorkbook rejects a sheet import which is not an .xlsx workbook before it is uploaded,
+// the user gets the reason right away instead of a failed job.
+func checkWorkbook(req ImportReq) error {
+	if req.Type != datamodels.UnitTypeSheet {
+		return nil
 	}
 
-	if taskId == "" {
-		return file, errors.New("File import failed, taskId is empty")
+	invalid := func(message string) error {
+		return &InvalidFileError{UniverserError{Op: "importing file", Message: message}}
 	}
-
-	var unitId string
-	for {
-		unitId, err = s.uSvc.PullResult(UniverserPullReq{
-			TaskId: taskId,
-			Cookie: req.Cookie,
-		})
-		if err != nil {
-			log.Printf("Error while getting task: %v", err)
-			return
-		}
-		if unitId != "" {
-			break
-		}
-		time.Sleep(500 * time.Millisecond)
+	if !strings.HasSuffix(strings.ToLower(req.FileName), ".xlsx") {
+		return invalid(fmt.Sprintf("%s is not an .xlsx file", req.FileName))
 	}
 
-	file, err = s.create(unitId, CreateUnitRequest{
-		Name:   strings.Split(req.FileName, ".")[0],
-		Type:   datamodels.FileTypeStr(req.Type),
-		UserId: req.UserId,
-	})
+	zr, err := zip.NewReader(req.FormFile, int64(req.FileSize))
 	if err != nil {
-		log.Printf("Error while creating file: %v", err)
-		return
+		return invalid(fmt.Sprintf("%s is not a valid .xlsx workbook, it may be damaged", req.FileName))
 	}
-	log.Printf("File created: %v", file)
-
-	return file, nil
+	for _, f := range zr.File {
+		if f.Name == "xl/workbook.xml" {
+			return nil
+		}
+	}
+	return invalid(fmt.Sprintf("%s is not a valid .xlsx workbook, it contains no workbook", req.FileName))
 }
 
-func (s *fileService) BatchDelete(userId string, fileIds []uint) error {
+func (s *fileService) RemoveFromList(userId string, fileIds []uint) error {
+	for _, fileId := range fileIds {
+		if collaborator, found := s.collaRepo.Get(fileId, userId); found && collaborator.Role == datamodels.RoleOwner {
+			return ErrOwnerRemove
+		}
+	}
 	return s.collaRepo.BatchDelete(userId, fileIds)
 }
 
@@ -197,46 +399,54 @@ type ExportResp struct {
 	Reader   io.ReadCloser
 }
 
-func (s *fileService) Export(req ExportReq) (resp ExportResp, err error) {
+// Export queues the export job of a file the user collaborates on,
+// the result is fetched with Download once the job is done.
+func (s *fileService) Export(req ExportReq) (datamodels.Job, error) {
 	file, found := s.GetByFileId(req.FileId)
 	if !found {
-		return resp, errors.New("File not found")
+		return datamodels.Job{}, ErrFileNotFound
 	}
 
-	_, found = s.collaRepo.Get(req.FileId, req.UserId)
-	if !found {
-		return resp, errors.New("File not found")
+	if err := authorize(s.GetRole(req.FileId, req.UserId), ActionExport); err != nil {
+		return datamodels.Job{}, err
 	}
 
-	taskId, err := s.uSvc.Export(UniverserExportReq{
-		UnitId: file.UnitId,
-		Type:   file.UnitType,
-		Cookie: req.Cookie,
+	return s.jobSvc.Enqueue(datamodels.Job{
+		Kind:     datamodels.JobKindExport,
+		UserId:   req.UserId,
+		UnitType: file.UnitType,
+		FileId:   file.ID,
+		SourceId: file.UnitId,
+		Cookie:   req.Cookie,
 	})
-	if err != nil {
-		log.Printf("Error while exporting file: %v", err)
-		return
+}
+
+type DownloadReq struct {
+	JobId  string
+	UserId string
+
+	Cookie string
+}
+
+var ErrJobNotFinished = errors.New("job is not finished")
+
+// Download streams the result of a finished export job.
+func (s *fileService) Download(ctx context.Context, req DownloadReq) (resp ExportResp, err error) {
+	job, found := s.jobSvc.Get(req.JobId)
+	if !found || job.UserId != req.UserId || job.Kind != datamodels.JobKindExport {
+		return resp, ErrJobNotFound
+	}
+	if job.Status != datamodels.JobStatusDone {
+		return resp, ErrJobNotFinished
 	}
 
-	var fileId string
-	for {
-		fileId, err = s.uSvc.PullResult(UniverserPullReq{
-			TaskId:       taskId,
-			Cookie:       req.Cookie,
-			ExchangeType: ExchangeTypeExport,
-		})
-		if err != nil {
-			log.Printf("Error while getting task: %v", err)
-			return
-		}
-		if fileId != "" {
-			break
-		}
-		time.Sleep(500 * time.Millisecond)
+	file, found := s.GetByFileId(job.FileId)
+	if !found {
+		return resp, ErrFileNotFound
 	}
 
-	reader, err := s.uSvc.GetFile(UniverserGetFileReq{
-		FileId: fileId,
+	reader, err := s.uSvc.GetFile(ctx, UniverserGetFileReq{
+		FileId: job.Result,
 		Cookie: req.Cookie,
 	})
 	if err != nil {
@@ -256,30 +466,144 @@ func (s *fileService) Export(req ExportReq) (resp ExportResp, err error) {
 }
 
 type JoinReq struct {
+	// UserId is the user sharing the file.
+	UserId  string
 	UserIds []string
 	FileId  uint
 	Role    datamodels.Role
+	// ExpiresAt ends the grants at a fixed date, nil for grants which never expire.
+	ExpiresAt *time.Time
 }
 
+// Join shares the file with UserIds at Role, at most the role of the sharer.
+// Sharing only raises roles, collaborators already granted more keep their role.
 func (s *fileService) Join(req JoinReq) error {
+	if !req.Role.Valid() {
+		return ErrInvalidRole
+	}
+	if req.ExpiresAt != nil {
+		if req.Role == datamodels.RoleOwner {
+			return ErrOwnerExpiry
+		}
+		if !req.ExpiresAt.After(time.Now()) {
+			return ErrExpiresInPast
+		}
+		// stored in the local zone like the timestamps of gorm, sqlite compares them as text.
+		expiresAt := req.ExpiresAt.Local()
+		req.ExpiresAt = &expiresAt
+	}
+	role := s.GetRole(req.FileId, req.UserId)
+	if err := authorize(role, ActionShare); err != nil {
+		return err
+	}
+	if !CanGrant(role, req.Role) {
+		return ErrForbidden
+	}
+
+	return s.uow.Do(func(repos repositories.Repositories) error {
+		return raiseRoles(repos.FileCollaborators(), req.FileId, req.UserIds, req.Role, req.ExpiresAt)
+	})
+}
+
+// raiseRoles grants role on the file to userIds until expiresAt, nil for good.
+// The ones already granted more keep their grant, the ones granted as much keep the later expiry,
+// raising a grant which never expires with an expiring one fails with ErrExpiringUpgrade.
+func raiseRoles(collaRepo repositories.FileCollaboratorRepository, fileId uint, userIds []string, role datamodels.Role,
+	expiresAt *time.Time) error {
 	var data []datamodels.FileCollaborator
-	for _, userId := range req.UserIds {
+	for _, userId := range userIds {
+		if granted, found := collaRepo.Get(fileId, userId); found {
+			lever, grantedLever := datamodels.RoleLever[role], datamodels.RoleLever[granted.Role]
+			if grantedLever > lever || grantedLever == lever && !expiresBefore(granted.ExpiresAt, expiresAt) {
+				continue
+			}
+			if granted.ExpiresAt == nil && expiresAt != nil {
+				return ErrExpiringUpgrade
+			}
+		}
 		data = append(data, datamodels.FileCollaborator{
-			FileId: req.FileId,
-			UserId: userId,
-			Role:   req.Role,
+			FileId:    fileId,
+			UserId:    userId,
+			Role:      role,
+			ExpiresAt: expiresAt,
 		})
 	}
+	if len(data) == 0 {
+		return nil
+	}
+	return collaRepo.InsertOrUpdate(data)
+}
 
-	return s.collaRepo.InsertOrUpdate(data)
+// expiresBefore tells whether a grant expiring at a ends before one expiring at b, nil never expires.
+func expiresBefore(a, b *time.Time) bool {
+	return a != nil && (b == nil || a.Before(*b))
 }
 
-type Action string
+func (s *fileService) UpdateCollaborator(userId string, fileId uint, collaboratorId string, role datamodels.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	if err := authorize(s.GetRole(fileId, userId), ActionManage); err != nil {
+		return err
+	}
 
-const (
-	ActionDelete Action = "delete"
-	ActionJoin   Action = "join"
-)
+	return s.uow.Do(func(repos repositories.Repositories) error {
+		collaRepo := repos.FileCollaborators()
+		collaborator, found := collaRepo.Get(fileId, collaboratorId)
+		if !found {
+			return ErrCollaboratorNotFound
+		}
+		if role != datamodels.RoleOwner {
+			if err := checkNotLastOwner(collaRepo, fileId, collaboratorId); err != nil {
+				return err
+			}
+		}
+
+		collaborator.Role = role
+		if role == datamodels.RoleOwner {
+			collaborator.ExpiresAt = nil
+		}
+		return collaRepo.InsertOrUpdate([]datamodels.FileCollaborator{collaborator})
+	})
+}
+
+func (s *fileService) RemoveCollaborator(userId string, fileId uint, collaboratorId string) error {
+	// everyone with a grant may leave the file.
+	action := ActionManage
+	if collaboratorId == userId {
+		action = ActionView
+	}
+	if err := authorize(s.GetRole(fileId, userId), action); err != nil {
+		return err
+	}
+
+	return s.uow.Do(func(repos repositories.Repositories) error {
+		collaRepo := repos.FileCollaborators()
+		if _, found := collaRepo.Get(fileId, collaboratorId); !found {
+			return ErrCollaboratorNotFound
+		}
+		if err := checkNotLastOwner(collaRepo, fileId, collaboratorId); err != nil {
+			return err
+		}
+		return collaRepo.BatchDelete(collaboratorId, []uint{fileId})
+	})
+}
+
+// checkNotLastOwner refuses to take the owner role away from the only owner of a file.
+func checkNotLastOwner(collaRepo repositories.FileCollaboratorRepository, fileId uint, collaboratorId string) error {
+	collaborators, _ := collaRepo.GetByFileId(fileId)
+	owners, isOwner := 0, false
+	for _, collaborator := range collaborators {
+		if collaborator.Role == datamodels.RoleOwner {
+			owners++
+			isOwner = isOwner || collaborator.UserId == collaboratorId
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
 
 type CheckPermissionReq struct {
 	FileId uint
@@ -288,25 +612,94 @@ type CheckPermissionReq struct {
 }
 
 func (s *fileService) CheckPermission(req CheckPermissionReq) bool {
-	colla, found := s.collaRepo.Get(req.FileId, req.UserId)
+	return Allowed(s.GetRole(req.FileId, req.UserId), req.Action)
+}
+
+func (s *fileService) MoveToWorkspace(userId string, fileId uint, workspaceId uint) error {
+	if err := authorize(s.GetRole(fileId, userId), ActionMove); err != nil {
+		return err
+	}
+	if err := s.checkWorkspaceWrite(userId, workspaceId); err != nil {
+		return err
+	}
+
+	return s.repo.Update(fileId, map[string]interface{}{"workspace_id": workspaceId})
+}
+
+func (s *fileService) Rename(userId string, fileId uint, name string) (datamodels.File, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return datamodels.File{}, ErrEmptyName
+	}
+	if err := authorize(s.GetRole(fileId, userId), ActionRename); err != nil {
+		return datamodels.File{}, err
+	}
+
+	if err := s.repo.Update(fileId, map[string]interface{}{"name": name}); err != nil {
+		log.Printf("Error while renaming file: %v", err)
+		return datamodels.File{}, err
+	}
+	file, _ := s.repo.Get(fileId)
+	return file, nil
+}
+
+func (s *fileService) Publish(userId string, fileId uint, published bool) error {
+	if err := authorize(s.GetRole(fileId, userId), ActionPublish); err != nil {
+		return err
+	}
+
+	// the flag is read on every role check, unpublishing revokes the guests right away.
+	if err := s.repo.Update(fileId, map[string]interface{}{"published": published}); err != nil {
+		log.Printf("Error while publishing file %d: %v", fileId, err)
+		return err
+	}
+	return nil
+}
+
+type CopyReq struct {
+	FileId uint
+	UserId string
+	// Name of the copy, "<name> (copy)" when empty.
+	Name string
+	// WorkspaceId is the workspace the copy is created in.
+	WorkspaceId uint
+
+	Cookie string
+}
+
+func (s *fileService) Copy(req CopyReq) (datamodels.Job, error) {
+	file, found := s.GetByFileId(req.FileId)
 	if !found {
-		return false
+		return datamodels.Job{}, ErrFileNotFound
+	}
+	if err := authorize(s.GetRole(req.FileId, req.UserId), ActionCopy); err != nil {
+		return datamodels.Job{}, err
+	}
+	if err := s.checkWorkspaceWrite(req.UserId, req.WorkspaceId); err != nil {
+		return datamodels.Job{}, err
 	}
 
-	switch req.Action {
-	case ActionDelete:
-		return colla.Role == datamodels.RoleOwner
-	case ActionJoin:
-		return colla.Role == datamodels.RoleOwner
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = file.Name + " (copy)"
 	}
 
-	return false
+	return s.jobSvc.Enqueue(datamodels.Job{
+		Kind:         datamodels.JobKindCopy,
+		UserId:       req.UserId,
+		UnitType:     file.UnitType,
+		WorkspaceId:  req.WorkspaceId,
+		FileName:     name,
+		SourceId:     file.UnitId,
+		SourceFileId: file.ID,
+		Cookie:       req.Cookie,
+	})
 }
 
 func (s *fileService) UpdateEditTime(unitId string, editTimeUnixMs int64) error {
 	file, found := s.repo.GetByUnitId(unitId)
 	if !found {
-		return errors.New("file not found")
+		return ErrFileNotFound
 	}
 
 	return s.repo.Update(file.ID, map[string]interface{}{
@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-usip/datamodels"
+	"go-usip/fakeuniverser"
+	"go-usip/repositories"
+)
+
+var errCreateFailed = errors.New("create failed")
+
+// failingCreates fails creating files or file collaborators inside its transactions.
+type failingCreates struct {
+	repositories.UnitOfWork
+	files, collaborators bool
+}
+
+func (u failingCreates) Do(fn func(repos repositories.Repositories) error) error {
+	return u.UnitOfWork.Do(func(repos repositories.Repositories) error {
+		return fn(failingCreateRepos{Repositories: repos, uow: u})
+	})
+}
+
+type failingCreateRepos struct {
+	repositories.Repositories
+	uow failingCreates
+}
+
+func (r failingCreateRepos) Files() repositories.FileRepository {
+	if r.uow.files {
+		return failingFileCreate{r.Repositories.Files()}
+	}
+	return r.Repositories.Files()
+}
+
+func (r failingCreateRepos) FileCollaborators() repositories.FileCollaboratorRepository {
+	if r.uow.collaborators {
+		return failingCollaboratorCreate{r.Repositories.FileCollaborators()}
+	}
+	return r.Repositories.FileCollaborators()
+}
+
+type failingFileCreate struct {
+	repositories.FileRepository
+}
+
+func (failingFileCreate) Create(datamodels.File) (datamodels.File, error) {
+	return datamodels.File{}, errCreateFailed
+}
+
+// createdUnits remembers the units it creates.
+type createdUnits struct {
+	UniverserService
+	unitIds *[]string
+}
+
+func (u createdUnits) CreateUnit(ctx context.Context, req CreateUnitRequest) (string, error) {
+	unitId, err := u.UniverserService.CreateUnit(ctx, req)
+	*u.unitIds = append(*u.unitIds, unitId)
+	return unitId, err
+}
+
+type failingCollaboratorCreate struct {
+	repositories.FileCollaboratorRepository
+}
+
+func (failingCollaboratorCreate) Create(datamodels.FileCollaborator) (datamodels.FileCollaborator, error) {
+	return datamodels.FileCollaborator{}, errCreateFailed
+}
+
+func TestFileServiceJoinKeepsPermanentGrants(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	fileService := NewFileService(fileRepo, collaRepo, repositories.NewWorkspaceMemberRepository(db),
+		repositories.NewUnitOfWork(db), nil, nil)
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Now().Add(24 * time.Hour)
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "owner", Role: datamodels.RoleOwner},
+		{FileId: file.ID, UserId: "ann", Role: datamodels.RoleReader},
+		{FileId: file.ID, UserId: "bob", Role: datamodels.RoleReader, ExpiresAt: &tomorrow},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nextWeek := time.Now().Add(7 * 24 * time.Hour)
+	err = fileService.Join(JoinReq{UserId: "owner", FileId: file.ID, UserIds: []string{"bob", "ann"}, Role: datamodels.RoleEditor, ExpiresAt: &nextWeek})
+	if !errors.Is(err, ErrExpiringUpgrade) {
+		t.Fatalf("got %v, want %v", err, ErrExpiringUpgrade)
+	}
+	for _, userId := range []string{"ann", "bob"} {
+		if grant, _ := collaRepo.Get(file.ID, userId); grant.Role != datamodels.RoleReader {
+			t.Errorf("%s got %s from a refused share", userId, grant.Role)
+		}
+	}
+
+	if err := fileService.Join(JoinReq{UserId: "owner", FileId: file.ID, UserIds: []string{"bob"}, Role: datamodels.RoleEditor, ExpiresAt: &nextWeek}); err != nil {
+		t.Fatal(err)
+	}
+	if grant, _ := collaRepo.Get(file.ID, "bob"); grant.Role != datamodels.RoleEditor || grant.ExpiresAt == nil {
+		t.Errorf("expiring grant not raised: %+v", grant)
+	}
+	if err := fileService.Join(JoinReq{UserId: "owner", FileId: file.ID, UserIds: []string{"ann"}, Role: datamodels.RoleEditor}); err != nil {
+		t.Fatal(err)
+	}
+	if grant, _ := collaRepo.Get(file.ID, "ann"); grant.Role != datamodels.RoleEditor || grant.ExpiresAt != nil {
+		t.Errorf("permanent grant not raised for good: %+v", grant)
+	}
+}
+
+func TestFileServiceCreateQueuesUnrecordedUnits(t *testing.T) {
+	fake := fakeuniverser.New(fakeuniverser.Options{})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	orphanRepo := repositories.NewOrphanUnitRepository(db)
+	req := CreateUnitRequest{Name: "Budget", Type: datamodels.FileTypeStr(datamodels.UnitTypeSheet), UserId: "1"}
+	var unitIds []string
+
+	// without universer.unitLifecycle the unit cannot be deleted, it is queued.
+	uSvc := createdUnits{NewUniverseService(UniverserConfig{Host: srv.URL}), &unitIds}
+	fileService := NewFileService(fileRepo, repositories.NewFileCollaboratorRepository(db), repositories.NewWorkspaceMemberRepository(db),
+		failingCreates{UnitOfWork: repositories.NewUnitOfWork(db), files: true}, uSvc, nil)
+	if _, err := fileService.Create(context.Background(), req); !errors.Is(err, errCreateFailed) {
+		t.Fatalf("got %v, want %v", err, errCreateFailed)
+	}
+	units, _ := orphanRepo.GetAll()
+	if len(units) != 1 || units[0].UnitId != unitIds[0] || units[0].Reason != datamodels.OrphanUnrecorded ||
+		units[0].UnitType != datamodels.UnitTypeSheet {
+		t.Fatalf("got orphan units %+v, want the unrecorded unit %s", units, unitIds[0])
+	}
+	if _, ok := fake.Unit(unitIds[0]); !ok {
+		t.Fatal("the queued unit is gone from universer")
+	}
+	if err := orphanRepo.Delete(units[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// with it the unit is deleted right away.
+	uSvc = createdUnits{NewUniverseService(UniverserConfig{Host: srv.URL, UnitLifecycle: true}), &unitIds}
+	fileService = NewFileService(fileRepo, repositories.NewFileCollaboratorRepository(db), repositories.NewWorkspaceMemberRepository(db),
+		failingCreates{UnitOfWork: repositories.NewUnitOfWork(db), collaborators: true}, uSvc, nil)
+	if _, err := fileService.Create(context.Background(), req); !errors.Is(err, errCreateFailed) {
+		t.Fatalf("got %v, want %v", err, errCreateFailed)
+	}
+	if units, _ := orphanRepo.GetAll(); len(units) != 0 {
+		t.Fatalf("got orphan units %+v, want the unit deleted", units)
+	}
+	if _, ok := fake.Unit(unitIds[1]); ok {
+		t.Fatal("the unit left without a file is still in universer")
+	}
+	var files int64
+	if err := db.Model(&datamodels.File{}).Count(&files).Error; err != nil {
+		t.Fatal(err)
+	}
+	if files != 0 {
+		t.Fatalf("%d files recorded from failed transactions", files)
+	}
+}
+
+func TestFileServiceKeepsLastOwner(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	fileService := NewFileService(fileRepo, collaRepo, repositories.NewWorkspaceMemberRepository(db),
+		repositories.NewUnitOfWork(db), nil, nil)
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "owner", Role: datamodels.RoleOwner},
+		{FileId: file.ID, UserId: "ann", Role: datamodels.RoleEditor},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	role := func(userId string) datamodels.Role {
+		grant, _ := collaRepo.Get(file.ID, userId)
+		return grant.Role
+	}
+
+	if err := fileService.RemoveCollaborator("owner", file.ID, "owner"); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("removing the only owner: got %v, want %v", err, ErrLastOwner)
+	}
+	if err := fileService.UpdateCollaborator("owner", file.ID, "owner", datamodels.RoleEditor); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("demoting the only owner: got %v, want %v", err, ErrLastOwner)
+	}
+	if role("owner") != datamodels.RoleOwner {
+		t.Fatalf("the only owner became %q", role("owner"))
+	}
+
+	// with two owners either can be demoted, then the other one is the last.
+	if err := fileService.UpdateCollaborator("owner", file.ID, "ann", datamodels.RoleOwner); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileService.UpdateCollaborator("ann", file.ID, "owner", datamodels.RoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if role("owner") != datamodels.RoleEditor || role("ann") != datamodels.RoleOwner {
+		t.Fatalf("got owner %s and ann %s, want editor and owner", role("owner"), role("ann"))
+	}
+	if err := fileService.RemoveCollaborator("ann", file.ID, "ann"); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("removing the new only owner: got %v, want %v", err, ErrLastOwner)
+	}
+	if err := fileService.RemoveCollaborator("ann", file.ID, "owner"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := collaRepo.Get(file.ID, "owner"); found {
+		t.Fatal("the demoted owner was not removed")
+	}
+}
+
+func TestFileServiceCopyAndRenameRoles(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{PendingPolls: 1, Latency: 10 * time.Millisecond})
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	jobRepo := repositories.NewJobRepository(db)
+	jobService := newTestJobService(t, db, uSvc)
+	jobService.Start()
+	fileService := NewFileService(fileRepo, collaRepo, repositories.NewWorkspaceMemberRepository(db),
+		repositories.NewUnitOfWork(db), uSvc, jobService)
+
+	file, err := fileService.Create(context.Background(), CreateUnitRequest{Name: "Budget", Type: datamodels.FileTypeStr(datamodels.UnitTypeSheet), UserId: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "ed", Role: datamodels.RoleEditor},
+		{FileId: file.ID, UserId: "rita", Role: datamodels.RoleReader},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := fileService.Rename("rita", file.ID, "Mine"); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("reader renaming: got %v, want %v", err, ErrForbidden)
+	}
+	if renamed, err := fileService.Rename("ed", file.ID, " Budget 2027 "); err != nil || renamed.Name != "Budget 2027" {
+		t.Fatalf("editor renaming: got %+v, %v", renamed, err)
+	}
+	if _, err := fileService.Copy(CopyReq{FileId: file.ID, UserId: "eve"}); !errors.Is(err, ErrFileNotFound) {
+		t.Fatalf("outsider copying: got %v, want %v", err, ErrFileNotFound)
+	}
+
+	// readers may copy, the copy is theirs alone.
+	job, err := fileService.Copy(CopyReq{FileId: file.ID, UserId: "rita"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, cancel := jobService.Subscribe(job.JobId)
+	defer cancel()
+	var last JobEvent
+	for !(datamodels.Job{Status: last.Status}).Finished() {
+		select {
+		case last = <-events:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the copy job is still %s", last.Status)
+		}
+	}
+	if last.Status != datamodels.JobStatusDone {
+		t.Fatalf("copy job %s: %s", last.Status, last.Error)
+	}
+
+	job = waitJob(t, jobRepo, job.JobId)
+	if job.UserId != "rita" {
+		t.Fatalf("the copy job belongs to %q", job.UserId)
+	}
+	copied, found := fileRepo.Get(job.FileId)
+	if !found || copied.Name != "Budget 2027 (copy)" || copied.SourceFileId != file.ID || copied.UnitId == file.UnitId {
+		t.Fatalf("got copy %+v, want a new unit copied from file %d", copied, file.ID)
+	}
+	collaborators, _ := collaRepo.GetByFileId(copied.ID)
+	if len(collaborators) != 1 || collaborators[0].UserId != "rita" || collaborators[0].Role != datamodels.RoleOwner {
+		t.Fatalf("got collaborators %+v on the copy, want rita as its only owner", collaborators)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"strings"
+)
+
+var (
+	ErrFolderNotFound = errors.New("folder not found")
+	ErrInvalidFolder  = errors.New("a folder cannot be moved into itself or one of its subfolders")
+	ErrEmptyName      = errors.New("name is empty")
+)
+
+// FolderService organizes the files of a user in a tree of personal folders.
+// Every method takes the acting user, folders of other users are reported as not found.
+// RootFolderId stands for the top of the tree wherever a folder id is expected.
+type FolderService interface {
+	Get(userId string, folderId uint) (datamodels.Folder, error)
+	GetChildren(userId string, folderId uint) ([]datamodels.Folder, error)
+	// GetBreadcrumbs returns the path from the top level folder down to folderId included,
+	// it is empty for RootFolderId.
+	GetBreadcrumbs(userId string, folderId uint) ([]datamodels.Folder, error)
+
+	Create(userId string, name string, parentId uint) (datamodels.Folder, error)
+	Rename(userId string, folderId uint, name string) (datamodels.Folder, error)
+	Move(userId string, folderId uint, parentId uint) (datamodels.Folder, error)
+	// Delete removes the folder and its subfolders,
+	// the files they held move to the parent of the folder.
+	Delete(userId string, folderId uint) error
+
+	MoveFiles(userId string, fileIds []uint, folderId uint) error
+}
+
+func NewFolderService(repo repositories.FolderRepository, collaRepo repositories.FileCollaboratorRepository) FolderService {
+	return &folderService{
+		repo:      repo,
+		collaRepo: collaRepo,
+	}
+}
+
+type folderService struct {
+	repo      repositories.FolderRepository
+	collaRepo repositories.FileCollaboratorRepository
+}
+
+func (s *folderService) Get(userId string, folderId uint) (datamodels.Folder, error) {
+	folder, found := s.repo.Get(folderId)
+	if !found || folder.UserId != userId {
+		return datamodels.Folder{}, ErrFolderNotFound
+	}
+	return folder, nil
+}
+
+// checkFolder accepts RootFolderId and the folders of userId.
+func (s *folderService) checkFolder(userId string, folderId uint) error {
+	if folderId == datamodels.RootFolderId {
+		return nil
+	}
+	_, err := s.Get(userId, folderId)
+	return err
+}
+
+func (s *folderService) GetChildren(userId string, folderId uint) ([]datamodels.Folder, error) {
+	if err := s.checkFolder(userId, folderId); err != nil {
+		return nil, err
+	}
+
+	folders, _ := s.repo.GetChildren(userId, folderId)
+	return folders, nil
+}
+
+func (s *folderService) GetBreadcrumbs(userId string, folderId uint) ([]datamodels.Folder, error) {
+	var path []datamodels.Folder
+	for id := folderId; id != datamodels.RootFolderId; {
+		folder, err := s.Get(userId, id)
+		if err != nil {
+			return nil, err
+		}
+		path = append([]datamodels.Folder{folder}, path...)
+		id = folder.ParentId
+	}
+	return path, nil
+}
+
+func (s *folderService) Create(userId string, name string, parentId uint) (datamodels.Folder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return datamodels.Folder{}, ErrEmptyName
+	}
+	if err := s.checkFolder(userId, parentId); err != nil {
+		return datamodels.Folder{}, err
+	}
+
+	folder, err := s.repo.Create(datamodels.Folder{
+		Name:     name,
+		UserId:   userId,
+		ParentId: parentId,
+	})
+	if err != nil {
+		log.Printf("Error while creating folder: %v", err)
+		return datamodels.Folder{}, err
+	}
+	return folder, nil
+}
+
+func (s *folderService) Rename(userId string, folderId uint, name string) (datamodels.Folder, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return datamodels.Folder{}, ErrEmptyName
+	}
+	folder, err := s.Get(userId, folderId)
+	if err != nil {
+		return folder, err
+	}
+
+	if err := s.repo.Update(folderId, map[string]interface{}{"name": name}); err != nil {
+		log.Printf("Error while renaming folder: %v", err)
+		return folder, err
+	}
+	folder.Name = name
+	return folder, nil
+}
+
+func (s *folderService) Move(userId string, folderId uint, parentId uint) (datamodels.Folder, error) {
+	folder, err := s.Get(userId, folderId)
+	if err != nil {
+		return folder, err
+	}
+
+	// the new parent must not be the folder or below it.
+	ancestors, err := s.GetBreadcrumbs(userId, parentId)
+	if err != nil {
+		return folder, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == folderId {
+			return folder, ErrInvalidFolder
+		}
+	}
+
+	if err := s.repo.Update(folderId, map[string]interface{}{"parent_id": parentId}); err != nil {
+		log.Printf("Error while moving folder: %v", err)
+		return folder, err
+	}
+	folder.ParentId = parentId
+	return folder, nil
+}
+
+func (s *folderService) Delete(userId string, folderId uint) error {
+	folder, err := s.Get(userId, folderId)
+	if err != nil {
+		return err
+	}
+
+	subtree := []uint{folder.ID}
+	for i := 0; i < len(subtree); i++ {
+		children, _ := s.repo.GetChildren(userId, subtree[i])
+		for _, child := range children {
+			subtree = append(subtree, child.ID)
+		}
+	}
+
+	if err := s.collaRepo.MoveFolderContents(userId, subtree, folder.ParentId); err != nil {
+		log.Printf("Error while moving files out of folder: %v", err)
+		return err
+	}
+	return s.repo.BatchDelete(subtree)
+}
+
+func (s *folderService) MoveFiles(userId string, fileIds []uint, folderId uint) error {
+	if err := s.checkFolder(userId, folderId); err != nil {
+		return err
+	}
+	return s.collaRepo.MoveToFolder(userId, fileIds, folderId)
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestFolderServiceKeepsFoldersPersonal(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	folderService := NewFolderService(repositories.NewFolderRepository(db), collaRepo)
+	fileService := NewFileService(fileRepo, collaRepo, repositories.NewWorkspaceMemberRepository(db),
+		repositories.NewUnitOfWork(db), nil, nil)
+
+	projects, err := folderService.Create("ann", "Projects", datamodels.RootFolderId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budgets, err := folderService.Create("ann", "Budgets", projects.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "ann", Role: datamodels.RoleOwner},
+		{FileId: file.ID, UserId: "bob", Role: datamodels.RoleEditor},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inFolder := func(userId string, folderId uint) bool {
+		t.Helper()
+		files, _ := fileService.GetByUserIdInFolder(userId, folderId)
+		return len(files) == 1 && files[0].ID == file.ID
+	}
+
+	// the folders of ann do not exist for bob.
+	if _, err := folderService.Get("bob", projects.ID); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("get: got %v, want %v", err, ErrFolderNotFound)
+	}
+	if _, err := folderService.Create("bob", "Mine", projects.ID); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("create inside: got %v, want %v", err, ErrFolderNotFound)
+	}
+	if _, err := folderService.Rename("bob", projects.ID, "Mine"); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("rename: got %v, want %v", err, ErrFolderNotFound)
+	}
+	if err := folderService.MoveFiles("bob", []uint{file.ID}, budgets.ID); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("move files into: got %v, want %v", err, ErrFolderNotFound)
+	}
+	if err := folderService.Delete("bob", projects.ID); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("delete: got %v, want %v", err, ErrFolderNotFound)
+	}
+
+	if _, err := folderService.Move("ann", projects.ID, budgets.ID); !errors.Is(err, ErrInvalidFolder) {
+		t.Fatalf("move into a subfolder: got %v, want %v", err, ErrInvalidFolder)
+	}
+
+	// filing a shared file only moves it for the user filing it.
+	if err := folderService.MoveFiles("ann", []uint{file.ID}, budgets.ID); err != nil {
+		t.Fatal(err)
+	}
+	if !inFolder("ann", budgets.ID) || !inFolder("bob", datamodels.RootFolderId) {
+		t.Fatal("the file is not in budgets for ann and at the top for bob")
+	}
+
+	// deleting a folder moves its files up to its parent.
+	if err := folderService.Delete("ann", projects.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := folderService.Get("ann", budgets.ID); !errors.Is(err, ErrFolderNotFound) {
+		t.Fatalf("subfolder of a deleted folder: got %v, want %v", err, ErrFolderNotFound)
+	}
+	if !inFolder("ann", datamodels.RootFolderId) {
+		t.Fatal("the file of the deleted folders is not back at the top")
+	}
+}
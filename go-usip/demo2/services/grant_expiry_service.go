@@ -0,0 +1,139 @@
+package services
+
+import (
+	"fmt"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// GrantExpiryService removes the collaborator grants which reached their expiry date and warns
+// the owners of the files ahead of it. Expired grants give no access even before they are removed.
+type GrantExpiryService interface {
+	// Start sweeps the grants every SweepInterval.
+	Start()
+	// Sweep emails the owners about the grants expiring within the notice period, once per grant,
+	// and removes the expired grants; it returns how many grants were notified and removed.
+	Sweep() (notified int, removed int64, err error)
+}
+
+type GrantExpiryConfig struct {
+	SweepInterval time.Duration
+	// Notice is how long before their expiry the owners are told about a grant, 0 tells nobody.
+	Notice time.Duration
+	// Host is the address of the app used in the links of the emails.
+	Host string
+}
+
+// LoadGrantExpiryConfig reads the grants.* and host keys of the config file.
+func LoadGrantExpiryConfig() GrantExpiryConfig {
+	cfg := GrantExpiryConfig{
+		SweepInterval: viper.GetDuration("grants.sweepInterval"),
+		Notice:        72 * time.Hour,
+		Host:          viper.GetString("host"),
+	}
+	if viper.IsSet("grants.expiryNotice") {
+		cfg.Notice = viper.GetDuration("grants.expiryNotice")
+	}
+	if cfg.SweepInterval <= 0 {
+		cfg.SweepInterval = time.Hour
+	}
+	return cfg
+}
+
+func NewGrantExpiryService(cfg GrantExpiryConfig, collaRepo repositories.FileCollaboratorRepository,
+	fileRepo repositories.FileRepository, userRepo repositories.UserRepository, mailer Mailer) GrantExpiryService {
+	return &grantExpiryService{
+		cfg:       cfg,
+		collaRepo: collaRepo,
+		fileRepo:  fileRepo,
+		userRepo:  userRepo,
+		mailer:    mailer,
+	}
+}
+
+type grantExpiryService struct {
+	cfg       GrantExpiryConfig
+	collaRepo repositories.FileCollaboratorRepository
+	fileRepo  repositories.FileRepository
+	userRepo  repositories.UserRepository
+	mailer    Mailer
+}
+
+func (s *grantExpiryService) Start() {
+	go func() {
+		for {
+			if notified, removed, err := s.Sweep(); err != nil {
+				log.Printf("Error while sweeping expired grants: %v", err)
+			} else if notified > 0 || removed > 0 {
+				log.Printf("Notified %d expiring grants and removed %d expired ones", notified, removed)
+			}
+			time.Sleep(s.cfg.SweepInterval)
+		}
+	}()
+}
+
+func (s *grantExpiryService) Sweep() (int, int64, error) {
+	now := time.Now()
+	notified, err := s.notifyExpiring(now)
+	if err != nil {
+		return notified, 0, err
+	}
+	removed, err := s.collaRepo.DeleteExpired(now)
+	return notified, removed, err
+}
+
+// notifyExpiring emails the owners of the files about the grants expiring within the notice period.
+// Grants of trashed files are left for when the file is restored.
+func (s *grantExpiryService) notifyExpiring(now time.Time) (int, error) {
+	if s.cfg.Notice <= 0 {
+		return 0, nil
+	}
+
+	grants, _ := s.collaRepo.GetExpiringBefore(now.Add(s.cfg.Notice))
+	var ids []int64
+	for _, grant := range grants {
+		file, found := s.fileRepo.Get(grant.FileId)
+		if !found {
+			continue
+		}
+		s.notify(grant, file)
+		ids = append(ids, grant.ID)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return len(ids), s.collaRepo.MarkExpiryNotified(ids)
+}
+
+// notify emails the owners of the file with an address, failures are logged: the grant expires anyway.
+func (s *grantExpiryService) notify(grant datamodels.FileCollaborator, file datamodels.File) {
+	collaborator, _ := s.userRepo.Get(grant.UserId)
+	name := collaborator.Nickname
+	if name == "" {
+		name = grant.UserId
+	}
+
+	collaborators, _ := s.collaRepo.GetByFileId(file.ID)
+	for _, owner := range collaborators {
+		if owner.Role != datamodels.RoleOwner {
+			continue
+		}
+		user, found := s.userRepo.Get(owner.UserId)
+		if !found || user.Email == "" {
+			continue
+		}
+		err := s.mailer.Send(Mail{
+			To:      user.Email,
+			Subject: fmt.Sprintf("Access of %s to %s expires soon", name, file.Name),
+			Body: fmt.Sprintf("The %s access of %s to %q expires on %s.\n\nShare the file again to extend it:\n%s/files\n",
+				grant.Role, name, file.Name, grant.ExpiresAt.Format("2006-01-02 15:04:05"), s.cfg.Host),
+		})
+		if err != nil {
+			log.Printf("Error while sending the expiry notice of grant %d: %v", grant.ID, err)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"net/mail"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrInviteNotFound = errors.New("invite not found")
+	ErrNoRecipients   = errors.New("no email address or username to invite")
+	ErrInvalidEmail   = errors.New("invalid email address")
+	// ErrInviteEmail is returned when registering with an invite token under another email address.
+	ErrInviteEmail = errors.New("register with the email address the invite was sent to")
+)
+
+// InviteService invites people to files by email address or username, without knowing their user id.
+// Existing users get a pending invite they accept or decline, email addresses without account
+// get a token granting the role when they register with it.
+type InviteService interface {
+	// Invite offers role on the file to each recipient, owners only.
+	// Recipients already granted as much are skipped, unknown usernames are refused.
+	Invite(req InviteReq) ([]datamodels.Invite, error)
+	// GetPending lists the invites userId has to accept or decline, newest first.
+	GetPending(userId string) ([]datamodels.Invite, error)
+	Accept(userId string, inviteId uint) error
+	Decline(userId string, inviteId uint) error
+
+	// Lookup returns the invite of token while nobody registered with it, for the address email.
+	Lookup(token string, email string) (datamodels.Invite, error)
+	// Redeem grants the role of the invite of token to userId, who just registered with it.
+	Redeem(userId string, token string) error
+	// Attach gives userId, who just registered with email, the invites sent to the address before,
+	// they are then pending like the invites of existing users.
+	Attach(userId string, email string) error
+}
+
+type InviteReq struct {
+	UserId string
+	FileId uint
+	// Recipients are email addresses or usernames.
+	Recipients []string
+	Role       datamodels.Role
+}
+
+type InviteConfig struct {
+	// Host is the address of the app used in the links of the emails.
+	Host string
+}
+
+// LoadInviteConfig reads the host key of the config file.
+func LoadInviteConfig() InviteConfig {
+	return InviteConfig{
+		Host: viper.GetString("host"),
+	}
+}
+
+func NewInviteService(cfg InviteConfig, repo repositories.InviteRepository, fileRepo repositories.FileRepository,
+	collaRepo repositories.FileCollaboratorRepository, memberRepo repositories.WorkspaceMemberRepository,
+	userRepo repositories.UserRepository, uow repositories.UnitOfWork, mailer Mailer) InviteService {
+	return &inviteService{
+		cfg:        cfg,
+		repo:       repo,
+		fileRepo:   fileRepo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		userRepo:   userRepo,
+		uow:        uow,
+		mailer:     mailer,
+	}
+}
+
+type inviteService struct {
+	cfg        InviteConfig
+	repo       repositories.InviteRepository
+	fileRepo   repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	userRepo   repositories.UserRepository
+	uow        repositories.UnitOfWork
+	mailer     Mailer
+}
+
+// NormalizeEmail returns the address of an email, lower cased to be compared, ok is false when it is not one.
+func NormalizeEmail(email string) (string, bool) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) {
+		return "", false
+	}
+	return strings.ToLower(addr.Address), true
+}
+
+func (s *inviteService) Invite(req InviteReq) ([]datamodels.Invite, error) {
+	if !req.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	file, found := s.fileRepo.Get(req.FileId)
+	if !found {
+		return nil, ErrFileNotFound
+	}
+	role := effectiveRole(s.collaRepo, s.memberRepo, file, req.UserId)
+	if err := authorize(role, ActionShare); err != nil {
+		return nil, err
+	}
+	if !CanGrant(role, req.Role) {
+		return nil, ErrForbidden
+	}
+
+	invites, err := s.resolve(req, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(invites) == 0 {
+		return nil, nil
+	}
+
+	invites, err = s.repo.BatchCreate(invites)
+	if err != nil {
+		log.Printf("Error while creating invites to file %d: %v", req.FileId, err)
+		return nil, err
+	}
+
+	inviter, _ := s.userRepo.Get(req.UserId)
+	for _, invite := range invites {
+		s.notify(invite, file, inviter)
+	}
+	return invites, nil
+}
+
+// resolve turns the recipients of req into invites, all of them are checked before any is created.
+func (s *inviteService) resolve(req InviteReq, file datamodels.File) ([]datamodels.Invite, error) {
+	var (
+		invites []datamodels.Invite
+		seen    = make(map[string]bool)
+		empty   = true
+	)
+	for _, recipient := range req.Recipients {
+		recipient = strings.TrimSpace(recipient)
+		if recipient == "" {
+			continue
+		}
+		empty = false
+
+		invite := datamodels.Invite{
+			FileId:    req.FileId,
+			Role:      req.Role,
+			InvitedBy: req.UserId,
+			Status:    datamodels.InvitePending,
+		}
+		var (
+			user  datamodels.User
+			found bool
+		)
+		if strings.Contains(recipient, "@") {
+			email, ok := NormalizeEmail(recipient)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidEmail, recipient)
+			}
+			invite.Email = email
+			user, found = s.userRepo.GetByEmail(email)
+		} else {
+			user, found = s.userRepo.GetByUsername(recipient)
+			if !found {
+				return nil, fmt.Errorf("%w: %s", ErrUserNotFound, recipient)
+			}
+			invite.Email = user.Email
+		}
+
+		if found {
+			if user.UserId == req.UserId || seen[user.UserId] ||
+				datamodels.RoleLever[effectiveRole(s.collaRepo, s.memberRepo, file, user.UserId)] >= datamodels.RoleLever[req.Role] {
+				continue
+			}
+			seen[user.UserId] = true
+			invite.UserId = user.UserId
+		} else {
+			if seen[invite.Email] {
+				continue
+			}
+			seen[invite.Email] = true
+			// the same kind of token as share links, it is the only proof of owning the address.
+			token, err := datamodels.GenerateShareToken()
+			if err != nil {
+				return nil, err
+			}
+			invite.Token = token
+		}
+		invites = append(invites, invite)
+	}
+	if empty {
+		return nil, ErrNoRecipients
+	}
+	return invites, nil
+}
+
+// notify emails the invite, failures are logged: existing users still find it in the app.
+func (s *inviteService) notify(invite datamodels.Invite, file datamodels.File, inviter datamodels.User) {
+	if invite.Email == "" {
+		return
+	}
+
+	body := fmt.Sprintf("%s invited you to %q as %s.\n\n", inviter.Nickname, file.Name, invite.Role)
+	if invite.Token != "" {
+		body += fmt.Sprintf("Create your account to open it:\n%s/register?invite=%s\n", s.cfg.Host, invite.Token)
+	} else {
+		body += fmt.Sprintf("Accept or decline the invite on your files page:\n%s/files\n", s.cfg.Host)
+	}
+
+	err := s.mailer.Send(Mail{
+		To:      invite.Email,
+		Subject: fmt.Sprintf("%s invited you to %s", inviter.Nickname, file.Name),
+		Body:    body,
+	})
+	if err != nil {
+		log.Printf("Error while sending invite %d: %v", invite.ID, err)
+	}
+}
+
+func (s *inviteService) GetPending(userId string) ([]datamodels.Invite, error) {
+	invites, _ := s.repo.GetPendingByUserId(userId)
+	return invites, nil
+}
+
+// pending returns the pending invite inviteId of userId, invites of other users are not found.
+func (s *inviteService) pending(userId string, inviteId uint) (datamodels.Invite, error) {
+	invite, found := s.repo.Get(inviteId)
+	if !found || invite.UserId != userId || invite.Status != datamodels.InvitePending {
+		return invite, ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+func (s *inviteService) Accept(userId string, inviteId uint) error {
+	invite, err := s.pending(userId, inviteId)
+	if err != nil {
+		return err
+	}
+	if _, found := s.fileRepo.Get(invite.FileId); !found {
+		return ErrFileNotFound
+	}
+
+	return s.accept(invite, userId)
+}
+
+// accept grants the role of the invite to userId with its answer, unless it was answered meanwhile.
+func (s *inviteService) accept(invite datamodels.Invite, userId string) error {
+	return s.uow.Do(func(repos repositories.Repositories) error {
+		answered, err := repos.Invites().Answer(invite.ID, userId, datamodels.InviteAccepted)
+		if err != nil {
+			return err
+		}
+		if !answered {
+			return ErrInviteNotFound
+		}
+		return raiseRoles(repos.FileCollaborators(), invite.FileId, []string{userId}, invite.Role, nil)
+	})
+}
+
+func (s *inviteService) Decline(userId string, inviteId uint) error {
+	invite, err := s.pending(userId, inviteId)
+	if err != nil {
+		return err
+	}
+	answered, err := s.repo.Answer(invite.ID, userId, datamodels.InviteDeclined)
+	if err != nil {
+		return err
+	}
+	if !answered {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// byToken returns the pending invite of token while nobody registered with it.
+func (s *inviteService) byToken(token string) (datamodels.Invite, error) {
+	invite, found := s.repo.GetByToken(token)
+	if token == "" || !found || invite.UserId != "" || invite.Status != datamodels.InvitePending {
+		return invite, ErrInviteNotFound
+	}
+	return invite, nil
+}
+
+func (s *inviteService) Lookup(token string, email string) (datamodels.Invite, error) {
+	invite, err := s.byToken(token)
+	if err != nil {
+		return invite, err
+	}
+	// the token was mailed to the address, it does not vouch for another one.
+	if email, _ = NormalizeEmail(email); email != invite.Email {
+		return invite, ErrInviteEmail
+	}
+	return invite, nil
+}
+
+func (s *inviteService) Redeem(userId string, token string) error {
+	invite, err := s.byToken(token)
+	if err != nil {
+		return err
+	}
+	if _, found := s.fileRepo.Get(invite.FileId); !found {
+		return ErrFileNotFound
+	}
+
+	if err := s.accept(invite, userId); err != nil {
+		log.Printf("Error while redeeming invite %d: %v", invite.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (s *inviteService) Attach(userId string, email string) error {
+	if email == "" {
+		return nil
+	}
+	if err := s.repo.AttachByEmail(email, userId); err != nil {
+		log.Printf("Error while attaching the invites of %s to user %s: %v", email, userId, err)
+		return err
+	}
+	return nil
+}
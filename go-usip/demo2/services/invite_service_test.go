@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+// sentMails keeps the emails instead of sending them.
+type sentMails []Mail
+
+func (m *sentMails) Send(mail Mail) error {
+	*m = append(*m, mail)
+	return nil
+}
+
+func TestInviteServiceRegisterFromInvite(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	inviteRepo := repositories.NewInviteRepository(db)
+	userService := NewUserService(userRepo, nil)
+	inviteService := NewInviteService(InviteConfig{}, inviteRepo, fileRepo, collaRepo,
+		repositories.NewWorkspaceMemberRepository(db), userRepo, repositories.NewUnitOfWork(db), &sentMails{})
+
+	owner, err := userService.Create("p", datamodels.User{Nickname: "Owner", Username: "owner"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	var fileIds []uint
+	for _, name := range []string{"Budget", "Plan"} {
+		file, err := fileRepo.Create(datamodels.File{Name: name, UnitId: name, UnitType: datamodels.UnitTypeSheet})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: owner.UserId, Role: datamodels.RoleOwner}); err != nil {
+			t.Fatal(err)
+		}
+		invites, err := inviteService.Invite(InviteReq{
+			UserId:     owner.UserId,
+			FileId:     file.ID,
+			Recipients: []string{"ann@example.com"},
+			Role:       datamodels.RoleEditor,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens = append(tokens, invites[0].Token)
+		fileIds = append(fileIds, file.ID)
+	}
+
+	if _, err := inviteService.Lookup(tokens[0], "mallory@example.com"); !errors.Is(err, ErrInviteEmail) {
+		t.Fatalf("another address: got %v, want %v", err, ErrInviteEmail)
+	}
+	if _, err := inviteService.Lookup(tokens[0], ""); !errors.Is(err, ErrInviteEmail) {
+		t.Fatalf("no address: got %v, want %v", err, ErrInviteEmail)
+	}
+	if _, err := inviteService.Lookup(tokens[0], "Ann@Example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the way PostRegister does.
+	ann, err := userService.Create("p", datamodels.User{Nickname: "Ann", Username: "ann", Email: "Ann@Example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inviteService.Redeem(ann.UserId, tokens[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := inviteService.Attach(ann.UserId, ann.Email); err != nil {
+		t.Fatal(err)
+	}
+
+	if collaborator, found := collaRepo.Get(fileIds[0], ann.UserId); !found || collaborator.Role != datamodels.RoleEditor {
+		t.Fatalf("redeemed invite granted %+v, %v", collaborator, found)
+	}
+	pending, _ := inviteService.GetPending(ann.UserId)
+	if len(pending) != 1 || pending[0].FileId != fileIds[1] {
+		t.Fatalf("got pending invites %+v, want the one to file %d", pending, fileIds[1])
+	}
+	if err := inviteService.Accept(ann.UserId, pending[0].ID); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"go-usip/datamodels"
+	"sync"
+)
+
+// JobEvent is published on every status change of a job.
+type JobEvent struct {
+	JobId  string
+	Status datamodels.JobStatus
+	Error  string
+}
+
+// jobBroker fans job events out to in-process subscribers.
+type jobBroker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan JobEvent]struct{}
+}
+
+func newJobBroker() *jobBroker {
+	return &jobBroker{subs: map[string]map[chan JobEvent]struct{}{}}
+}
+
+func (b *jobBroker) subscribe(jobId string) (<-chan JobEvent, func()) {
+	// a job changes status a handful of times, the buffer never fills up.
+	ch := make(chan JobEvent, 16)
+
+	b.mu.Lock()
+	if b.subs[jobId] == nil {
+		b.subs[jobId] = map[chan JobEvent]struct{}{}
+	}
+	b.subs[jobId][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs[jobId], ch)
+		if len(b.subs[jobId]) == 0 {
+			delete(b.subs, jobId)
+		}
+	}
+}
+
+func (b *jobBroker) publish(ev JobEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[ev.JobId] {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+
+// JobService runs imports and exports in the background.
+// Jobs are persisted, Start resumes the ones left unfinished by a previous run.
+type JobService interface {
+	Start()
+
+	Enqueue(job datamodels.Job) (datamodels.Job, error)
+	Get(jobId string) (datamodels.Job, bool)
+	// Subscribe streams the status changes of a job until cancel is called.
+	Subscribe(jobId string) (events <-chan JobEvent, cancel func())
+}
+
+type JobConfig struct {
+	Workers int
+	// PollInterval is the first delay between two polls of a universer task,
+	// it doubles after each poll up to MaxPollInterval.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// Deadline bounds how long a job may take from being enqueued.
+	Deadline time.Duration
+}
+
+// LoadJobConfig reads the jobs.* keys of the config file.
+func LoadJobConfig() JobConfig {
+	cfg := JobConfig{
+		Workers:         viper.GetInt("jobs.workers"),
+		PollInterval:    viper.GetDuration("jobs.pollInterval"),
+		MaxPollInterval: viper.GetDuration("jobs.maxPollInterval"),
+		Deadline:        viper.GetDuration("jobs.deadline"),
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 500 * time.Millisecond
+	}
+	if cfg.MaxPollInterval < cfg.PollInterval {
+		cfg.MaxPollInterval = 10 * cfg.PollInterval
+	}
+	if cfg.Deadline <= 0 {
+		cfg.Deadline = 10 * time.Minute
+	}
+	return cfg
+}
+
+type jobService struct {
+	cfg      JobConfig
+	repo     repositories.JobRepository
+	fileRepo repositories.FileRepository
+	uow      repositories.UnitOfWork
+	uSvc     UniverserService
+
+	queue  chan string
+	broker *jobBroker
+}
+
+func NewJobService(cfg JobConfig, repo repositories.JobRepository, fileRepo repositories.FileRepository,
+	uow repositories.UnitOfWork, uSvc UniverserService) JobService {
+	return &jobService{
+		cfg:      cfg,
+		repo:     repo,
+		fileRepo: fileRepo,
+		uow:      uow,
+		uSvc:     uSvc,
+		queue:    make(chan string, 128),
+		broker:   newJobBroker(),
+	}
+}
+
+// Start launches the workers and queues the unfinished jobs of a previous run.
+func (s *jobService) Start() {
+	for i := 0; i < s.cfg.Workers; i++ {
+		go s.work()
+	}
+
+	jobs, _ := s.repo.GetUnfinished()
+	if len(jobs) > 0 {
+		log.Printf("Resuming %d unfinished jobs", len(jobs))
+	}
+	for _, job := range jobs {
+		s.schedule(job.JobId)
+	}
+}
+
+func (s *jobService) Enqueue(job datamodels.Job) (datamodels.Job, error) {
+	job.JobId = datamodels.GenerateJobId()
+	job.Status = datamodels.JobStatusQueued
+	if job.Kind == datamodels.JobKindImport {
+		job.Status = datamodels.JobStatusUploaded
+	}
+	job.Deadline = time.Now().Add(s.cfg.Deadline)
+
+	job, err := s.repo.Create(job)
+	if err != nil {
+		log.Printf("Error while creating job: %v", err)
+		return job, err
+	}
+
+	s.schedule(job.JobId)
+	return job, nil
+}
+
+func (s *jobService) Get(jobId string) (datamodels.Job, bool) {
+	return s.repo.Get(jobId)
+}
+
+func (s *jobService) Subscribe(jobId string) (<-chan JobEvent, func()) {
+	return s.broker.subscribe(jobId)
+}
+
+func (s *jobService) schedule(jobId string) {
+	// never block the caller on a full queue.
+	go func() { s.queue <- jobId }()
+}
+
+func (s *jobService) work() {
+	for jobId := range s.queue {
+		s.run(jobId)
+	}
+}
+
+func (s *jobService) run(jobId string) {
+	job, found := s.repo.Get(jobId)
+	if !found || job.Finished() {
+		return
+	}
+
+	// the job outlives the request which enqueued it, it is only bound by its deadline.
+	ctx, cancel := context.WithDeadline(context.Background(), job.Deadline)
+	defer cancel()
+
+	if err := s.process(ctx, &job); err != nil {
+		log.Printf("Job %s failed: %v", job.JobId, err)
+		job.Error = err.Error()
+		s.transition(&job, datamodels.JobStatusFailed)
+		return
+	}
+	s.transition(&job, datamodels.JobStatusDone)
+}
+
+// transition persists the job with its new status and publishes the change.
+// Finished jobs do not keep the session cookie of their user.
+func (s *jobService) transition(job *datamodels.Job, status datamodels.JobStatus) {
+	job.Status = status
+	if job.Finished() {
+		job.Cookie = ""
+	}
+	if err := s.repo.Save(*job); err != nil {
+		log.Printf("Error while saving job %s: %v", job.JobId, err)
+	}
+
+	s.broker.publish(JobEvent{
+		JobId:  job.JobId,
+		Status: job.Status,
+		Error:  job.Error,
+	})
+}
+
+func (s *jobService) process(ctx context.Context, job *datamodels.Job) error {
+	// a copy exports its source first, then imports the export like an uploaded file.
+	if job.Kind == datamodels.JobKindCopy && job.ExportedId == "" {
+		exportedId, err := s.runTask(ctx, job)
+		if err != nil {
+			return err
+		}
+		job.ExportedId = exportedId
+		job.TaskId = ""
+		s.transition(job, datamodels.JobStatusQueued)
+	}
+
+	result, err := s.runTask(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.Result = result
+
+	if job.Kind == datamodels.JobKindImport || job.Kind == datamodels.JobKindCopy {
+		// a job resumed after its file was created, but before it was saved as done, polls the same
+		// finished task again: the unit is recorded once.
+		if file, found := s.fileRepo.GetByUnitId(result); found {
+			job.FileId = file.ID
+			return nil
+		}
+
+		name := job.FileName
+		if job.Kind == datamodels.JobKindImport {
+			name = strings.Split(job.FileName, ".")[0]
+		}
+		file, err := createFile(s.uow, s.uSvc, result, CreateUnitRequest{
+			Name:         name,
+			Type:         datamodels.FileTypeStr(job.UnitType),
+			UserId:       job.UserId,
+			WorkspaceId:  job.WorkspaceId,
+			SourceFileId: job.SourceFileId,
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("File created: %v", file)
+		job.FileId = file.ID
+	}
+
+	return nil
+}
+
+// runTask submits the current universer task of the job unless it already was,
+// and waits for its result.
+func (s *jobService) runTask(ctx context.Context, job *datamodels.Job) (string, error) {
+	if job.TaskId == "" {
+		taskId, err := s.submitWhenAvailable(ctx, *job)
+		if err != nil {
+			return "", err
+		}
+		if taskId == "" {
+			return "", fmt.Errorf("%s failed, taskId is empty", job.Kind)
+		}
+
+		job.TaskId = taskId
+		s.transition(job, datamodels.JobStatusQueued)
+	}
+
+	return s.poll(ctx, job)
+}
+
+// exporting tells whether the current task of the job is an export.
+func exporting(job datamodels.Job) bool {
+	return job.Kind == datamodels.JobKindExport || (job.Kind == datamodels.JobKindCopy && job.ExportedId == "")
+}
+
+func (s *jobService) submit(ctx context.Context, job datamodels.Job) (string, error) {
+	switch {
+	case job.Kind == datamodels.JobKindCopy && !exporting(job):
+		return s.uSvc.Import(ctx, UniverserImportReq{
+			FileId:     job.ExportedId,
+			Type:       job.UnitType,
+			OutputType: 1,
+			Cookie:     job.Cookie,
+		})
+	case exporting(job):
+		return s.uSvc.Export(ctx, UniverserExportReq{
+			UnitId: job.SourceId,
+			Type:   job.UnitType,
+			Cookie: job.Cookie,
+		})
+	case job.Kind == datamodels.JobKindImport:
+		return s.uSvc.Import(ctx, UniverserImportReq{
+			FileId:     job.SourceId,
+			Type:       job.UnitType,
+			OutputType: 1,
+			Cookie:     job.Cookie,
+		})
+	default:
+		return "", fmt.Errorf("unknown job kind %q", job.Kind)
+	}
+}
+
+// submitWhenAvailable submits the job, waiting for universer to come back
+// with the poll backoff while it is unavailable, until the job deadline.
+func (s *jobService) submitWhenAvailable(ctx context.Context, job datamodels.Job) (string, error) {
+	interval := s.cfg.PollInterval
+	for {
+		taskId, err := s.submit(ctx, job)
+		if !errors.Is(err, ErrUniverserUnavailable) {
+			return taskId, err
+		}
+		log.Printf("Universer unavailable while submitting job %s, retrying: %v", job.JobId, err)
+
+		if time.Now().Add(interval).After(job.Deadline) {
+			return "", err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > s.cfg.MaxPollInterval {
+			interval = s.cfg.MaxPollInterval
+		}
+	}
+}
+
+// poll waits for the universer task of the job with exponential backoff,
+// transient errors are retried until the job deadline.
+// The job moves to pending once universer reports the task as running.
+func (s *jobService) poll(ctx context.Context, job *datamodels.Job) (string, error) {
+	exchangeType := ExchangeTypeImport
+	if exporting(*job) {
+		exchangeType = ExchangeTypeExport
+	}
+
+	interval := s.cfg.PollInterval
+	for {
+		result, err := s.uSvc.PullResult(ctx, UniverserPullReq{
+			TaskId:       job.TaskId,
+			Cookie:       job.Cookie,
+			ExchangeType: exchangeType,
+		})
+		if errors.Is(err, ErrUniverserTaskFailed) {
+			return "", err
+		}
+		if err == nil && result != "" {
+			return result, nil
+		}
+		if err == nil && job.Status != datamodels.JobStatusPending {
+			s.transition(job, datamodels.JobStatusPending)
+		}
+		if err != nil {
+			log.Printf("Error while polling task %s of job %s, retrying: %v", job.TaskId, job.JobId, err)
+		}
+
+		if time.Now().Add(interval).After(job.Deadline) {
+			return "", fmt.Errorf("universer task %s did not finish before %s", job.TaskId, job.Deadline.Format(time.RFC3339))
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > s.cfg.MaxPollInterval {
+			interval = s.cfg.MaxPollInterval
+		}
+	}
+}
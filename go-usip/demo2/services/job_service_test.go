@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-usip/datamodels"
+	"go-usip/fakeuniverser"
+	"go-usip/migrations"
+	"go-usip/repositories"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// newTestDB opens a migrated sqlite database in a temporary directory.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestJobService(t *testing.T, db *gorm.DB, uSvc UniverserService) JobService {
+	t.Helper()
+	return NewJobService(JobConfig{
+		Workers:         1,
+		PollInterval:    time.Millisecond,
+		MaxPollInterval: time.Millisecond,
+		Deadline:        time.Minute,
+	}, repositories.NewJobRepository(db), repositories.NewFileRepository(db), repositories.NewUnitOfWork(db), uSvc)
+}
+
+// waitJob waits for a job to finish and returns it.
+func waitJob(t *testing.T, repo repositories.JobRepository, jobId string) datamodels.Job {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		job, _ := repo.Get(jobId)
+		if job.Finished() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job is still %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobServiceClearsCookieOfFinishedJobs(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{PendingPolls: 1})
+	db := newTestDB(t)
+	repo := repositories.NewJobRepository(db)
+	jobService := newTestJobService(t, db, uSvc)
+	jobService.Start()
+
+	unitId, err := uSvc.CreateUnit(context.Background(), CreateUnitRequest{Name: "Budget", Type: datamodels.FileTypeStr(datamodels.UnitTypeSheet), UserId: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := jobService.Enqueue(datamodels.Job{
+		Kind:     datamodels.JobKindExport,
+		UserId:   "1",
+		UnitType: datamodels.UnitTypeSheet,
+		SourceId: unitId,
+		Cookie:   "_on-premise=session",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job = waitJob(t, repo, job.JobId)
+	if job.Status != datamodels.JobStatusDone {
+		t.Fatalf("job %s: %s", job.Status, job.Error)
+	}
+	if job.Cookie != "" {
+		t.Fatalf("finished job kept the cookie %q", job.Cookie)
+	}
+}
+
+// TestJobServiceResumedImportRecordsUnitOnce resumes an import which created its file
+// but stopped before it was saved as done.
+func TestJobServiceResumedImportRecordsUnitOnce(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{})
+	db := newTestDB(t)
+	repo := repositories.NewJobRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "Budget.xlsx")
+	if err := os.WriteFile(path, []byte("workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fileId, err := uSvc.UploadFile(ctx, ImportReq{FileName: "Budget.xlsx", FileSize: 8, FormFile: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskId, err := uSvc.Import(ctx, UniverserImportReq{FileId: fileId, Type: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitId, err := pull(t, uSvc, taskId, ExchangeTypeImport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := createFile(repositories.NewUnitOfWork(db), uSvc, unitId, CreateUnitRequest{Name: "Budget", Type: "sheet", UserId: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := repo.Create(datamodels.Job{
+		JobId:    datamodels.GenerateJobId(),
+		Kind:     datamodels.JobKindImport,
+		Status:   datamodels.JobStatusPending,
+		UserId:   "1",
+		UnitType: datamodels.UnitTypeSheet,
+		FileName: "Budget.xlsx",
+		SourceId: fileId,
+		TaskId:   taskId,
+		Deadline: time.Now().Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newTestJobService(t, db, uSvc).Start()
+	job = waitJob(t, repo, job.JobId)
+	if job.Status != datamodels.JobStatusDone || job.FileId != file.ID {
+		t.Fatalf("job %s with file %d, want done with file %d: %s", job.Status, job.FileId, file.ID, job.Error)
+	}
+	var files int64
+	if err := db.Model(&datamodels.File{}).Where("unit_id = ?", unitId).Count(&files).Error; err != nil {
+		t.Fatal(err)
+	}
+	if files != 1 {
+		t.Fatalf("unit %s recorded as %d files", unitId, files)
+	}
+	if _, found := fileRepo.Get(file.ID); !found {
+		t.Fatal("the file is gone")
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Mail is a plain text email.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends the emails of the app, NewMailer picks the implementation from the config file.
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+const (
+	MailDriverSMTP = "smtp"
+	// MailDriverOutbox writes the emails to files instead of sending them, for development.
+	MailDriverOutbox = "outbox"
+)
+
+type MailerConfig struct {
+	Driver string
+	From   string
+	// Outbox is the directory the outbox driver writes the emails to.
+	Outbox string
+	SMTP   SMTPConfig
+}
+
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN auth, none is used without Username.
+	Username string
+	Password string
+}
+
+// LoadMailerConfig reads the mail.* keys of the config file, emails go to the outbox by default.
+func LoadMailerConfig() MailerConfig {
+	cfg := MailerConfig{
+		Driver: viper.GetString("mail.driver"),
+		From:   viper.GetString("mail.from"),
+		Outbox: viper.GetString("mail.outbox"),
+		SMTP: SMTPConfig{
+			Host:     viper.GetString("mail.smtp.host"),
+			Port:     viper.GetInt("mail.smtp.port"),
+			Username: viper.GetString("mail.smtp.username"),
+			Password: viper.GetString("mail.smtp.password"),
+		},
+	}
+	if cfg.Driver == "" {
+		cfg.Driver = MailDriverOutbox
+	}
+	if cfg.From == "" {
+		cfg.From = "no-reply@localhost"
+	}
+	if cfg.Outbox == "" {
+		cfg.Outbox = "./outbox"
+	}
+	if cfg.SMTP.Port == 0 {
+		cfg.SMTP.Port = 587
+	}
+	return cfg
+}
+
+func NewMailer(cfg MailerConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case MailDriverSMTP:
+		if cfg.SMTP.Host == "" {
+			return nil, errors.New("mail.smtp.host is empty")
+		}
+		return &smtpMailer{cfg: cfg}, nil
+	case MailDriverOutbox:
+		if err := os.MkdirAll(cfg.Outbox, 0o755); err != nil {
+			return nil, err
+		}
+		return &outboxMailer{cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail.driver %q, use %s or %s", cfg.Driver, MailDriverSMTP, MailDriverOutbox)
+	}
+}
+
+// message renders mail with its headers, addresses and subjects spanning lines are refused.
+func message(from string, mail Mail) ([]byte, error) {
+	for _, header := range []string{from, mail.To, mail.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errors.New("mail header contains a line break")
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	buf.WriteString(strings.ReplaceAll(mail.Body, "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
+
+type smtpMailer struct {
+	cfg MailerConfig
+}
+
+func (m *smtpMailer) Send(mail Mail) error {
+	msg, err := message(m.cfg.From, mail)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.cfg.SMTP.Username != "" {
+		auth = smtp.PlainAuth("", m.cfg.SMTP.Username, m.cfg.SMTP.Password, m.cfg.SMTP.Host)
+	}
+	addr := m.cfg.SMTP.Host + ":" + strconv.Itoa(m.cfg.SMTP.Port)
+	return smtp.SendMail(addr, auth, m.cfg.From, []string{mail.To}, msg)
+}
+
+type outboxMailer struct {
+	cfg MailerConfig
+}
+
+func (m *outboxMailer) Send(mail Mail) error {
+	msg, err := message(m.cfg.From, mail)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), strings.NewReplacer("/", "_", "\\", "_").Replace(mail.To))
+	path := filepath.Join(m.cfg.Outbox, name)
+	if err := os.WriteFile(path, msg, 0o644); err != nil {
+		return err
+	}
+	log.Printf("Mail to %s written to %s", mail.To, path)
+	return nil
+}
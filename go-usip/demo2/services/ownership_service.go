@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrSameOwner       = errors.New("the file already belongs to this user")
+	ErrNotCollaborator = errors.New("ownership can only be transferred to a collaborator of the file")
+	// ErrNotFileOwner is returned to a workspace owner transferring a file they are not an owner of themselves.
+	ErrNotFileOwner = errors.New("only the owners of the file itself can transfer it")
+)
+
+// OwnershipService hands files over to another user: the new owner is promoted
+// and the previous owner is demoted to editor in the same transaction.
+type OwnershipService interface {
+	// Transfer lets an owner of the file make the collaborator toUserId its owner,
+	// owning the workspace of the file is not enough: the owner grant of userId on the file is demoted.
+	Transfer(userId string, fileId uint, toUserId string) error
+	// TransferAll moves every file fromUserId owns to toUserId and returns how many were moved, admins only.
+	// Workspace files owned through a workspace role are not moved, the workspace keeps them.
+	TransferAll(adminId string, fromUserId string, toUserId string) (int, error)
+	IsAdmin(userId string) bool
+}
+
+type OwnershipConfig struct {
+	// Admins are the user ids allowed to transfer the files of other users.
+	Admins []string
+}
+
+// LoadOwnershipConfig reads the admins key of the config file.
+func LoadOwnershipConfig() OwnershipConfig {
+	return OwnershipConfig{
+		Admins: viper.GetStringSlice("admins"),
+	}
+}
+
+func NewOwnershipService(cfg OwnershipConfig, collaRepo repositories.FileCollaboratorRepository,
+	memberRepo repositories.WorkspaceMemberRepository, userRepo repositories.UserRepository,
+	repo repositories.FileRepository, uow repositories.UnitOfWork) OwnershipService {
+	admins := make(map[string]bool, len(cfg.Admins))
+	for _, admin := range cfg.Admins {
+		admins[admin] = true
+	}
+	return &ownershipService{
+		admins:     admins,
+		repo:       repo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		userRepo:   userRepo,
+		uow:        uow,
+	}
+}
+
+type ownershipService struct {
+	admins map[string]bool
+
+	repo       repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	userRepo   repositories.UserRepository
+	uow        repositories.UnitOfWork
+}
+
+func (s *ownershipService) IsAdmin(userId string) bool {
+	return s.admins[userId]
+}
+
+func (s *ownershipService) Transfer(userId string, fileId uint, toUserId string) error {
+	file, found := s.repo.Get(fileId)
+	if !found {
+		return ErrFileNotFound
+	}
+	if err := authorize(effectiveRole(s.collaRepo, s.memberRepo, file, userId), ActionTransfer); err != nil {
+		return err
+	}
+	if toUserId == userId {
+		return ErrSameOwner
+	}
+	if effectiveRole(s.collaRepo, s.memberRepo, file, toUserId) == "" {
+		return ErrNotCollaborator
+	}
+
+	err := s.uow.Do(func(repos repositories.Repositories) error {
+		if grant, found := repos.FileCollaborators().Get(fileId, userId); !found || grant.Role != datamodels.RoleOwner {
+			return ErrNotFileOwner
+		}
+		return handOver(repos.FileCollaborators(), []uint{fileId}, userId, toUserId)
+	})
+	if errors.Is(err, ErrNotFileOwner) {
+		return err
+	}
+	if err != nil {
+		log.Printf("Error while transferring file %d: %v", fileId, err)
+		return err
+	}
+	return nil
+}
+
+func (s *ownershipService) TransferAll(adminId string, fromUserId string, toUserId string) (int, error) {
+	if !s.IsAdmin(adminId) {
+		return 0, ErrForbidden
+	}
+	if fromUserId == toUserId {
+		return 0, ErrSameOwner
+	}
+	for _, userId := range []string{fromUserId, toUserId} {
+		if _, found := s.userRepo.Get(userId); !found {
+			return 0, ErrUserNotFound
+		}
+	}
+
+	var fileIds []uint
+	collaborators, _ := s.collaRepo.GetByUserId(fromUserId)
+	for _, collaborator := range collaborators {
+		if collaborator.Role == datamodels.RoleOwner {
+			fileIds = append(fileIds, collaborator.FileId)
+		}
+	}
+	if len(fileIds) == 0 {
+		return 0, nil
+	}
+
+	err := s.uow.Do(func(repos repositories.Repositories) error {
+		return handOver(repos.FileCollaborators(), fileIds, fromUserId, toUserId)
+	})
+	if err != nil {
+		log.Printf("Error while transferring the files of %s: %v", fromUserId, err)
+		return 0, err
+	}
+	return len(fileIds), nil
+}
+
+// handOver makes toUserId owner of the files and demotes fromUserId to editor,
+// the existing grants keep their folder.
+func handOver(collaRepo repositories.FileCollaboratorRepository, fileIds []uint, fromUserId string, toUserId string) error {
+	grants := make([]datamodels.FileCollaborator, 0, 2*len(fileIds))
+	for _, fileId := range fileIds {
+		grants = append(grants,
+			datamodels.FileCollaborator{FileId: fileId, UserId: toUserId, Role: datamodels.RoleOwner},
+			datamodels.FileCollaborator{FileId: fileId, UserId: fromUserId, Role: datamodels.RoleEditor},
+		)
+	}
+	return collaRepo.InsertOrUpdate(grants)
+}
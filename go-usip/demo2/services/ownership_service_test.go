@@ -0,0 +1,61 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestOwnershipServiceTransferNeedsFileOwner(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	memberRepo := repositories.NewWorkspaceMemberRepository(db)
+	ownershipService := NewOwnershipService(OwnershipConfig{}, collaRepo, memberRepo,
+		repositories.NewUserRepository(db), fileRepo, repositories.NewUnitOfWork(db))
+
+	workspace, err := repositories.NewWorkspaceRepository(db).Create(datamodels.Workspace{Name: "Team"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := memberRepo.InsertOrUpdate([]datamodels.WorkspaceMember{{WorkspaceId: workspace.ID, UserId: "boss", Role: datamodels.RoleOwner}}); err != nil {
+		t.Fatal(err)
+	}
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet, WorkspaceId: workspace.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "owner", Role: datamodels.RoleOwner},
+		{FileId: file.ID, UserId: "ann", Role: datamodels.RoleEditor},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	roles := func() map[string]datamodels.Role {
+		collaborators, _ := collaRepo.GetByFileId(file.ID)
+		roles := make(map[string]datamodels.Role, len(collaborators))
+		for _, collaborator := range collaborators {
+			roles[collaborator.UserId] = collaborator.Role
+		}
+		return roles
+	}
+
+	// the workspace owner has no grant of their own to hand over.
+	if err := ownershipService.Transfer("boss", file.ID, "ann"); !errors.Is(err, ErrNotFileOwner) {
+		t.Fatalf("got %v, want %v", err, ErrNotFileOwner)
+	}
+	if got := roles(); len(got) != 2 || got["owner"] != datamodels.RoleOwner || got["ann"] != datamodels.RoleEditor {
+		t.Fatalf("a refused transfer changed the grants: %v", got)
+	}
+
+	if err := ownershipService.Transfer("owner", file.ID, "ann"); err != nil {
+		t.Fatal(err)
+	}
+	if got := roles(); len(got) != 2 || got["owner"] != datamodels.RoleEditor || got["ann"] != datamodels.RoleOwner {
+		t.Fatalf("got grants %v, want ann owner and the previous owner editor", got)
+	}
+}
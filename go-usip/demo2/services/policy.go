@@ -0,0 +1,115 @@
+package services
+
+import (
+	"go-usip/datamodels"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// Action is something a collaborator does with a file.
+type Action string
+
+const (
+	ActionView    Action = "view"
+	ActionComment Action = "comment"
+	ActionEdit    Action = "edit"
+	ActionExport  Action = "export"
+	ActionCopy    Action = "copy"
+	ActionRename  Action = "rename"
+	// ActionShare grants access to other users, owners only.
+	ActionShare Action = "share"
+	// ActionPublish lets anyone read the file, guests without an account included.
+	ActionPublish Action = "publish"
+	// ActionManage changes the role of collaborators or revokes their access.
+	ActionManage Action = "manage"
+	// ActionMove moves a file in or out of a workspace.
+	ActionMove     Action = "move"
+	ActionDelete   Action = "delete"
+	ActionTransfer Action = "transfer"
+)
+
+// actions lists every action, in the order AllowedActions returns them.
+var actions = []Action{
+	ActionView, ActionComment, ActionEdit, ActionExport, ActionCopy, ActionRename,
+	ActionShare, ActionPublish, ActionManage, ActionMove, ActionDelete, ActionTransfer,
+}
+
+// actionRoles is the lowest role allowed to do each action, higher roles in RoleLever may too.
+var actionRoles = map[Action]datamodels.Role{
+	ActionView:     datamodels.RoleReader,
+	ActionComment:  datamodels.RoleCommenter,
+	ActionEdit:     datamodels.RoleEditor,
+	ActionExport:   datamodels.RoleReader,
+	ActionCopy:     datamodels.RoleReader,
+	ActionRename:   datamodels.RoleEditor,
+	ActionShare:    datamodels.RoleOwner,
+	ActionPublish:  datamodels.RoleOwner,
+	ActionManage:   datamodels.RoleOwner,
+	ActionMove:     datamodels.RoleOwner,
+	ActionDelete:   datamodels.RoleOwner,
+	ActionTransfer: datamodels.RoleOwner,
+}
+
+// Allowed tells whether role may do action, unknown actions are denied.
+func Allowed(role datamodels.Role, action Action) bool {
+	minRole, ok := actionRoles[action]
+	return ok && role.Valid() && datamodels.RoleLever[role] >= datamodels.RoleLever[minRole]
+}
+
+// AllowedActions lists the actions role may do.
+func AllowedActions(role datamodels.Role) []Action {
+	allowed := make([]Action, 0, len(actions))
+	for _, action := range actions {
+		if Allowed(role, action) {
+			allowed = append(allowed, action)
+		}
+	}
+	return allowed
+}
+
+// CanGrant tells whether a sharer with role may give granted to someone, roles above their own are refused.
+func CanGrant(role datamodels.Role, granted datamodels.Role) bool {
+	return granted.Valid() && Allowed(role, ActionShare) && datamodels.RoleLever[granted] <= datamodels.RoleLever[role]
+}
+
+// authorize checks action against the effective role of a user on a file,
+// users without access do not learn the file exists.
+func authorize(role datamodels.Role, action Action) error {
+	if role == "" {
+		return ErrFileNotFound
+	}
+	if !Allowed(role, action) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+type RoleConfig struct {
+	// Custom maps the name of a custom role onto the built-in role it acts as,
+	// universer is told the built-in role.
+	Custom map[string]string
+}
+
+// LoadRoleConfig reads the roles key of the config file.
+func LoadRoleConfig() RoleConfig {
+	return RoleConfig{
+		Custom: viper.GetStringMapString("roles"),
+	}
+}
+
+// DefineRoles adds the custom roles of cfg to the known roles.
+func DefineRoles(cfg RoleConfig) error {
+	names := make([]string, 0, len(cfg.Custom))
+	for name := range cfg.Custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := datamodels.DefineRole(datamodels.Role(name), datamodels.Role(cfg.Custom[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
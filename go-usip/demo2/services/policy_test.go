@@ -0,0 +1,83 @@
+package services
+
+import (
+	"sync"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestOnlyOwnersShare(t *testing.T) {
+	for _, role := range []datamodels.Role{datamodels.RoleReader, datamodels.RoleCommenter, datamodels.RoleEditor} {
+		if Allowed(role, ActionShare) || CanGrant(role, datamodels.RoleReader) {
+			t.Errorf("%s may share", role)
+		}
+	}
+	if !CanGrant(datamodels.RoleOwner, datamodels.RoleEditor) {
+		t.Error("owners may not share")
+	}
+}
+
+// defineTestRoles defines the custom roles once, the known roles are global.
+var defineTestRoles sync.Once
+
+func TestCustomRolesActAsTheirBase(t *testing.T) {
+	defineTestRoles.Do(func() {
+		if err := DefineRoles(RoleConfig{Custom: map[string]string{"reviewer": "commenter", "translator": "editor"}}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err := DefineRoles(RoleConfig{Custom: map[string]string{"co-owner": "owner"}}); err == nil {
+		t.Fatal("a custom role acting as owner was defined")
+	}
+	reviewer, translator := datamodels.Role("reviewer"), datamodels.Role("translator")
+
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	fileService := NewFileService(fileRepo, collaRepo, repositories.NewWorkspaceMemberRepository(db),
+		repositories.NewUnitOfWork(db), nil, nil)
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: "owner", Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileService.Join(JoinReq{UserId: "owner", FileId: file.ID, UserIds: []string{"ann"}, Role: reviewer}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileService.Join(JoinReq{UserId: "owner", FileId: file.ID, UserIds: []string{"tom"}, Role: translator}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the host keeps the custom role, universer is told the base one.
+	for userId, want := range map[string]datamodels.Role{"ann": reviewer, "tom": translator} {
+		if got := fileService.GetRole(file.ID, userId); got != want {
+			t.Errorf("%s got role %q, want %q", userId, got, want)
+		}
+	}
+	if reviewer.Base() != datamodels.RoleCommenter || translator.Base() != datamodels.RoleEditor {
+		t.Errorf("got bases %q and %q", reviewer.Base(), translator.Base())
+	}
+	for _, check := range []struct {
+		userId  string
+		action  Action
+		allowed bool
+	}{
+		{"ann", ActionComment, true},
+		{"ann", ActionEdit, false},
+		{"tom", ActionEdit, true},
+		{"tom", ActionRename, true},
+		{"tom", ActionShare, false},
+	} {
+		if got := fileService.CheckPermission(CheckPermissionReq{FileId: file.ID, UserId: check.userId, Action: check.action}); got != check.allowed {
+			t.Errorf("%s %s: got %v, want %v", check.userId, check.action, got, check.allowed)
+		}
+	}
+	if CanGrant(translator, reviewer) {
+		t.Error("a custom editor may share")
+	}
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type ReconcileIssueKind string
+
+const (
+	// IssueMissingUnit is a file whose universer unit does not exist anymore,
+	// repairing deletes the file. It is only checked with universer.unitLifecycle.
+	IssueMissingUnit ReconcileIssueKind = "missing-unit"
+	// IssueOrphan is a personal file nobody collaborates on,
+	// repairing moves it to the trash which purges it with its unit after the retention period.
+	IssueOrphan ReconcileIssueKind = "orphan"
+	// IssueNoOwner is a file with collaborators but no owner,
+	// repairing makes the collaborator with the highest role its owner.
+	IssueNoOwner ReconcileIssueKind = "no-owner"
+)
+
+type ReconcileIssue struct {
+	Kind   ReconcileIssueKind
+	FileId uint
+	UnitId string
+	Detail string
+
+	Repaired bool
+	// Err is why the repair failed.
+	Err error
+}
+
+type ReconcileReport struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Files     int
+	// Unchecked counts the files universer could not tell about, their unit may be missing.
+	Unchecked int
+	// UnitCheckDisabled is set when universer.unitLifecycle is off, no file was checked for a missing unit.
+	UnitCheckDisabled bool
+	Issues            []ReconcileIssue
+	// DeletedUnits counts the orphan units deleted from universer,
+	// OrphanUnits those still queued because universer.unitLifecycle is off or the deletion failed.
+	DeletedUnits int
+	OrphanUnits  int
+}
+
+// ReconcileService compares the files of the host database with the universer units and their collaborators,
+// and deletes the queued orphan units from universer.
+type ReconcileService interface {
+	// Start runs the reconciliation every Interval, repairing the issues when Repair is set.
+	Start()
+	Run(ctx context.Context, repair bool) (ReconcileReport, error)
+}
+
+type ReconcileConfig struct {
+	// Interval between two runs, 0 disables the periodic run.
+	Interval time.Duration
+	Repair   bool
+	// BatchSize is how many files are loaded at once.
+	BatchSize int
+}
+
+// LoadReconcileConfig reads the reconcile.* keys of the config file.
+func LoadReconcileConfig() ReconcileConfig {
+	cfg := ReconcileConfig{
+		Interval:  24 * time.Hour,
+		Repair:    viper.GetBool("reconcile.repair"),
+		BatchSize: viper.GetInt("reconcile.batchSize"),
+	}
+	if viper.IsSet("reconcile.interval") {
+		cfg.Interval = viper.GetDuration("reconcile.interval")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return cfg
+}
+
+func NewReconcileService(cfg ReconcileConfig, repo repositories.FileRepository, collaRepo repositories.FileCollaboratorRepository,
+	orphanRepo repositories.OrphanUnitRepository, uSvc UniverserService) ReconcileService {
+	return &reconcileService{
+		cfg:        cfg,
+		repo:       repo,
+		collaRepo:  collaRepo,
+		orphanRepo: orphanRepo,
+		uSvc:       uSvc,
+	}
+}
+
+type reconcileService struct {
+	cfg        ReconcileConfig
+	repo       repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	orphanRepo repositories.OrphanUnitRepository
+	uSvc       UniverserService
+}
+
+func (s *reconcileService) Start() {
+	if s.cfg.Interval <= 0 {
+		return
+	}
+
+	go func() {
+		for {
+			time.Sleep(s.cfg.Interval)
+			report, err := s.Run(context.Background(), s.cfg.Repair)
+			if err != nil {
+				log.Printf("Error while reconciling: %v", err)
+				continue
+			}
+			LogReconcileReport(report)
+		}
+	}()
+}
+
+// LogReconcileReport logs a summary line and every issue of the report.
+func LogReconcileReport(report ReconcileReport) {
+	log.Printf("Reconciliation checked %d files in %s, %d issues, %d unchecked, %d orphan units deleted, %d still queued",
+		report.Files, report.Duration.Round(time.Millisecond), len(report.Issues), report.Unchecked,
+		report.DeletedUnits, report.OrphanUnits)
+	if report.UnitCheckDisabled {
+		log.Printf("Reconciliation did not check for missing units, universer.unitLifecycle is off")
+	}
+	for _, issue := range report.Issues {
+		status := "not repaired"
+		switch {
+		case issue.Err != nil:
+			status = "repair failed: " + issue.Err.Error()
+		case issue.Repaired:
+			status = "repaired"
+		}
+		log.Printf("Reconciliation %s file %d unit %s: %s, %s", issue.Kind, issue.FileId, issue.UnitId, issue.Detail, status)
+	}
+}
+
+func (s *reconcileService) Run(ctx context.Context, repair bool) (ReconcileReport, error) {
+	report := ReconcileReport{StartedAt: time.Now()}
+
+	var afterId uint
+	for {
+		files, found := s.repo.GetAfter(afterId, s.cfg.BatchSize)
+		if !found {
+			return report, errors.New("listing files failed")
+		}
+		if len(files) == 0 {
+			break
+		}
+		afterId = files[len(files)-1].ID
+
+		if err := s.checkBatch(ctx, files, repair, &report); err != nil {
+			return report, err
+		}
+	}
+
+	// the orphan units are not an issue to repair, nothing refers to them anymore.
+	if err := s.deleteOrphanUnits(ctx, &report); err != nil {
+		return report, err
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+func (s *reconcileService) checkBatch(ctx context.Context, files []datamodels.File, repair bool, report *ReconcileReport) error {
+	fileIds := make([]uint, 0, len(files))
+	for _, file := range files {
+		fileIds = append(fileIds, file.ID)
+	}
+	// without the collaborators every file would look orphaned.
+	collaborators, found := s.collaRepo.GetByFileIds(fileIds)
+	if !found {
+		return errors.New("listing collaborators failed")
+	}
+	byFile := make(map[uint][]datamodels.FileCollaborator, len(files))
+	for _, collaborator := range collaborators {
+		byFile[collaborator.FileId] = append(byFile[collaborator.FileId], collaborator)
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.Files++
+
+		exists, err := s.uSvc.UnitExists(ctx, UniverserUnitExistsReq{UnitId: file.UnitId, Type: file.UnitType})
+		switch {
+		case errors.Is(err, ErrUnitLifecycleDisabled):
+			// the units cannot be looked up, only the collaborators are checked.
+			report.UnitCheckDisabled = true
+		case err != nil:
+			report.Unchecked++
+		case !exists:
+			report.Issues = append(report.Issues, s.fix(ReconcileIssue{
+				Kind:   IssueMissingUnit,
+				FileId: file.ID,
+				UnitId: file.UnitId,
+				Detail: "universer does not know the unit",
+			}, repair, func() error { return s.deleteFile(file) }))
+			continue
+		}
+
+		fileCollaborators := byFile[file.ID]
+		if len(fileCollaborators) == 0 {
+			// workspace members keep access to a file without grants.
+			if file.WorkspaceId == datamodels.NoWorkspaceId {
+				report.Issues = append(report.Issues, s.fix(ReconcileIssue{
+					Kind:   IssueOrphan,
+					FileId: file.ID,
+					UnitId: file.UnitId,
+					Detail: "no collaborators",
+				}, repair, func() error { return s.repo.BatchDelete([]uint{file.ID}) }))
+			}
+			continue
+		}
+
+		heir := fileCollaborators[0]
+		hasOwner := false
+		for _, collaborator := range fileCollaborators {
+			hasOwner = hasOwner || collaborator.Role == datamodels.RoleOwner
+			if datamodels.RoleLever[collaborator.Role] > datamodels.RoleLever[heir.Role] ||
+				(collaborator.Role == heir.Role && collaborator.ID < heir.ID) {
+				heir = collaborator
+			}
+		}
+		if !hasOwner {
+			report.Issues = append(report.Issues, s.fix(ReconcileIssue{
+				Kind:   IssueNoOwner,
+				FileId: file.ID,
+				UnitId: file.UnitId,
+				Detail: fmt.Sprintf("no owner among %d collaborators, heir %s", len(fileCollaborators), heir.UserId),
+			}, repair, func() error { return s.promote(heir) }))
+		}
+	}
+	return nil
+}
+
+// deleteOrphanUnits deletes the queued units from universer, a unit stays queued until that succeeds.
+func (s *reconcileService) deleteOrphanUnits(ctx context.Context, report *ReconcileReport) error {
+	units, found := s.orphanRepo.GetAll()
+	if !found {
+		return errors.New("listing orphan units failed")
+	}
+	for i, unit := range units {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := s.uSvc.DeleteUnit(ctx, UniverserDeleteUnitReq{UnitId: unit.UnitId, Type: unit.UnitType})
+		if errors.Is(err, ErrUnitLifecycleDisabled) {
+			report.OrphanUnits += len(units) - i
+			return nil
+		}
+		if err != nil {
+			log.Printf("Error while deleting orphan unit %s: %v", unit.UnitId, err)
+			if err := s.orphanRepo.SetError(unit.ID, err.Error()); err != nil {
+				log.Printf("Error while recording the failure of orphan unit %s: %v", unit.UnitId, err)
+			}
+			report.OrphanUnits++
+			continue
+		}
+		if err := s.orphanRepo.Delete(unit.ID); err != nil {
+			// deleting an unknown unit succeeds, the next run drops it.
+			log.Printf("Error while dequeuing orphan unit %s: %v", unit.UnitId, err)
+		}
+		report.DeletedUnits++
+	}
+	return nil
+}
+
+// fix runs repairFn when repair is set and records the outcome on the issue.
+func (s *reconcileService) fix(issue ReconcileIssue, repair bool, repairFn func() error) ReconcileIssue {
+	if !repair {
+		return issue
+	}
+	if err := repairFn(); err != nil {
+		log.Printf("Error while repairing %s of file %d: %v", issue.Kind, issue.FileId, err)
+		issue.Err = err
+		return issue
+	}
+	issue.Repaired = true
+	return issue
+}
+
+// deleteFile removes a file whose unit is gone, there is nothing left to restore.
+func (s *reconcileService) deleteFile(file datamodels.File) error {
+	if err := s.collaRepo.DeleteByFileId(file.ID); err != nil {
+		return err
+	}
+	return s.repo.Purge(file.ID)
+}
+
+func (s *reconcileService) promote(heir datamodels.FileCollaborator) error {
+	return s.collaRepo.InsertOrUpdate([]datamodels.FileCollaborator{{
+		FileId: heir.FileId,
+		UserId: heir.UserId,
+		Role:   datamodels.RoleOwner,
+	}})
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/fakeuniverser"
+	"go-usip/repositories"
+)
+
+// brokenCollaborators fails listing the collaborators of files.
+type brokenCollaborators struct {
+	repositories.FileCollaboratorRepository
+}
+
+func (brokenCollaborators) GetByFileIds([]uint) ([]datamodels.FileCollaborator, bool) {
+	return nil, false
+}
+
+func TestReconcileServiceAbortsWithoutCollaborators(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{})
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: "1", Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileService := NewReconcileService(ReconcileConfig{BatchSize: 10}, fileRepo, brokenCollaborators{collaRepo},
+		repositories.NewOrphanUnitRepository(db), uSvc)
+	report, err := reconcileService.Run(context.Background(), true)
+	if err == nil {
+		t.Fatal("the run went on without the collaborators")
+	}
+	if len(report.Issues) != 0 {
+		t.Fatalf("reported issues from a failed listing: %+v", report.Issues)
+	}
+	if _, found := fileRepo.Get(file.ID); !found {
+		t.Fatal("the file was moved to the trash")
+	}
+}
+
+func TestReconcileServiceSkipsUnitsWithoutUnitLifecycle(t *testing.T) {
+	fake := fakeuniverser.New(fakeuniverser.Options{})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+
+	// universer does not know unit1, the check would report it missing.
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: "1", Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+
+	reconcileService := NewReconcileService(ReconcileConfig{BatchSize: 10}, fileRepo, collaRepo,
+		repositories.NewOrphanUnitRepository(db), NewUniverseService(UniverserConfig{Host: srv.URL}))
+	report, err := reconcileService.Run(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Unchecked != 0 || len(report.Issues) != 0 {
+		t.Fatalf("got %+v, want one file checked without issues", report)
+	}
+	if !report.UnitCheckDisabled {
+		t.Fatal("the report passes a unit check which did not run")
+	}
+	if _, found := fileRepo.Get(file.ID); !found {
+		t.Fatal("the file was deleted")
+	}
+}
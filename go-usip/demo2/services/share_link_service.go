@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"time"
+)
+
+var (
+	ErrShareLinkNotFound = errors.New("share link not found")
+	ErrShareLinkExpired  = errors.New("the share link has expired")
+	// ErrShareLinkUsedUp is returned once a link granted access as many times as it allows.
+	ErrShareLinkUsedUp = repositories.ErrShareLinkUsedUp
+	// ErrShareLinkPassword is returned when the password of a link is missing or wrong.
+	ErrShareLinkPassword = errors.New("the share link password is missing or wrong")
+	ErrInvalidMaxUses    = errors.New("the use limit cannot be negative")
+	ErrExpiresInPast     = errors.New("the expiry must be in the future")
+)
+
+// ShareLinkService shares files through token links: a logged in user opening
+// a link is granted its role, the same way as a collaborator added with Join.
+// Links stay valid until they expire, are used up or an owner revokes them,
+// the access they granted is kept after that.
+type ShareLinkService interface {
+	// Create makes a link granting role on the file, owners only.
+	Create(req CreateShareLinkReq) (datamodels.ShareLink, error)
+	// GetByFileId lists the links of the file which are not revoked, owners only.
+	GetByFileId(userId string, fileId uint) ([]datamodels.ShareLink, error)
+	// Revoke disables a link of the file, owners only.
+	Revoke(userId string, fileId uint, linkId uint) error
+
+	// Lookup returns the link of token while it can be redeemed, without checking its password.
+	Lookup(token string) (datamodels.ShareLink, error)
+	// Redeem grants userId the role of the link of token and returns the link.
+	// Users already granted more on the file keep their grant, every redemption counts as a use of the link.
+	Redeem(userId string, token string, password string) (datamodels.ShareLink, error)
+}
+
+type CreateShareLinkReq struct {
+	UserId string
+	FileId uint
+	Role   datamodels.Role
+	// ExpiresAt is nil for a link which never expires.
+	ExpiresAt *time.Time
+	// Password is empty for a link anyone with the token can redeem.
+	Password string
+	// MaxUses is how many users may redeem the link, 0 for no limit.
+	MaxUses int
+}
+
+func NewShareLinkService(repo repositories.ShareLinkRepository, fileRepo repositories.FileRepository,
+	collaRepo repositories.FileCollaboratorRepository, memberRepo repositories.WorkspaceMemberRepository,
+	uow repositories.UnitOfWork) ShareLinkService {
+	return &shareLinkService{
+		repo:       repo,
+		fileRepo:   fileRepo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		uow:        uow,
+	}
+}
+
+type shareLinkService struct {
+	repo       repositories.ShareLinkRepository
+	fileRepo   repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	uow        repositories.UnitOfWork
+}
+
+func (s *shareLinkService) authorize(userId string, fileId uint) error {
+	file, found := s.fileRepo.Get(fileId)
+	if !found {
+		return ErrFileNotFound
+	}
+	return authorize(effectiveRole(s.collaRepo, s.memberRepo, file, userId), ActionManage)
+}
+
+func (s *shareLinkService) Create(req CreateShareLinkReq) (datamodels.ShareLink, error) {
+	// a link is not a way to hand a file over, ownership is transferred instead.
+	if !req.Role.Valid() || req.Role.Base() == datamodels.RoleOwner {
+		return datamodels.ShareLink{}, ErrInvalidRole
+	}
+	if req.MaxUses < 0 {
+		return datamodels.ShareLink{}, ErrInvalidMaxUses
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return datamodels.ShareLink{}, ErrExpiresInPast
+	}
+	if err := s.authorize(req.UserId, req.FileId); err != nil {
+		return datamodels.ShareLink{}, err
+	}
+
+	token, err := datamodels.GenerateShareToken()
+	if err != nil {
+		return datamodels.ShareLink{}, err
+	}
+	link := datamodels.ShareLink{
+		Token:     token,
+		FileId:    req.FileId,
+		Role:      req.Role,
+		CreatedBy: req.UserId,
+		ExpiresAt: req.ExpiresAt,
+		MaxUses:   req.MaxUses,
+	}
+	if req.Password != "" {
+		if link.HashedPassword, err = datamodels.GeneratePassword(req.Password); err != nil {
+			return datamodels.ShareLink{}, err
+		}
+	}
+
+	link, err = s.repo.Create(link)
+	if err != nil {
+		log.Printf("Error while creating a share link of file %d: %v", req.FileId, err)
+		return datamodels.ShareLink{}, err
+	}
+	return link, nil
+}
+
+func (s *shareLinkService) GetByFileId(userId string, fileId uint) ([]datamodels.ShareLink, error) {
+	if err := s.authorize(userId, fileId); err != nil {
+		return nil, err
+	}
+	links, _ := s.repo.GetByFileId(fileId)
+	return links, nil
+}
+
+func (s *shareLinkService) Revoke(userId string, fileId uint, linkId uint) error {
+	if err := s.authorize(userId, fileId); err != nil {
+		return err
+	}
+	link, found := s.repo.Get(linkId)
+	if !found || link.FileId != fileId {
+		return ErrShareLinkNotFound
+	}
+	return s.repo.Delete(link.ID)
+}
+
+func (s *shareLinkService) Lookup(token string) (datamodels.ShareLink, error) {
+	link, err := s.find(token)
+	if err != nil {
+		return link, err
+	}
+	if link.UsedUp() {
+		return link, ErrShareLinkUsedUp
+	}
+	return link, nil
+}
+
+// find returns the link of token unless it was revoked or expired, used up links are returned.
+func (s *shareLinkService) find(token string) (datamodels.ShareLink, error) {
+	link, found := s.repo.GetByToken(token)
+	if !found {
+		return link, ErrShareLinkNotFound
+	}
+	// links of trashed or purged files are reported as not found, like the files.
+	if _, found := s.fileRepo.Get(link.FileId); !found {
+		return link, ErrShareLinkNotFound
+	}
+	if link.Expired(time.Now()) {
+		return link, ErrShareLinkExpired
+	}
+	return link, nil
+}
+
+func (s *shareLinkService) Redeem(userId string, token string, password string) (datamodels.ShareLink, error) {
+	link, err := s.find(token)
+	if err != nil {
+		return link, err
+	}
+	if len(link.HashedPassword) > 0 {
+		if ok, _ := datamodels.ValidatePassword(password, link.HashedPassword); !ok {
+			return link, ErrShareLinkPassword
+		}
+	}
+
+	// the use limit is checked when counting the use, see ShareLinkRepository.Use.
+	err = s.uow.Do(func(repos repositories.Repositories) error {
+		if err := repos.ShareLinks().Use(link.ID); err != nil {
+			return err
+		}
+		return raiseRoles(repos.FileCollaborators(), link.FileId, []string{userId}, link.Role, nil)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrShareLinkUsedUp) {
+			log.Printf("Error while redeeming share link %d: %v", link.ID, err)
+		}
+		return link, err
+	}
+	return link, nil
+}
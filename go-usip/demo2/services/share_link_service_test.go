@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestShareLinkServiceRedeem(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	linkRepo := repositories.NewShareLinkRepository(db)
+	shareLinkService := NewShareLinkService(linkRepo, fileRepo, collaRepo,
+		repositories.NewWorkspaceMemberRepository(db), repositories.NewUnitOfWork(db))
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "owner", Role: datamodels.RoleOwner},
+		{FileId: file.ID, UserId: "ed", Role: datamodels.RoleEditor},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	nextWeek := time.Now().Add(7 * 24 * time.Hour)
+	newLink := func(req CreateShareLinkReq) datamodels.ShareLink {
+		t.Helper()
+		req.UserId, req.FileId, req.Role = "owner", file.ID, datamodels.RoleReader
+		link, err := shareLinkService.Create(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return link
+	}
+	noGrant := func(userId string) {
+		t.Helper()
+		if grant, found := collaRepo.Get(file.ID, userId); found {
+			t.Fatalf("%s was granted %+v", userId, grant)
+		}
+	}
+
+	link := newLink(CreateShareLinkReq{Password: "secret", MaxUses: 2, ExpiresAt: &nextWeek})
+	if _, err := shareLinkService.Redeem("ann", link.Token, "guess"); !errors.Is(err, ErrShareLinkPassword) {
+		t.Fatalf("wrong password: got %v, want %v", err, ErrShareLinkPassword)
+	}
+	if _, err := shareLinkService.Redeem("ann", link.Token, ""); !errors.Is(err, ErrShareLinkPassword) {
+		t.Fatalf("no password: got %v, want %v", err, ErrShareLinkPassword)
+	}
+	noGrant("ann")
+	if _, err := shareLinkService.Redeem("ann", link.Token, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if grant, _ := collaRepo.Get(file.ID, "ann"); grant.Role != datamodels.RoleReader || grant.ExpiresAt != nil {
+		t.Fatalf("redeemed link granted %+v", grant)
+	}
+
+	// a user granted more keeps the grant.
+	if _, err := shareLinkService.Redeem("ed", link.Token, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if grant, _ := collaRepo.Get(file.ID, "ed"); grant.Role != datamodels.RoleEditor {
+		t.Fatalf("redeeming a reader link demoted the editor to %s", grant.Role)
+	}
+
+	// both uses are gone, only the guarded update of ShareLinkRepository.Use refuses the link:
+	// Redeem does not check the limit before its transaction.
+	if _, err := shareLinkService.Redeem("bob", link.Token, "secret"); !errors.Is(err, ErrShareLinkUsedUp) {
+		t.Fatalf("used up link: got %v, want %v", err, ErrShareLinkUsedUp)
+	}
+	noGrant("bob")
+	if link, _ := linkRepo.Get(link.ID); link.Uses != 2 {
+		t.Fatalf("link counted %d uses, want 2", link.Uses)
+	}
+
+	expired := newLink(CreateShareLinkReq{ExpiresAt: &nextWeek})
+	if err := db.Model(&datamodels.ShareLink{}).Where("id = ?", expired.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := shareLinkService.Redeem("bob", expired.Token, ""); !errors.Is(err, ErrShareLinkExpired) {
+		t.Fatalf("expired link: got %v, want %v", err, ErrShareLinkExpired)
+	}
+	noGrant("bob")
+
+	revoked := newLink(CreateShareLinkReq{})
+	if err := shareLinkService.Revoke("owner", file.ID, revoked.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := shareLinkService.Redeem("bob", revoked.Token, ""); !errors.Is(err, ErrShareLinkNotFound) {
+		t.Fatalf("revoked link: got %v, want %v", err, ErrShareLinkNotFound)
+	}
+	noGrant("bob")
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+var (
+	ErrNotOwner    = errors.New("only owners can move files to the trash")
+	ErrOwnerRemove = errors.New("owners move files to the trash instead of removing them from their list")
+)
+
+// TrashService soft deletes files: a trashed file is hidden from every collaborator
+// until an owner restores it, it is purged manually or after the retention period,
+// its universer unit with it when universer.unitLifecycle is set, the unit is queued
+// for the reconciliation otherwise.
+type TrashService interface {
+	// Start purges the files trashed for longer than the retention period, every PurgeInterval.
+	Start()
+
+	// GetByUserId lists the trashed files userId owns, newest first.
+	GetByUserId(userId string) ([]datamodels.File, error)
+	// Trash moves files to the trash, none is moved unless userId owns them all.
+	Trash(userId string, fileIds []uint) error
+	Restore(userId string, fileId uint) error
+	// Purge removes a trashed file for good, its universer unit is deleted with universer.unitLifecycle
+	// and queued as an orphan unit otherwise.
+	Purge(ctx context.Context, userId string, fileId uint) error
+	// PurgeExpired purges the files trashed before the retention period and returns how many were,
+	// a file failing to purge does not stop the others, the errors are joined.
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+type TrashConfig struct {
+	// Retention is how long a file stays in the trash, 0 keeps it until purged by hand.
+	Retention     time.Duration
+	PurgeInterval time.Duration
+}
+
+// LoadTrashConfig reads the trash.* keys of the config file.
+func LoadTrashConfig() TrashConfig {
+	cfg := TrashConfig{
+		Retention:     30 * 24 * time.Hour,
+		PurgeInterval: viper.GetDuration("trash.purgeInterval"),
+	}
+	if viper.IsSet("trash.retention") {
+		cfg.Retention = viper.GetDuration("trash.retention")
+	}
+	if cfg.PurgeInterval <= 0 {
+		cfg.PurgeInterval = time.Hour
+	}
+	return cfg
+}
+
+func NewTrashService(cfg TrashConfig, repo repositories.FileRepository, collaRepo repositories.FileCollaboratorRepository,
+	memberRepo repositories.WorkspaceMemberRepository, uow repositories.UnitOfWork, uSvc UniverserService) TrashService {
+	return &trashService{
+		cfg:        cfg,
+		repo:       repo,
+		collaRepo:  collaRepo,
+		memberRepo: memberRepo,
+		uow:        uow,
+		uSvc:       uSvc,
+	}
+}
+
+type trashService struct {
+	cfg        TrashConfig
+	repo       repositories.FileRepository
+	collaRepo  repositories.FileCollaboratorRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	uow        repositories.UnitOfWork
+	uSvc       UniverserService
+}
+
+func (s *trashService) Start() {
+	if s.cfg.Retention <= 0 {
+		log.Printf("Trash retention is disabled, trashed files are kept until purged")
+		return
+	}
+
+	go func() {
+		for {
+			n, err := s.PurgeExpired(context.Background())
+			if err != nil {
+				log.Printf("Error while purging the trash: %v", err)
+			}
+			if n > 0 {
+				log.Printf("Purged %d files from the trash", n)
+			}
+			time.Sleep(s.cfg.PurgeInterval)
+		}
+	}()
+}
+
+func (s *trashService) GetByUserId(userId string) ([]datamodels.File, error) {
+	var fileIds []uint
+	collaborators, _ := s.collaRepo.GetByUserId(userId)
+	for _, collaborator := range collaborators {
+		if collaborator.Role == datamodels.RoleOwner {
+			fileIds = append(fileIds, collaborator.FileId)
+		}
+	}
+	var workspaceIds []uint
+	members, _ := s.memberRepo.GetByUserId(userId)
+	for _, member := range members {
+		if member.Role == datamodels.RoleOwner {
+			workspaceIds = append(workspaceIds, member.WorkspaceId)
+		}
+	}
+	if len(fileIds) == 0 && len(workspaceIds) == 0 {
+		return nil, nil
+	}
+
+	files, _ := s.repo.GetDeletedIn(fileIds, workspaceIds)
+	return files, nil
+}
+
+func (s *trashService) Trash(userId string, fileIds []uint) error {
+	files, _ := s.repo.BatchGet(fileIds)
+	if len(files) != len(fileIds) {
+		return ErrFileNotFound
+	}
+	for _, file := range files {
+		if err := authorize(effectiveRole(s.collaRepo, s.memberRepo, file, userId), ActionDelete); err != nil {
+			if errors.Is(err, ErrForbidden) {
+				return ErrNotOwner
+			}
+			return err
+		}
+	}
+
+	if err := s.repo.BatchDelete(fileIds); err != nil {
+		log.Printf("Error while moving files to the trash: %v", err)
+		return err
+	}
+	return nil
+}
+
+// getTrashed returns a trashed file userId owns.
+func (s *trashService) getTrashed(userId string, fileId uint) (datamodels.File, error) {
+	file, found := s.repo.GetDeleted(fileId)
+	if !found || effectiveRole(s.collaRepo, s.memberRepo, file, userId) != datamodels.RoleOwner {
+		return datamodels.File{}, ErrFileNotFound
+	}
+	return file, nil
+}
+
+func (s *trashService) Restore(userId string, fileId uint) error {
+	if _, err := s.getTrashed(userId, fileId); err != nil {
+		return err
+	}
+
+	if err := s.repo.Restore(fileId); err != nil {
+		log.Printf("Error while restoring file: %v", err)
+		return err
+	}
+	return nil
+}
+
+func (s *trashService) Purge(ctx context.Context, userId string, fileId uint) error {
+	file, err := s.getTrashed(userId, fileId)
+	if err != nil {
+		return err
+	}
+	return s.purge(ctx, file)
+}
+
+func (s *trashService) PurgeExpired(ctx context.Context) (int, error) {
+	files, found := s.repo.GetDeletedBefore(time.Now().Add(-s.cfg.Retention))
+	if !found {
+		return 0, errors.New("listing expired files failed")
+	}
+
+	purged := 0
+	var errs []error
+	for _, file := range files {
+		if err := s.purge(ctx, file); err != nil {
+			// the file stays in the trash, the next run tries again.
+			errs = append(errs, err)
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purge deletes the universer unit first, the file is kept when that fails so the purge can be retried.
+// Without universer.unitLifecycle the unit is queued, the reconciliation deletes it once it is set.
+func (s *trashService) purge(ctx context.Context, file datamodels.File) error {
+	err := s.uSvc.DeleteUnit(ctx, UniverserDeleteUnitReq{
+		UnitId: file.UnitId,
+		Type:   file.UnitType,
+	})
+	queueUnit := errors.Is(err, ErrUnitLifecycleDisabled)
+	if err != nil && !queueUnit {
+		log.Printf("Error while deleting the unit of file %d: %v", file.ID, err)
+		return err
+	}
+
+	err = s.uow.Do(func(repos repositories.Repositories) error {
+		if queueUnit {
+			if _, err := repos.OrphanUnits().Create(datamodels.OrphanUnit{
+				UnitId:   file.UnitId,
+				UnitType: file.UnitType,
+				Reason:   datamodels.OrphanPurged,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := repos.FileCollaborators().DeleteByFileId(file.ID); err != nil {
+			return err
+		}
+		return repos.Files().Purge(file.ID)
+	})
+	if err != nil {
+		log.Printf("Error while purging file %d: %v", file.ID, err)
+		return err
+	}
+	return nil
+}
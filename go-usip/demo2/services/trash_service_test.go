@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-usip/datamodels"
+	"go-usip/fakeuniverser"
+	"go-usip/repositories"
+)
+
+// failingDeletes fails the DeleteUnit calls of some units.
+type failingDeletes struct {
+	UniverserService
+	unitIds map[string]bool
+}
+
+var errDeleteFailed = errors.New("delete failed")
+
+func (u failingDeletes) DeleteUnit(ctx context.Context, req UniverserDeleteUnitReq) error {
+	if u.unitIds[req.UnitId] {
+		return errDeleteFailed
+	}
+	return u.UniverserService.DeleteUnit(ctx, req)
+}
+
+func TestTrashServicePurgeExpiredContinuesAfterFailures(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{})
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+
+	var fileIds []uint
+	for _, unitId := range []string{"unit1", "unit2", "unit3"} {
+		file, err := fileRepo.Create(datamodels.File{Name: unitId, UnitId: unitId, UnitType: datamodels.UnitTypeSheet})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: "1", Role: datamodels.RoleOwner}); err != nil {
+			t.Fatal(err)
+		}
+		fileIds = append(fileIds, file.ID)
+	}
+	if err := fileRepo.BatchDelete(fileIds); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	trashService := NewTrashService(TrashConfig{Retention: time.Millisecond}, fileRepo, collaRepo,
+		repositories.NewWorkspaceMemberRepository(db), repositories.NewUnitOfWork(db),
+		failingDeletes{UniverserService: uSvc, unitIds: map[string]bool{"unit2": true}})
+
+	purged, err := trashService.PurgeExpired(context.Background())
+	if purged != 2 {
+		t.Fatalf("purged %d files, want 2", purged)
+	}
+	if !errors.Is(err, errDeleteFailed) {
+		t.Fatalf("got %v, want %v", err, errDeleteFailed)
+	}
+
+	for i, fileId := range fileIds {
+		_, trashed := fileRepo.GetDeleted(fileId)
+		if trashed != (i == 1) {
+			t.Errorf("file %d still in the trash: %v", fileId, trashed)
+		}
+	}
+	if collaborators, _ := collaRepo.GetByFileId(fileIds[1]); len(collaborators) != 1 {
+		t.Errorf("the file failing to purge lost its collaborators: %v", collaborators)
+	}
+}
+
+func TestTrashServicePurgeQueuesUnitsWithoutUnitLifecycle(t *testing.T) {
+	fake := fakeuniverser.New(fakeuniverser.Options{})
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	uSvc := NewUniverseService(UniverserConfig{Host: srv.URL})
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	orphanRepo := repositories.NewOrphanUnitRepository(db)
+
+	unitId, err := uSvc.CreateUnit(context.Background(), CreateUnitRequest{Name: "Budget", Type: datamodels.FileTypeStr(datamodels.UnitTypeSheet), UserId: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: unitId, UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: "1", Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fileRepo.BatchDelete([]uint{file.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	trashService := NewTrashService(TrashConfig{}, fileRepo, collaRepo,
+		repositories.NewWorkspaceMemberRepository(db), repositories.NewUnitOfWork(db), uSvc)
+	if err := trashService.Purge(context.Background(), "1", file.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, trashed := fileRepo.GetDeleted(file.ID); trashed {
+		t.Fatal("the file is still in the trash")
+	}
+	if _, ok := fake.Unit(unitId); !ok {
+		t.Fatal("the unit was deleted with universer.unitLifecycle off")
+	}
+	if units, _ := orphanRepo.GetAll(); len(units) != 1 || units[0].UnitId != unitId || units[0].Reason != datamodels.OrphanPurged {
+		t.Fatalf("got orphan units %+v, want the purged unit", units)
+	}
+
+	// the unit stays queued until universer.unitLifecycle is set.
+	report, err := NewReconcileService(ReconcileConfig{BatchSize: 10}, fileRepo, collaRepo, orphanRepo, uSvc).
+		Run(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrphanUnits != 1 || report.DeletedUnits != 0 {
+		t.Fatalf("got %+v, want the unit still queued", report)
+	}
+
+	uSvc = NewUniverseService(UniverserConfig{Host: srv.URL, UnitLifecycle: true})
+	report, err = NewReconcileService(ReconcileConfig{BatchSize: 10}, fileRepo, collaRepo, orphanRepo, uSvc).
+		Run(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OrphanUnits != 0 || report.DeletedUnits != 1 {
+		t.Fatalf("got %+v, want the unit deleted", report)
+	}
+	if _, ok := fake.Unit(unitId); ok {
+		t.Fatal("the queued unit is still in universer")
+	}
+	if units, _ := orphanRepo.GetAll(); len(units) != 0 {
+		t.Fatalf("units still queued after their deletion: %+v", units)
+	}
+}
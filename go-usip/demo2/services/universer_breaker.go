@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails every call with ErrUniverserUnavailable without calling universer.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single trial call through to find out whether universer is back.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// UniverserBreaker is a UniverserService guarded by a circuit breaker.
+type UniverserBreaker interface {
+	UniverserService
+
+	State() BreakerState
+	// Available reports whether calls are currently let through.
+	Available() bool
+}
+
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive outages tripping the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before a trial call.
+	OpenTimeout time.Duration
+}
+
+// LoadBreakerConfig reads the universer.breaker.* keys of the config file.
+func LoadBreakerConfig() BreakerConfig {
+	cfg := BreakerConfig{
+		FailureThreshold: viper.GetInt("universer.breaker.failureThreshold"),
+		OpenTimeout:      viper.GetDuration("universer.breaker.openTimeout"),
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	return cfg
+}
+
+// NewUniverserBreaker wraps inner with a circuit breaker.
+// Only errors wrapping ErrUniverserUnavailable count as failures,
+// universer rejecting a request means it is up.
+func NewUniverserBreaker(inner UniverserService, cfg BreakerConfig) UniverserBreaker {
+	return &universerBreaker{
+		inner: inner,
+		cfg:   cfg,
+		state: BreakerClosed,
+	}
+}
+
+type universerBreaker struct {
+	inner UniverserService
+	cfg   BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	// trial is set while the half-open trial call is in flight.
+	trial bool
+}
+
+func (b *universerBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.currentState()
+}
+
+func (b *universerBreaker) Available() bool {
+	return b.State() != BreakerOpen
+}
+
+// currentState moves an open breaker to half-open once OpenTimeout elapsed, b.mu must be held.
+func (b *universerBreaker) currentState() BreakerState {
+	if b.state == BreakerOpen && time.Since(b.openedAt) >= b.cfg.OpenTimeout {
+		b.state = BreakerHalfOpen
+		b.trial = false
+		log.Printf("Universer circuit breaker half-open, trying universer again")
+	}
+	return b.state
+}
+
+func (b *universerBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.currentState() {
+	case BreakerOpen:
+		return &UnavailableError{UniverserError{Op: "calling universer", Message: "circuit breaker is open"}}
+	case BreakerHalfOpen:
+		if b.trial {
+			return &UnavailableError{UniverserError{Op: "calling universer", Message: "circuit breaker is half-open"}}
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *universerBreaker) done(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	switch {
+	case errors.Is(err, ErrUniverserUnavailable):
+		b.failures++
+		if b.state == BreakerHalfOpen || b.failures >= b.cfg.FailureThreshold {
+			if b.state != BreakerOpen {
+				log.Printf("Universer circuit breaker open after %d failures: %v", b.failures, err)
+			}
+			b.state = BreakerOpen
+			b.openedAt = time.Now()
+		}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, ErrUnitLifecycleDisabled):
+		// the caller gave up or the call never left the host, this says nothing about universer.
+	default:
+		if b.state != BreakerClosed {
+			log.Printf("Universer circuit breaker closed, universer is back")
+		}
+		b.state = BreakerClosed
+		b.failures = 0
+	}
+}
+
+func (b *universerBreaker) CreateUnit(ctx context.Context, req CreateUnitRequest) (string, error) {
+	if err := b.allow(); err != nil {
+		return "", err
+	}
+	unitId, err := b.inner.CreateUnit(ctx, req)
+	b.done(err)
+	return unitId, err
+}
+
+func (b *universerBreaker) UploadFile(ctx context.Context, req ImportReq) (string, error) {
+	if err := b.allow(); err != nil {
+		return "", err
+	}
+	fileId, err := b.inner.UploadFile(ctx, req)
+	b.done(err)
+	return fileId, err
+}
+
+func (b *universerBreaker) Import(ctx context.Context, req UniverserImportReq) (string, error) {
+	if err := b.allow(); err != nil {
+		return "", err
+	}
+	taskId, err := b.inner.Import(ctx, req)
+	b.done(err)
+	return taskId, err
+}
+
+func (b *universerBreaker) PullResult(ctx context.Context, req UniverserPullReq) (string, error) {
+	if err := b.allow(); err != nil {
+		return "", err
+	}
+	result, err := b.inner.PullResult(ctx, req)
+	b.done(err)
+	return result, err
+}
+
+func (b *universerBreaker) Export(ctx context.Context, req UniverserExportReq) (string, error) {
+	if err := b.allow(); err != nil {
+		return "", err
+	}
+	taskId, err := b.inner.Export(ctx, req)
+	b.done(err)
+	return taskId, err
+}
+
+func (b *universerBreaker) DeleteUnit(ctx context.Context, req UniverserDeleteUnitReq) error {
+	if err := b.allow(); err != nil {
+		return err
+	}
+	err := b.inner.DeleteUnit(ctx, req)
+	b.done(err)
+	return err
+}
+
+func (b *universerBreaker) UnitExists(ctx context.Context, req UniverserUnitExistsReq) (bool, error) {
+	if err := b.allow(); err != nil {
+		return false, err
+	}
+	exists, err := b.inner.UnitExists(ctx, req)
+	b.done(err)
+	return exists, err
+}
+
+func (b *universerBreaker) GetFile(ctx context.Context, req UniverserGetFileReq) (io.ReadCloser, error) {
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	reader, err := b.inner.GetFile(ctx, req)
+	b.done(err)
+	return reader, err
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// downUniverser is unreachable, and has the unit lifecycle calls disabled like the default config.
+type downUniverser struct {
+	UniverserService
+}
+
+func (downUniverser) CreateUnit(context.Context, CreateUnitRequest) (string, error) {
+	return "", &UnavailableError{UniverserError{Op: "creating unit", Message: "connection refused"}}
+}
+
+func (downUniverser) UnitExists(context.Context, UniverserUnitExistsReq) (bool, error) {
+	return false, ErrUnitLifecycleDisabled
+}
+
+func (downUniverser) DeleteUnit(context.Context, UniverserDeleteUnitReq) error {
+	return ErrUnitLifecycleDisabled
+}
+
+func TestUniverserBreakerIgnoresDisabledUnitLifecycle(t *testing.T) {
+	breaker := NewUniverserBreaker(downUniverser{}, BreakerConfig{FailureThreshold: 1, OpenTimeout: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	if _, err := breaker.CreateUnit(ctx, CreateUnitRequest{}); err == nil {
+		t.Fatal("universer is down")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("breaker is %s, want %s", state, BreakerHalfOpen)
+	}
+
+	if _, err := breaker.UnitExists(ctx, UniverserUnitExistsReq{UnitId: "unit1"}); err != ErrUnitLifecycleDisabled {
+		t.Fatalf("got %v, want %v", err, ErrUnitLifecycleDisabled)
+	}
+	if err := breaker.DeleteUnit(ctx, UniverserDeleteUnitReq{UnitId: "unit1"}); err != ErrUnitLifecycleDisabled {
+		t.Fatalf("got %v, want %v", err, ErrUnitLifecycleDisabled)
+	}
+	if state := breaker.State(); state != BreakerHalfOpen {
+		t.Fatalf("breaker is %s without universer answering, want %s", state, BreakerHalfOpen)
+	}
+
+	// the trial call is still available and fails, opening the breaker again.
+	if _, err := breaker.CreateUnit(ctx, CreateUnitRequest{}); err == nil {
+		t.Fatal("universer is down")
+	}
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("breaker is %s, want %s", state, BreakerOpen)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// universerSuccessCode is the code of UniverserErr in successful responses,
+// universer documents no other code so failures are typed by their HTTP status only.
+const universerSuccessCode = 1
+
+// UniverserError is a universer call which failed.
+// Code is the universer error code, 0 when universer gave none,
+// e.g. it could not be reached or the request was rejected before calling it.
+//
+// The kinds callers care about have their own type embedding UniverserError,
+// match them with errors.As.
+type UniverserError struct {
+	Op      string
+	Code    int
+	Message string
+	Err     error
+}
+
+func (e *UniverserError) Error() string {
+	msg := e.Op + ": " + e.Message
+	if e.Code != 0 {
+		msg += fmt.Sprintf(" (universer code %d)", e.Code)
+	}
+	return msg
+}
+
+func (e *UniverserError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError is returned when the unit, file or task does not exist in universer.
+type NotFoundError struct{ UniverserError }
+
+// PermissionDeniedError is returned when universer refuses the user the operation.
+type PermissionDeniedError struct{ UniverserError }
+
+// InvalidFileError is returned for files universer cannot read, like a broken .xlsx.
+type InvalidFileError struct{ UniverserError }
+
+// QuotaExceededError is returned when the file or the user storage is over the universer quota.
+type QuotaExceededError struct{ UniverserError }
+
+// TimeoutError is returned when universer did not answer in time.
+// It is an outage as well, errors.Is(err, ErrUniverserUnavailable) holds.
+type TimeoutError struct{ UniverserError }
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrUniverserUnavailable
+}
+
+// UnavailableError is returned when universer could not be reached, answered with a 5xx
+// or the circuit breaker is open. errors.Is(err, ErrUniverserUnavailable) holds.
+type UnavailableError struct{ UniverserError }
+
+func (e *UnavailableError) Is(target error) bool {
+	return target == ErrUniverserUnavailable
+}
+
+// codeError reports the error universer gave in the body of a 200 response, it is left untyped.
+func codeError(op string, e UniverserErr) error {
+	return &UniverserError{Op: op, Code: e.Code, Message: e.Message}
+}
+
+// transportError types a failed round trip, an outage unless the caller gave up.
+func transportError(op string, err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	base := UniverserError{Op: op, Message: err.Error(), Err: err}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return &TimeoutError{base}
+	}
+	return &UnavailableError{base}
+}
+
+// statusError types a non-200 response.
+func statusError(op string, resp *resty.Response) error {
+	base := UniverserError{Op: op, Message: resp.Status()}
+	if body := resp.String(); body != "" {
+		base.Message = body
+	}
+
+	switch code := resp.StatusCode(); {
+	case code == http.StatusNotFound:
+		return &NotFoundError{base}
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return &PermissionDeniedError{base}
+	case code == http.StatusBadRequest, code == http.StatusUnsupportedMediaType, code == http.StatusUnprocessableEntity:
+		return &InvalidFileError{base}
+	case code == http.StatusRequestEntityTooLarge, code == http.StatusTooManyRequests:
+		return &QuotaExceededError{base}
+	case code == http.StatusGatewayTimeout:
+		return &TimeoutError{base}
+	case code >= http.StatusInternalServerError:
+		return &UnavailableError{base}
+	default:
+		return &base
+	}
+}
+
+// decodeError reports a response body which is not what universer should send.
+func decodeError(op string, err error) error {
+	return &UniverserError{Op: op, Message: "unexpected response: " + err.Error(), Err: err}
+}
@@ -1,33 +1,146 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go-usip/datamodels"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/spf13/viper"
 )
 
-const universerSuccessCode = 1
+// ErrUniverserTaskFailed is returned by PullResult when the task finished unsuccessfully.
+var ErrUniverserTaskFailed = errors.New("universer task failed")
 
+// ErrUnitLifecycleDisabled is returned by DeleteUnit and UnitExists while universer.unitLifecycle is off.
+var ErrUnitLifecycleDisabled = errors.New("universer unit lifecycle calls are disabled, see universer.unitLifecycle")
+
+// ErrUniverserUnavailable matches the UnavailableError and TimeoutError outages with errors.Is.
+var ErrUniverserUnavailable = errors.New("universer is temporarily unavailable")
+
+// UniverserService calls the universer api. Every call is bound to ctx,
+// the idempotent ones (PullResult, GetFile) are retried on network errors and 5xx.
+// Failures are typed, see UniverserError.
 type UniverserService interface {
-	CreateUnit(req CreateUnitRequest) (unitId string, err error)
-	UploadFile(req ImportReq) (fileId string, err error)
-	Import(req UniverserImportReq) (taskId string, err error)
-	PullResult(req UniverserPullReq) (string, error)
-	Export(req UniverserExportReq) (taskId string, err error)
-	GetFile(req UniverserGetFileReq) (reader io.ReadCloser, err error)
+	CreateUnit(ctx context.Context, req CreateUnitRequest) (unitId string, err error)
+	UploadFile(ctx context.Context, req ImportReq) (fileId string, err error)
+	Import(ctx context.Context, req UniverserImportReq) (taskId string, err error)
+	PullResult(ctx context.Context, req UniverserPullReq) (string, error)
+	Export(ctx context.Context, req UniverserExportReq) (taskId string, err error)
+	GetFile(ctx context.Context, req UniverserGetFileReq) (reader io.ReadCloser, err error)
+	// DeleteUnit removes a unit for good, deleting a unit universer does not know is not an error.
+	// It returns ErrUnitLifecycleDisabled unless UnitLifecycle is set.
+	DeleteUnit(ctx context.Context, req UniverserDeleteUnitReq) error
+	// UnitExists tells whether universer knows a unit, errors mean it could not tell.
+	// It returns ErrUnitLifecycleDisabled unless UnitLifecycle is set.
+	UnitExists(ctx context.Context, req UniverserUnitExistsReq) (bool, error)
 }
 
-func NewUniverseService() UniverserService {
-	return &universeService{}
+type UniverserConfig struct {
+	Host string
+
+	DialTimeout time.Duration
+	// Timeout bounds the wait for response headers,
+	// streamed downloads are not cut off while reading the body.
+	Timeout time.Duration
+
+	MaxRetries   int
+	RetryWait    time.Duration
+	RetryMaxWait time.Duration
+
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// UnitLifecycle enables deleting units and looking them up. The endpoints are the ones of fakeuniverser,
+	// they are not part of the documented universer api, so they are off unless the deployment has them.
+	UnitLifecycle bool
 }
 
-type universeService struct{}
+// LoadUniverserConfig reads the universer.* keys of the config file.
+func LoadUniverserConfig() UniverserConfig {
+	cfg := UniverserConfig{
+		Host:                strings.TrimRight(viper.GetString("universer.host"), "/"),
+		DialTimeout:         viper.GetDuration("universer.dialTimeout"),
+		Timeout:             viper.GetDuration("universer.timeout"),
+		MaxRetries:          viper.GetInt("universer.maxRetries"),
+		RetryWait:           viper.GetDuration("universer.retryWait"),
+		RetryMaxWait:        viper.GetDuration("universer.retryMaxWait"),
+		MaxIdleConns:        viper.GetInt("universer.maxIdleConns"),
+		MaxIdleConnsPerHost: viper.GetInt("universer.maxIdleConnsPerHost"),
+		IdleConnTimeout:     viper.GetDuration("universer.idleConnTimeout"),
+		UnitLifecycle:       viper.GetBool("universer.unitLifecycle"),
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.RetryWait <= 0 {
+		cfg.RetryWait = 200 * time.Millisecond
+	}
+	if cfg.RetryMaxWait < cfg.RetryWait {
+		cfg.RetryMaxWait = 10 * cfg.RetryWait
+	}
+	if cfg.MaxIdleConns <= 0 {
+		cfg.MaxIdleConns = 100
+	}
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = 20
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = 90 * time.Second
+	}
+	return cfg
+}
+
+func NewUniverseService(cfg UniverserConfig) UniverserService {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: cfg.DialTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          cfg.MaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+	}
+	httpClient := &http.Client{Transport: transport}
+
+	// both clients share the transport and therefore its connections.
+	retryClient := resty.NewWithClient(httpClient).
+		SetRetryCount(cfg.MaxRetries).
+		SetRetryWaitTime(cfg.RetryWait).
+		SetRetryMaxWaitTime(cfg.RetryMaxWait).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			if err != nil {
+				return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+			}
+			return resp.StatusCode() >= http.StatusInternalServerError
+		})
+
+	return &universeService{
+		cfg:         cfg,
+		client:      resty.NewWithClient(httpClient),
+		retryClient: retryClient,
+	}
+}
+
+type universeService struct {
+	cfg UniverserConfig
+
+	// client never retries, it is used for calls creating something in universer.
+	client      *resty.Client
+	retryClient *resty.Client
+}
 
 type UniverserErr struct {
 	Code    int    `json:"code"`
@@ -38,29 +151,33 @@ type CreateUnitRequest struct {
 	Name   string `json:"name"`
 	Type   string `json:"type"`
 	UserId string `json:"user_id"`
+	// WorkspaceId is the workspace the file is created in, it is not sent to universer.
+	WorkspaceId uint `json:"-"`
+	// SourceFileId is the file a copy is made of, it is not sent to universer.
+	SourceFileId uint `json:"-"`
 
 	Cookie string `json:"-"`
 }
 
-func (s *universeService) CreateUnit(req CreateUnitRequest) (string, error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) CreateUnit(ctx context.Context, req CreateUnitRequest) (string, error) {
+	resp, err := s.client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Cookie", req.Cookie).
 		SetBody(map[string]string{
 			"name":    req.Name,
 			"creator": req.UserId,
 		}).
-		Post(fmt.Sprintf("%s/universer-api/snapshot/%d/unit/-/create", viper.GetString("universer.host"), datamodels.FileTypeInt(req.Type)))
+		Post(fmt.Sprintf("%s/universer-api/snapshot/%d/unit/-/create", s.cfg.Host, datamodels.FileTypeInt(req.Type)))
 
 	if err != nil {
 		log.Printf("Error while creating unit: %v", err)
-		return "", err
+		return "", transportError("creating unit", err)
 	}
 
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while creating unit: %v", resp.String())
-		return "", fmt.Errorf("Error while creating unit: %v", resp.String())
+		return "", statusError("creating unit", resp)
 	}
 	body := resp.Body()
 	var unit struct {
@@ -69,12 +186,12 @@ func (s *universeService) CreateUnit(req CreateUnitRequest) (string, error) {
 	}
 	if err := json.Unmarshal(body, &unit); err != nil {
 		log.Printf("Error while creating unit: %v", err)
-		return "", err
+		return "", decodeError("creating unit", err)
 	}
 
 	if unit.Error.Code != universerSuccessCode {
 		log.Printf("Error while creating unit: %v", unit.Error.Message)
-		return "", fmt.Errorf("Error while creating unit: %v", unit.Error.Message)
+		return "", codeError("creating unit", unit.Error)
 	}
 
 	return unit.UnitId, nil
@@ -84,20 +201,20 @@ type UploadFileResp struct {
 	FileId string `json:"FileId"`
 }
 
-func (s *universeService) UploadFile(req ImportReq) (fileId string, err error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) UploadFile(ctx context.Context, req ImportReq) (fileId string, err error) {
+	resp, err := s.client.R().
+		SetContext(ctx).
 		SetHeader("Cookie", req.Cookie).
 		SetFileReader("file", req.FileName, req.FormFile).
-		Post(fmt.Sprintf("%s/universer-api/stream/file/upload?size=%d", viper.GetString("universer.host"), req.FileSize))
+		Post(fmt.Sprintf("%s/universer-api/stream/file/upload?size=%d", s.cfg.Host, req.FileSize))
 	if err != nil {
 		log.Printf("Error while uploading file: %v", err)
-		return
+		return "", transportError("uploading file", err)
 	}
 
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while uploading file: %v", resp.String())
-		return "", fmt.Errorf("Error while uploading file: %v", resp.String())
+		return "", statusError("uploading file", resp)
 	}
 
 	body := resp.Body()
@@ -106,7 +223,7 @@ func (s *universeService) UploadFile(req ImportReq) (fileId string, err error) {
 	}
 	if err = json.Unmarshal(body, &fileResp); err != nil {
 		log.Printf("Error while uploading file: %v", err)
-		return
+		return "", decodeError("uploading file", err)
 	}
 
 	return fileResp.FileId, nil
@@ -120,24 +237,24 @@ type UniverserImportReq struct {
 	Cookie string `json:"-"`
 }
 
-func (s *universeService) Import(req UniverserImportReq) (taskId string, err error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) Import(ctx context.Context, req UniverserImportReq) (taskId string, err error) {
+	resp, err := s.client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Cookie", req.Cookie).
 		SetBody(map[string]interface{}{
 			"fileID":     req.FileId,
 			"outputType": req.OutputType,
 		}).
-		Post(fmt.Sprintf("%s/universer-api/exchange/%d/import", viper.GetString("universer.host"), req.Type))
+		Post(fmt.Sprintf("%s/universer-api/exchange/%d/import", s.cfg.Host, req.Type))
 	if err != nil {
 		log.Printf("Error while import: %v", err)
-		return "", err
+		return "", transportError("importing file", err)
 	}
 
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while import: %v", resp.String())
-		return "", fmt.Errorf("Error while import: %v", resp.String())
+		return "", statusError("importing file", resp)
 	}
 	var importResp struct {
 		Error  UniverserErr `json:"error"`
@@ -146,12 +263,12 @@ func (s *universeService) Import(req UniverserImportReq) (taskId string, err err
 	body := resp.Body()
 	if err := json.Unmarshal(body, &importResp); err != nil {
 		log.Printf("Error while import: %v", err)
-		return "", err
+		return "", decodeError("importing file", err)
 	}
 
 	if importResp.Error.Code != universerSuccessCode {
 		log.Printf("Error while import: %v", importResp.Error.Message)
-		return "", fmt.Errorf("Error while import: %v", importResp.Error.Message)
+		return "", codeError("importing file", importResp.Error)
 	}
 
 	return importResp.TaskId, nil
@@ -168,20 +285,20 @@ type UniverserPullReq struct {
 	ExchangeType int
 }
 
-func (s *universeService) PullResult(req UniverserPullReq) (string, error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) PullResult(ctx context.Context, req UniverserPullReq) (string, error) {
+	resp, err := s.retryClient.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Cookie", req.Cookie).
-		Get(fmt.Sprintf("%s/universer-api/exchange/task/%s", viper.GetString("universer.host"), req.TaskId))
+		Get(fmt.Sprintf("%s/universer-api/exchange/task/%s", s.cfg.Host, req.TaskId))
 	if err != nil {
 		log.Printf("Error while pulling result: %v", err)
-		return "", err
+		return "", transportError("pulling result", err)
 	}
 
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while pulling result: %v", resp.String())
-		return "", fmt.Errorf("Error while pulling result: %v", resp.String())
+		return "", statusError("pulling result", resp)
 	}
 
 	var result struct {
@@ -197,12 +314,12 @@ func (s *universeService) PullResult(req UniverserPullReq) (string, error) {
 	body := resp.Body()
 	if err := json.Unmarshal(body, &result); err != nil {
 		log.Printf("Error while pulling result: %v", err)
-		return "", err
+		return "", decodeError("pulling result", err)
 	}
 
 	if result.Error.Code != universerSuccessCode {
 		log.Printf("Error while pulling result: %v", result.Error.Message)
-		return "", fmt.Errorf("Error while pulling result: %v", result.Error.Message)
+		return "", codeError("pulling result", result.Error)
 	}
 
 	log.Printf("Pull result header: %+v", resp.Header())
@@ -217,7 +334,7 @@ func (s *universeService) PullResult(req UniverserPullReq) (string, error) {
 	case "pending":
 		return "", nil
 	default:
-		return "", fmt.Errorf("Error while pulling result: %v", result.Status)
+		return "", fmt.Errorf("%w: %v", ErrUniverserTaskFailed, result.Status)
 	}
 }
 
@@ -227,23 +344,23 @@ type UniverserExportReq struct {
 	Cookie string
 }
 
-func (s *universeService) Export(req UniverserExportReq) (taskId string, err error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) Export(ctx context.Context, req UniverserExportReq) (taskId string, err error) {
+	resp, err := s.client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetHeader("Cookie", req.Cookie).
 		SetBody(map[string]interface{}{
 			"unitID": req.UnitId,
 			"type":   req.Type,
 		}).
-		Post(fmt.Sprintf("%s/universer-api/exchange/%d/export", viper.GetString("universer.host"), req.Type))
+		Post(fmt.Sprintf("%s/universer-api/exchange/%d/export", s.cfg.Host, req.Type))
 	if err != nil {
 		log.Printf("Error while exporting unit: %v", err)
-		return
+		return "", transportError("exporting unit", err)
 	}
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while export: %v", resp.String())
-		return "", fmt.Errorf("Error while export: %v", resp.String())
+		return "", statusError("exporting unit", resp)
 	}
 	var exportResp struct {
 		Error  UniverserErr `json:"error"`
@@ -252,35 +369,117 @@ func (s *universeService) Export(req UniverserExportReq) (taskId string, err err
 	body := resp.Body()
 	if err := json.Unmarshal(body, &exportResp); err != nil {
 		log.Printf("Error while export: %v", err)
-		return "", err
+		return "", decodeError("exporting unit", err)
 	}
 
 	if exportResp.Error.Code != universerSuccessCode {
 		log.Printf("Error while export: %v", exportResp.Error.Message)
-		return "", fmt.Errorf("Error while export: %v", exportResp.Error.Message)
+		return "", codeError("exporting unit", exportResp.Error)
 	}
 
 	return exportResp.TaskId, nil
 }
 
+type UniverserDeleteUnitReq struct {
+	UnitId string
+	Type   int
+	Cookie string
+}
+
+func (s *universeService) DeleteUnit(ctx context.Context, req UniverserDeleteUnitReq) error {
+	if !s.cfg.UnitLifecycle {
+		return ErrUnitLifecycleDisabled
+	}
+	resp, err := s.retryClient.R().
+		SetContext(ctx).
+		SetHeader("Cookie", req.Cookie).
+		Post(fmt.Sprintf("%s/universer-api/snapshot/%d/unit/%s/delete", s.cfg.Host, req.Type, url.PathEscape(req.UnitId)))
+	if err != nil {
+		log.Printf("Error while deleting unit: %v", err)
+		return transportError("deleting unit", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode() != 200 {
+		log.Printf("Error while deleting unit: %v", resp.String())
+		return statusError("deleting unit", resp)
+	}
+
+	var deleteResp struct {
+		Error UniverserErr `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body(), &deleteResp); err != nil {
+		log.Printf("Error while deleting unit: %v", err)
+		return decodeError("deleting unit", err)
+	}
+
+	if deleteResp.Error.Code != universerSuccessCode {
+		log.Printf("Error while deleting unit: %v", deleteResp.Error.Message)
+		return codeError("deleting unit", deleteResp.Error)
+	}
+	return nil
+}
+
+type UniverserUnitExistsReq struct {
+	UnitId string
+	Type   int
+	Cookie string
+}
+
+func (s *universeService) UnitExists(ctx context.Context, req UniverserUnitExistsReq) (bool, error) {
+	if !s.cfg.UnitLifecycle {
+		return false, ErrUnitLifecycleDisabled
+	}
+	resp, err := s.retryClient.R().
+		SetContext(ctx).
+		SetHeader("Cookie", req.Cookie).
+		Get(fmt.Sprintf("%s/universer-api/snapshot/%d/unit/%s", s.cfg.Host, req.Type, url.PathEscape(req.UnitId)))
+	if err != nil {
+		log.Printf("Error while getting unit: %v", err)
+		return false, transportError("getting unit", err)
+	}
+	if resp.StatusCode() == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode() != 200 {
+		log.Printf("Error while getting unit: %v", resp.String())
+		return false, statusError("getting unit", resp)
+	}
+
+	var unitResp struct {
+		Error UniverserErr `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body(), &unitResp); err != nil {
+		log.Printf("Error while getting unit: %v", err)
+		return false, decodeError("getting unit", err)
+	}
+
+	if unitResp.Error.Code != universerSuccessCode {
+		log.Printf("Error while getting unit: %v", unitResp.Error.Message)
+		return false, codeError("getting unit", unitResp.Error)
+	}
+	return true, nil
+}
+
 type UniverserGetFileReq struct {
 	FileId string
 	Cookie string
 }
 
-func (s *universeService) GetFile(req UniverserGetFileReq) (reader io.ReadCloser, err error) {
-	client := resty.New()
-	resp, err := client.R().
+func (s *universeService) GetFile(ctx context.Context, req UniverserGetFileReq) (reader io.ReadCloser, err error) {
+	resp, err := s.retryClient.R().
+		SetContext(ctx).
 		SetHeader("Cookie", req.Cookie).
-		Get(fmt.Sprintf("%s/universer-api/file/%s/sign-url", viper.GetString("universer.host"), req.FileId))
+		Get(fmt.Sprintf("%s/universer-api/file/%s/sign-url", s.cfg.Host, req.FileId))
 	if err != nil {
 		log.Printf("Error while getting file: %v", err)
-		return
+		return nil, transportError("getting file", err)
 	}
 
 	if resp.StatusCode() != 200 {
 		log.Printf("Error while getting file: %v", resp.String())
-		return nil, fmt.Errorf("Error while getting file: %v", resp.String())
+		return nil, statusError("getting file", resp)
 	}
 
 	var urlResp struct {
@@ -291,12 +490,12 @@ func (s *universeService) GetFile(req UniverserGetFileReq) (reader io.ReadCloser
 	body := resp.Body()
 	if err := json.Unmarshal(body, &urlResp); err != nil {
 		log.Printf("Error while getting file: %v", err)
-		return nil, err
+		return nil, decodeError("getting file", err)
 	}
 
 	if urlResp.Error.Code != universerSuccessCode {
 		log.Printf("Error while getting file: %v", urlResp.Error.Message)
-		return nil, fmt.Errorf("Error while getting file: %v", urlResp.Error.Message)
+		return nil, codeError("getting file", urlResp.Error)
 	}
 
 	fileUrl := urlResp.URL
@@ -304,24 +503,27 @@ func (s *universeService) GetFile(req UniverserGetFileReq) (reader io.ReadCloser
 	uri, err := url.Parse(urlResp.URL)
 	if err != nil {
 		log.Printf("Error while getting file: %v", err)
-		return nil, err
+		return nil, decodeError("getting file", err)
 	}
 	if uri.Host == "" {
-		fileUrl = fmt.Sprintf("%s%s", viper.GetString("universer.host"), urlResp.URL)
+		fileUrl = fmt.Sprintf("%s%s", s.cfg.Host, urlResp.URL)
 	}
 
-	resp, err = client.R().
+	// the body is streamed to the caller, so the download itself is not retried.
+	resp, err = s.client.R().
+		SetContext(ctx).
 		SetHeader("Cookie", req.Cookie).
 		SetDoNotParseResponse(true).
 		Get(fileUrl)
 	if err != nil {
 		log.Printf("Error while getting file: %v", err)
-		return
+		return nil, transportError("getting file", err)
 	}
 
 	if resp.StatusCode() != 200 {
-		log.Printf("Error while getting file: %v", resp.String())
-		return nil, fmt.Errorf("Error while getting file: %v", resp.String())
+		resp.RawBody().Close()
+		log.Printf("Error while getting file: %v", resp.Status())
+		return nil, statusError("getting file", resp)
 	}
 
 	reader = resp.RawBody()
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/fakeuniverser"
+)
+
+func newFakeUniverser(t *testing.T, opts fakeuniverser.Options) (*fakeuniverser.Server, UniverserService) {
+	t.Helper()
+	fake := fakeuniverser.New(opts)
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	return fake, NewUniverseService(UniverserConfig{Host: srv.URL, UnitLifecycle: true})
+}
+
+// pull polls a task the way the job workers do until it is no longer pending.
+func pull(t *testing.T, uSvc UniverserService, taskId string, exchangeType int) (string, error) {
+	t.Helper()
+	for i := 0; i < 10; i++ {
+		result, err := uSvc.PullResult(context.Background(), UniverserPullReq{TaskId: taskId, ExchangeType: exchangeType})
+		if err != nil || result != "" {
+			return result, err
+		}
+	}
+	t.Fatalf("task %s is still pending", taskId)
+	return "", nil
+}
+
+func TestUniverseServiceImportExport(t *testing.T) {
+	fake, uSvc := newFakeUniverser(t, fakeuniverser.Options{PendingPolls: 2})
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "Budget.xlsx")
+	if err := os.WriteFile(path, []byte("workbook"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	fileId, err := uSvc.UploadFile(ctx, ImportReq{FileName: "Budget.xlsx", FileSize: 8, FormFile: f})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskId, err := uSvc.Import(ctx, UniverserImportReq{FileId: fileId, Type: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unitId, err := pull(t, uSvc, taskId, ExchangeTypeImport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unit, ok := fake.Unit(unitId); !ok || unit.Name != "Budget" {
+		t.Fatalf("imported unit %q: got %+v, %v", unitId, unit, ok)
+	}
+
+	taskId, err = uSvc.Export(ctx, UniverserExportReq{UnitId: unitId, Type: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportId, err := pull(t, uSvc, taskId, ExchangeTypeExport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := uSvc.GetFile(ctx, UniverserGetFileReq{FileId: exportId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "workbook" {
+		t.Fatalf("exported %q, want the imported workbook", data)
+	}
+}
+
+func TestUniverseServiceExportOfDeletedUnit(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{PendingPolls: 1})
+	ctx := context.Background()
+
+	unitId, err := uSvc.CreateUnit(ctx, CreateUnitRequest{Name: "Budget", Type: datamodels.FileTypeStr(datamodels.UnitTypeSheet), UserId: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskId, err := uSvc.Export(ctx, UniverserExportReq{UnitId: unitId, Type: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := uSvc.DeleteUnit(ctx, UniverserDeleteUnitReq{UnitId: unitId, Type: datamodels.UnitTypeSheet}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := pull(t, uSvc, taskId, ExchangeTypeExport); !errors.Is(err, ErrUniverserTaskFailed) {
+		t.Fatalf("got %v, want %v", err, ErrUniverserTaskFailed)
+	}
+}
+
+func TestUniverseServiceTypesErrorsByStatus(t *testing.T) {
+	_, uSvc := newFakeUniverser(t, fakeuniverser.Options{})
+	ctx := context.Background()
+
+	var notFound *NotFoundError
+	if _, err := uSvc.GetFile(ctx, UniverserGetFileReq{FileId: "missing"}); !errors.As(err, &notFound) {
+		t.Fatalf("got %v, want a NotFoundError", err)
+	}
+	if exists, err := uSvc.UnitExists(ctx, UniverserUnitExistsReq{UnitId: "missing", Type: datamodels.UnitTypeSheet}); err != nil || exists {
+		t.Fatalf("got %v, %v, want an unknown unit", exists, err)
+	}
+	if err := uSvc.DeleteUnit(ctx, UniverserDeleteUnitReq{UnitId: "missing", Type: datamodels.UnitTypeSheet}); err != nil {
+		t.Fatalf("deleting an unknown unit: %v", err)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"go-usip/repositories"
 )
 
+// ErrEmailTaken is returned when registering with the email of another user.
+var ErrEmailTaken = errors.New("email address already used")
+
 // UserService handles CRUID operations of a user datamodel,
 // it depends on a user repository for its actions.
 // It's here to decouple the data source from the higher level compoments.
@@ -83,6 +86,11 @@ func (s *userService) GetByPage(nextId, size uint) ([]datamodels.User, bool) {
 }
 
 func (s *userService) GetAvatarByUserID(userId string) (image.Image, bool) {
+	if datamodels.IsGuestId(userId) {
+		image, err := s.aSvc.GenerateAvatar(datamodels.GuestNickname)
+		return image, err == nil
+	}
+
 	user, found := s.repo.Get(userId)
 	if !found {
 		return nil, false
@@ -137,6 +145,18 @@ func (s *userService) Create(userPassword string, user datamodels.User) (datamod
 		return datamodels.User{}, errors.New("unable to create this user")
 	}
 
+	// the email is optional, invites sent to it are offered to the user.
+	if user.Email != "" {
+		email, ok := NormalizeEmail(user.Email)
+		if !ok {
+			return datamodels.User{}, ErrInvalidEmail
+		}
+		if _, found := s.repo.GetByEmail(email); found {
+			return datamodels.User{}, ErrEmailTaken
+		}
+		user.Email = email
+	}
+
 	user.UserId = datamodels.GenerateUserId()
 
 	hashed, err := datamodels.GeneratePassword(userPassword)
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"go-usip/datamodels"
+	"go-usip/repositories"
+	"log"
+	"strings"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrForbidden         = errors.New("permission denied")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrLastOwner         = errors.New("the last owner cannot be removed")
+)
+
+// UserWorkspace is a workspace with the role of the user asking for it.
+type UserWorkspace struct {
+	datamodels.Workspace
+	Role datamodels.Role
+}
+
+// WorkspaceService manages team workspaces and their members.
+// Every method takes the acting user, workspaces they are no member of are reported as not found.
+type WorkspaceService interface {
+	GetByUserId(userId string) ([]UserWorkspace, error)
+	Get(userId string, workspaceId uint) (UserWorkspace, error)
+	GetMembers(userId string, workspaceId uint) ([]datamodels.WorkspaceMember, error)
+
+	// Create makes a workspace owned by userId.
+	Create(userId string, name string) (datamodels.Workspace, error)
+	// AddMembers adds users or changes their role, owners only.
+	AddMembers(userId string, workspaceId uint, userIds []string, role datamodels.Role) error
+	// RemoveMember removes a member, owners may remove anyone and members themselves.
+	RemoveMember(userId string, workspaceId uint, memberId string) error
+}
+
+func NewWorkspaceService(repo repositories.WorkspaceRepository, memberRepo repositories.WorkspaceMemberRepository,
+	uow repositories.UnitOfWork) WorkspaceService {
+	return &workspaceService{
+		repo:       repo,
+		memberRepo: memberRepo,
+		uow:        uow,
+	}
+}
+
+type workspaceService struct {
+	repo       repositories.WorkspaceRepository
+	memberRepo repositories.WorkspaceMemberRepository
+	uow        repositories.UnitOfWork
+}
+
+func (s *workspaceService) GetByUserId(userId string) ([]UserWorkspace, error) {
+	members, _ := s.memberRepo.GetByUserId(userId)
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	roles := make(map[uint]datamodels.Role, len(members))
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		roles[member.WorkspaceId] = member.Role
+		ids = append(ids, member.WorkspaceId)
+	}
+
+	workspaces, _ := s.repo.BatchGet(ids)
+	result := make([]UserWorkspace, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		result = append(result, UserWorkspace{Workspace: workspace, Role: roles[workspace.ID]})
+	}
+	return result, nil
+}
+
+func (s *workspaceService) Get(userId string, workspaceId uint) (UserWorkspace, error) {
+	member, found := s.memberRepo.Get(workspaceId, userId)
+	if !found {
+		return UserWorkspace{}, ErrWorkspaceNotFound
+	}
+	workspace, found := s.repo.Get(workspaceId)
+	if !found {
+		return UserWorkspace{}, ErrWorkspaceNotFound
+	}
+	return UserWorkspace{Workspace: workspace, Role: member.Role}, nil
+}
+
+func (s *workspaceService) GetMembers(userId string, workspaceId uint) ([]datamodels.WorkspaceMember, error) {
+	if _, err := s.Get(userId, workspaceId); err != nil {
+		return nil, err
+	}
+
+	members, _ := s.memberRepo.GetByWorkspaceId(workspaceId)
+	return members, nil
+}
+
+func (s *workspaceService) Create(userId string, name string) (datamodels.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return datamodels.Workspace{}, ErrEmptyName
+	}
+
+	var workspace datamodels.Workspace
+	err := s.uow.Do(func(repos repositories.Repositories) error {
+		var err error
+		workspace, err = repos.Workspaces().Create(datamodels.Workspace{Name: name})
+		if err != nil {
+			log.Printf("Error while creating workspace: %v", err)
+			return err
+		}
+
+		err = repos.WorkspaceMembers().InsertOrUpdate([]datamodels.WorkspaceMember{{
+			WorkspaceId: workspace.ID,
+			UserId:      userId,
+			Role:        datamodels.RoleOwner,
+		}})
+		if err != nil {
+			log.Printf("Error while creating workspace owner: %v", err)
+		}
+		return err
+	})
+	if err != nil {
+		return datamodels.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+func (s *workspaceService) AddMembers(userId string, workspaceId uint, userIds []string, role datamodels.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	workspace, err := s.Get(userId, workspaceId)
+	if err != nil {
+		return err
+	}
+	if workspace.Role != datamodels.RoleOwner {
+		return ErrForbidden
+	}
+
+	members := make([]datamodels.WorkspaceMember, 0, len(userIds))
+	for _, memberId := range userIds {
+		if memberId == userId && role != datamodels.RoleOwner {
+			if err := s.checkNotLastOwner(workspaceId, memberId); err != nil {
+				return err
+			}
+		}
+		members = append(members, datamodels.WorkspaceMember{
+			WorkspaceId: workspaceId,
+			UserId:      memberId,
+			Role:        role,
+		})
+	}
+	if len(members) == 0 {
+		return nil
+	}
+	return s.memberRepo.InsertOrUpdate(members)
+}
+
+func (s *workspaceService) RemoveMember(userId string, workspaceId uint, memberId string) error {
+	workspace, err := s.Get(userId, workspaceId)
+	if err != nil {
+		return err
+	}
+	if workspace.Role != datamodels.RoleOwner && memberId != userId {
+		return ErrForbidden
+	}
+	if err := s.checkNotLastOwner(workspaceId, memberId); err != nil {
+		return err
+	}
+
+	return s.memberRepo.Delete(workspaceId, memberId)
+}
+
+// checkNotLastOwner refuses to take the owner role away from the only owner of a workspace.
+func (s *workspaceService) checkNotLastOwner(workspaceId uint, memberId string) error {
+	members, _ := s.memberRepo.GetByWorkspaceId(workspaceId)
+	owners, isOwner := 0, false
+	for _, member := range members {
+		if member.Role == datamodels.RoleOwner {
+			owners++
+			isOwner = isOwner || member.UserId == memberId
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
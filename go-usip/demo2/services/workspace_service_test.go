@@ -0,0 +1,75 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/repositories"
+)
+
+func TestWorkspaceServiceGrantsMembersAccess(t *testing.T) {
+	db := newTestDB(t)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	memberRepo := repositories.NewWorkspaceMemberRepository(db)
+	uow := repositories.NewUnitOfWork(db)
+	workspaceService := NewWorkspaceService(repositories.NewWorkspaceRepository(db), memberRepo, uow)
+	fileService := NewFileService(fileRepo, collaRepo, memberRepo, uow, nil, nil)
+
+	workspace, err := workspaceService.Create("boss", "Team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workspaceService.AddMembers("boss", workspace.ID, []string{"ann", "carl"}, datamodels.RoleReader); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspaceService.AddMembers("boss", workspace.ID, []string{"carl"}, datamodels.RoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	if err := workspaceService.AddMembers("ann", workspace.ID, []string{"ann"}, datamodels.RoleOwner); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("member adding members: got %v, want %v", err, ErrForbidden)
+	}
+	if _, err := workspaceService.GetMembers("eve", workspace.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+		t.Fatalf("outsider listing members: got %v, want %v", err, ErrWorkspaceNotFound)
+	}
+
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet, WorkspaceId: workspace.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a grant raises the workspace role, it never lowers it.
+	for _, grant := range []datamodels.FileCollaborator{
+		{FileId: file.ID, UserId: "ann", Role: datamodels.RoleEditor},
+		{FileId: file.ID, UserId: "carl", Role: datamodels.RoleCommenter},
+	} {
+		if _, err := collaRepo.Create(grant); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for userId, want := range map[string]datamodels.Role{
+		"boss": datamodels.RoleOwner,
+		"ann":  datamodels.RoleEditor,
+		"carl": datamodels.RoleEditor,
+		"eve":  "",
+	} {
+		if got := fileService.GetRole(file.ID, userId); got != want {
+			t.Errorf("%s got role %q, want %q", userId, got, want)
+		}
+	}
+	collaborators, _ := fileService.GetCollaborators(file.ID)
+	if len(collaborators) != 3 {
+		t.Errorf("got collaborators %+v, want boss, ann and carl", collaborators)
+	}
+
+	// leaving the workspace leaves the grant.
+	if err := workspaceService.RemoveMember("carl", workspace.ID, "carl"); err != nil {
+		t.Fatal(err)
+	}
+	if got := fileService.GetRole(file.ID, "carl"); got != datamodels.RoleCommenter {
+		t.Errorf("carl got role %q after leaving, want %q", got, datamodels.RoleCommenter)
+	}
+	if err := workspaceService.RemoveMember("boss", workspace.ID, "boss"); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("last owner leaving: got %v, want %v", err, ErrLastOwner)
+	}
+}
@@ -0,0 +1,46 @@
+import { approveAccessRequest, denyAccessRequest, fetchAccessRequests } from '../services/access-requests-service'
+import { escapeHtml } from '../utils/html'
+
+// renderAccessRequests lists the requests to answer on the files the user owns in panel, hidden without any.
+export async function renderAccessRequests(panel: HTMLElement) {
+  let requests
+  try {
+    requests = await fetchAccessRequests()
+  }
+  catch {
+    panel.hidden = true
+    return
+  }
+
+  panel.hidden = !requests.length
+  panel.innerHTML = `
+    <h2>Access requests</h2>
+    ${requests.map(request => `
+      <div class="members-row">
+        <div class="members-profile">
+          <img class="members-avatar" src="${escapeHtml(request.avatar)}" alt="">
+          <span class="members-name">
+            ${escapeHtml(request.nickname || request.userId)} asks for ${escapeHtml(request.role)} on <b>${escapeHtml(request.fileName)}</b>
+            ${request.message ? `<br><small>${escapeHtml(request.message)}</small>` : ''}
+          </span>
+        </div>
+        <button class="demo-btn-primary members-action approve-request-btn" type="button" data-request-id="${request.id}">Approve</button>
+        <button class="demo-btn-secondary members-action deny-request-btn" type="button" data-request-id="${request.id}">Deny</button>
+      </div>
+    `).join('')}
+  `
+
+  panel.querySelectorAll<HTMLButtonElement>('.approve-request-btn, .deny-request-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const requestId = Number(btn.dataset.requestId)
+      const approve = btn.classList.contains('approve-request-btn')
+      try {
+        await (approve ? approveAccessRequest(requestId) : denyAccessRequest(requestId))
+      }
+      catch (err) {
+        alert(`${approve ? 'Approving' : 'Denying'} the request failed: ${(err as Error).message}`)
+      }
+      await renderAccessRequests(panel)
+    })
+  })
+}
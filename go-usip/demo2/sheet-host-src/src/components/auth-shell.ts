@@ -23,3 +23,9 @@ export function attachFormMessage(message: string, isError = true) {
   el.textContent = message
   el.className = isError ? 'form-message is-error' : 'form-message is-success'
 }
+
+// nextPath is the page the user was sent to log in from, only paths of this site are followed.
+export function nextPath() {
+  const next = new URLSearchParams(location.search).get('next') ?? ''
+  return next.startsWith('/') && !next.startsWith('//') ? next : '/files'
+}
@@ -1,16 +1,37 @@
 import { fetchPeople, inviteUsers } from '../services/files-service'
-
-export function wireInviteDialog(fileContainer: HTMLDivElement, currentUserId: string) {
+import { sendInvites } from '../services/invites-service'
+import { fetchRoles } from '../services/roles-service'
+import { addWorkspaceMembers } from '../services/workspaces-service'
+import type { APIError } from '../types/api'
+import { escapeHtml } from '../utils/html'
+
+// wireInviteDialog opens the dialog from the .invite-btn buttons of root,
+// they carry either the file or the workspace the users are invited to.
+export function wireInviteDialog(root: ParentNode, currentUserId: string) {
   const dialog = document.querySelector<HTMLDialogElement>('#dialog')
   const dialogMsg = document.querySelector<HTMLDivElement>('#dialog-msg')
   const roleSelect = document.querySelector<HTMLSelectElement>('#select-role')
   const okBtn = document.querySelector<HTMLButtonElement>('#dialog-ok-btn')
   const cancelInviteBtn = document.querySelector<HTMLButtonElement>('#dialog-cancel-btn')
+  const recipientsRow = document.querySelector<HTMLDivElement>('#invite-recipients-row')
+  const recipientsInput = document.querySelector<HTMLInputElement>('#invite-recipients')
+  const expiryRow = document.querySelector<HTMLDivElement>('#invite-expiry-row')
+  const expiresAtInput = document.querySelector<HTMLInputElement>('#invite-expires-at')
 
-  if (!dialog || !dialogMsg || !roleSelect || !okBtn || !cancelInviteBtn)
+  if (!dialog || !dialogMsg || !roleSelect || !okBtn || !cancelInviteBtn || !recipientsRow || !recipientsInput
+    || !expiryRow || !expiresAtInput)
     return
 
+  // invitees get any role below owner, custom roles included; reader stays the default.
+  fetchRoles().then((roles) => {
+    roleSelect.innerHTML = roles
+      .filter(role => role.base !== 'owner')
+      .map(role => `<option value="${escapeHtml(role.name)}" ${role.name === 'reader' ? 'selected' : ''}>${escapeHtml(role.name)}</option>`)
+      .join('')
+  }).catch(() => {})
+
   let inviteFileId = 0
+  let inviteWorkspaceId = 0
   let invite: string[] = []
   const pageStack: number[] = [0]
 
@@ -96,28 +117,66 @@ export function wireInviteDialog(fileContainer: HTMLDivElement, currentUserId: s
     }
   }
 
-  fileContainer.querySelectorAll<HTMLButtonElement>('.invite-btn').forEach((btn) => {
+  root.querySelectorAll<HTMLButtonElement>('.invite-btn').forEach((btn) => {
     btn.addEventListener('click', async () => {
       invite = []
       inviteFileId = Number(btn.dataset.fileId ?? 0)
+      inviteWorkspaceId = Number(btn.dataset.workspaceId ?? 0)
       pageStack.length = 0
       pageStack.push(0)
+      // files also take email addresses and usernames, workspace members are picked from the list.
+      recipientsInput.value = ''
+      recipientsRow.hidden = !inviteFileId
+      // users picked for a file may get access until a date, e.g. contractors.
+      expiresAtInput.value = ''
+      expiryRow.hidden = !inviteFileId
       await renderPeople(0)
       dialog.showModal()
     })
   })
 
   okBtn.addEventListener('click', async () => {
-    if (!inviteFileId)
+    if (!inviteFileId && !inviteWorkspaceId)
       return
 
     const filteredInvite = invite.filter(userId => userId !== currentUserId)
-    if (!filteredInvite.length) {
+    const recipients = inviteFileId
+      ? recipientsInput.value.split(/[,\s]+/).filter(Boolean)
+      : []
+    if (!filteredInvite.length && !recipients.length) {
       alert('Please select at least one user to invite')
       return
     }
 
-    await inviteUsers(inviteFileId, filteredInvite, roleSelect.value)
+    if (inviteWorkspaceId) {
+      try {
+        await addWorkspaceMembers(inviteWorkspaceId, filteredInvite, roleSelect.value)
+      }
+      catch (err) {
+        alert(`Invite failed: ${(err as Error).message}`)
+        return
+      }
+    }
+    else {
+      if (recipients.length) {
+        try {
+          await sendInvites(inviteFileId, recipients, roleSelect.value)
+        }
+        catch (err) {
+          alert(`Invite failed: ${(err as Error).message}`)
+          return
+        }
+      }
+      if (filteredInvite.length) {
+        const expiresAt = expiresAtInput.value ? new Date(expiresAtInput.value).toISOString() : undefined
+        const resp = await inviteUsers(inviteFileId, filteredInvite, roleSelect.value, expiresAt)
+        if (!resp.ok) {
+          const payload = await resp.json().catch(() => ({})) as APIError
+          alert(resp.status === 403 ? 'Only owners can share this file' : `Invite failed: ${payload.error || resp.status}`)
+          return
+        }
+      }
+    }
     dialog.close()
   })
 
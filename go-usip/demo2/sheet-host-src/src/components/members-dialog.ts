@@ -1,5 +1,13 @@
-import { fetchCollaborators } from '../services/collaborators-service'
+import { me } from '../services/auth-service'
+import { baseRole, fetchRoles } from '../services/roles-service'
+import {
+  fetchCollaborators,
+  removeCollaborator,
+  transferOwnership,
+  updateCollaboratorRole,
+} from '../services/collaborators-service'
 import type { CollaboratorItem } from '../types/collaborators'
+import type { RoleItem } from '../types/roles'
 import { escapeHtml } from '../utils/html'
 
 const DIALOG_ID = 'members-dialog'
@@ -31,33 +39,106 @@ function ensureDialog() {
   return dialog
 }
 
-function roleClass(role: string) {
-  if (role === 'owner' || role === 'editor' || role === 'reader')
-    return role
-  return 'reader'
-}
 
-function renderMembers(listEl: HTMLDivElement, members: CollaboratorItem[]) {
+// renderMembers offers owners to change roles, revoke grants and transfer ownership,
+// other members can leave; workspace members without a grant are managed in the workspace.
+function renderMembers(listEl: HTMLDivElement, members: CollaboratorItem[], roles: RoleItem[], userId: string) {
   if (!members.length) {
     listEl.innerHTML = '<p class="members-empty">No members found.</p>'
     return
   }
 
+  const isOwner = members.some(member => member.subject.id === userId && member.role === 'owner')
   listEl.innerHTML = members.map((member) => {
-    const role = roleClass(member.role)
+    const role = escapeHtml(member.role)
+    const id = escapeHtml(member.subject.id)
+    const name = escapeHtml(member.subject.name)
+    const self = member.subject.id === userId
+    const roleEl = isOwner && member.granted
+      ? `<select class="members-role" data-user-id="${id}">
+          ${roles.map(item => `<option value="${escapeHtml(item.name)}" ${item.name === member.role ? 'selected' : ''}>${escapeHtml(item.name)}</option>`).join('')}
+        </select>`
+      : `<span class="file-role-badge role-${baseRole(roles, member.role)}">${role}</span>`
     return `
       <div class="members-row">
         <div class="members-profile">
           <img class="members-avatar" src="${escapeHtml(member.subject.avatar)}" alt="avatar" loading="lazy" decoding="async" />
-          <span class="members-name">${escapeHtml(member.subject.name)}</span>
+          <span class="members-name">${name}${self ? ' (you)' : ''}${member.expiresAt ? `<br><small>until ${escapeHtml(member.expiresAt)}</small>` : ''}</span>
         </div>
-        <span class="file-role-badge role-${role}">${role}</span>
+        ${roleEl}
+        ${isOwner && !self && role !== 'owner' ? `<button class="demo-btn-secondary members-action transfer-btn" type="button" data-user-id="${id}" data-name="${name}">Make owner</button>` : ''}
+        ${member.granted && (isOwner || self) ? `<button class="demo-btn-secondary members-action remove-member-btn" type="button" data-user-id="${id}" data-name="${name}">${self ? 'Leave' : 'Remove'}</button>` : ''}
       </div>
     `
   }).join('')
 }
 
-export async function openMembersDialog(unitId: string) {
+function wireMembers(listEl: HTMLDivElement, fileId: number, userId: string) {
+  const run = async (action: () => Promise<void>, failure: string) => {
+    try {
+      await action()
+    }
+    catch (err) {
+      alert(`${failure}: ${(err as Error).message}`)
+    }
+    await loadMembers(listEl, fileId, userId)
+  }
+
+  listEl.querySelectorAll<HTMLSelectElement>('.members-role').forEach((select) => {
+    select.addEventListener('change', () => run(
+      () => updateCollaboratorRole(fileId, select.dataset.userId ?? '', select.value),
+      'Changing the role failed',
+    ))
+  })
+
+  listEl.querySelectorAll<HTMLButtonElement>('.transfer-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      if (!confirm(`Make ${btn.dataset.name ?? 'this member'} the owner? You will become an editor.`))
+        return
+      try {
+        await transferOwnership(fileId, btn.dataset.userId ?? '')
+        location.reload()
+      }
+      catch (err) {
+        alert(`Transfer failed: ${(err as Error).message}`)
+      }
+    })
+  })
+
+  listEl.querySelectorAll<HTMLButtonElement>('.remove-member-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const memberId = btn.dataset.userId ?? ''
+      if (memberId === userId) {
+        if (!confirm('Leave this file? You will lose access to it.'))
+          return
+        try {
+          await removeCollaborator(fileId, memberId)
+          location.reload()
+        }
+        catch (err) {
+          alert(`Leaving failed: ${(err as Error).message}`)
+        }
+        return
+      }
+      if (!confirm(`Remove ${btn.dataset.name ?? 'this member'} from the file?`))
+        return
+      run(() => removeCollaborator(fileId, memberId), 'Removing the member failed')
+    })
+  })
+}
+
+async function loadMembers(listEl: HTMLDivElement, fileId: number, userId: string) {
+  try {
+    const [members, roles] = await Promise.all([fetchCollaborators(fileId), fetchRoles()])
+    renderMembers(listEl, members, roles, userId)
+    wireMembers(listEl, fileId, userId)
+  }
+  catch {
+    listEl.innerHTML = '<p class="members-empty">Failed to load members.</p>'
+  }
+}
+
+export async function openMembersDialog(fileId: number) {
   const dialog = ensureDialog()
   const listEl = dialog.querySelector<HTMLDivElement>('#members-list')
   if (!listEl)
@@ -67,8 +148,8 @@ export async function openMembersDialog(unitId: string) {
   dialog.showModal()
 
   try {
-    const members = await fetchCollaborators(unitId)
-    renderMembers(listEl, members)
+    const { user } = await me()
+    await loadMembers(listEl, fileId, user.userId)
   }
   catch {
     listEl.innerHTML = '<p class="members-empty">Failed to load members.</p>'
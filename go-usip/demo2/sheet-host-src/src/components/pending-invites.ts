@@ -0,0 +1,53 @@
+import { acceptInvite, declineInvite, fetchInvites } from '../services/invites-service'
+import { escapeHtml } from '../utils/html'
+
+// renderPendingInvites lists the invites the user has to answer in panel, hidden without any.
+export async function renderPendingInvites(panel: HTMLElement) {
+  let invites
+  try {
+    invites = await fetchInvites()
+  }
+  catch {
+    panel.hidden = true
+    return
+  }
+
+  panel.hidden = !invites.length
+  panel.innerHTML = `
+    <h2>Invites</h2>
+    ${invites.map(invite => `
+      <div class="members-row">
+        <span class="members-name">
+          ${escapeHtml(invite.invitedBy || 'Someone')} invited you to <b>${escapeHtml(invite.fileName)}</b> as ${escapeHtml(invite.role)}
+        </span>
+        <button class="demo-btn-primary members-action accept-invite-btn" type="button" data-invite-id="${invite.id}">Accept</button>
+        <button class="demo-btn-secondary members-action decline-invite-btn" type="button" data-invite-id="${invite.id}">Decline</button>
+      </div>
+    `).join('')}
+  `
+
+  panel.querySelectorAll<HTMLButtonElement>('.accept-invite-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      try {
+        await acceptInvite(Number(btn.dataset.inviteId))
+        location.reload()
+      }
+      catch (err) {
+        alert(`Accepting the invite failed: ${(err as Error).message}`)
+        await renderPendingInvites(panel)
+      }
+    })
+  })
+
+  panel.querySelectorAll<HTMLButtonElement>('.decline-invite-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      try {
+        await declineInvite(Number(btn.dataset.inviteId))
+      }
+      catch (err) {
+        alert(`Declining the invite failed: ${(err as Error).message}`)
+      }
+      await renderPendingInvites(panel)
+    })
+  })
+}
@@ -0,0 +1,152 @@
+import { fetchRoles } from '../services/roles-service'
+import { createShareLink, fetchShareLinks, revokeShareLink } from '../services/share-links-service'
+import type { ShareLinkItem } from '../types/share-links'
+import { escapeHtml } from '../utils/html'
+
+const DIALOG_ID = 'share-links-dialog'
+
+function ensureDialog() {
+  let dialog = document.getElementById(DIALOG_ID) as HTMLDialogElement | null
+  if (dialog)
+    return dialog
+
+  dialog = document.createElement('dialog')
+  dialog.id = DIALOG_ID
+  dialog.className = 'dialog-panel members-dialog'
+  dialog.innerHTML = `
+    <div class="dialog-header">
+      <h3>Share Links</h3>
+      <p>Anyone logged in who opens a link gets its role on this file.</p>
+    </div>
+    <div class="members-list" id="share-links-list"></div>
+    <form class="share-links-form" id="share-links-form">
+      <select class="members-role" name="role" id="share-links-role"></select>
+      <input type="datetime-local" name="expiresAt" title="Expires at, never when empty">
+      <input type="password" name="password" placeholder="Password (optional)" autocomplete="new-password">
+      <input type="number" name="maxUses" min="0" value="0" title="Use limit, 0 for no limit">
+      <button class="demo-btn-primary" type="submit">Create Link</button>
+    </form>
+    <div class="dialog-actions">
+      <button id="share-links-close-btn" class="demo-btn-secondary" type="button">Close</button>
+    </div>
+  `
+
+  document.body.appendChild(dialog)
+
+  const closeBtn = dialog.querySelector<HTMLButtonElement>('#share-links-close-btn')
+  closeBtn?.addEventListener('click', () => dialog?.close())
+
+  return dialog
+}
+
+function describeLink(link: ShareLinkItem) {
+  const details = [
+    link.expiresAt ? `expires ${link.expiresAt}` : 'never expires',
+    link.maxUses ? `${link.uses}/${link.maxUses} uses` : `${link.uses} uses`,
+  ]
+  if (link.hasPassword)
+    details.push('password')
+  return details.join(' · ')
+}
+
+function renderLinks(listEl: HTMLDivElement, links: ShareLinkItem[]) {
+  if (!links.length) {
+    listEl.innerHTML = '<p class="members-empty">No share links yet.</p>'
+    return
+  }
+
+  listEl.innerHTML = links.map(link => `
+    <div class="members-row">
+      <div class="members-profile share-links-profile">
+        <span class="members-name">${escapeHtml(link.role)}</span>
+        <span class="share-links-details">${escapeHtml(describeLink(link))}</span>
+      </div>
+      <button class="demo-btn-secondary members-action copy-link-btn" type="button" data-url="${escapeHtml(link.url)}">Copy Link</button>
+      <button class="demo-btn-secondary members-action revoke-link-btn" type="button" data-link-id="${link.id}">Revoke</button>
+    </div>
+  `).join('')
+}
+
+async function loadLinks(listEl: HTMLDivElement, fileId: number) {
+  try {
+    renderLinks(listEl, await fetchShareLinks(fileId))
+  }
+  catch {
+    listEl.innerHTML = '<p class="members-empty">Failed to load share links.</p>'
+    return
+  }
+
+  listEl.querySelectorAll<HTMLButtonElement>('.copy-link-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const url = btn.dataset.url ?? ''
+      try {
+        await navigator.clipboard.writeText(url)
+        btn.textContent = 'Copied'
+      }
+      catch {
+        prompt('Copy the link', url)
+      }
+    })
+  })
+
+  listEl.querySelectorAll<HTMLButtonElement>('.revoke-link-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      if (!confirm('Revoke this link? People who already used it keep their access.'))
+        return
+      try {
+        await revokeShareLink(fileId, Number(btn.dataset.linkId))
+      }
+      catch (err) {
+        alert(`Revoking the link failed: ${(err as Error).message}`)
+      }
+      await loadLinks(listEl, fileId)
+    })
+  })
+}
+
+// openShareLinksDialog lets owners list, create and revoke the share links of a file.
+export async function openShareLinksDialog(fileId: number) {
+  const dialog = ensureDialog()
+  const listEl = dialog.querySelector<HTMLDivElement>('#share-links-list')
+  const form = dialog.querySelector<HTMLFormElement>('#share-links-form')
+  const roleSelect = dialog.querySelector<HTMLSelectElement>('#share-links-role')
+  if (!listEl || !form || !roleSelect)
+    return
+
+  listEl.innerHTML = '<p class="members-empty">Loading...</p>'
+  form.reset()
+  dialog.showModal()
+
+  try {
+    const roles = await fetchRoles()
+    roleSelect.innerHTML = roles
+      .filter(role => role.base !== 'owner')
+      .map(role => `<option value="${escapeHtml(role.name)}" ${role.name === 'reader' ? 'selected' : ''}>${escapeHtml(role.name)}</option>`)
+      .join('')
+  }
+  catch {
+    // the select keeps the roles of the previous opening.
+  }
+
+  // the form is reused across files, the handler follows the file the dialog was opened for.
+  form.onsubmit = async (event) => {
+    event.preventDefault()
+    const data = new FormData(form)
+    const expiresAt = String(data.get('expiresAt') ?? '')
+    try {
+      await createShareLink(fileId, {
+        role: String(data.get('role') ?? ''),
+        expiresAt: expiresAt ? new Date(expiresAt).toISOString() : undefined,
+        password: String(data.get('password') ?? '') || undefined,
+        maxUses: Number(data.get('maxUses') ?? 0),
+      })
+      form.reset()
+    }
+    catch (err) {
+      alert(`Creating the link failed: ${(err as Error).message}`)
+    }
+    await loadLinks(listEl, fileId)
+  }
+
+  await loadLinks(listEl, fileId)
+}
@@ -2,6 +2,7 @@ import './style.css'
 import { renderFilesPage } from './pages/files-page'
 import { renderLoginPage } from './pages/login-page'
 import { renderRegisterPage } from './pages/register-page'
+import { renderSharePage } from './pages/share-page'
 import { renderSheetPage } from './pages/sheet-page'
 
 async function main() {
@@ -22,7 +23,12 @@ async function main() {
     return
   }
 
-  renderSheetPage()
+  if (path.startsWith('/share/')) {
+    await renderSharePage(decodeURIComponent(path.slice('/share/'.length)))
+    return
+  }
+
+  await renderSheetPage()
 }
 
 main().catch((error) => {
@@ -0,0 +1,64 @@
+import { attachFormMessage, renderAuthShell } from '../components/auth-shell'
+import { requestAccess } from '../services/access-requests-service'
+import { fetchRoles } from '../services/roles-service'
+import type { AccessStatusResp } from '../types/access-requests'
+import { escapeHtml } from '../utils/html'
+
+// renderAccessRequestPage replaces the sheet of unitId the user cannot see with a form asking its owners for access,
+// showing the answer to their last request.
+export async function renderAccessRequestPage(unitId: string, status: AccessStatusResp) {
+  const request = status.request
+  const pending = request?.status === 'pending'
+  let subtitle = 'You do not have access to this sheet. Ask its owners for a role, they will be able to approve or deny it.'
+  if (pending)
+    subtitle = `Your request for <b>${escapeHtml(request.role)}</b> access is waiting for the owners. You can still change it.`
+  else if (request?.status === 'denied')
+    subtitle = `Your request for <b>${escapeHtml(request.role)}</b> access was denied. You can ask again.`
+
+  renderAuthShell(
+    'Request Access',
+    subtitle,
+    `<form id="access-request-form" class="auth-form">
+      <label for="access-request-role"><b>Role</b></label>
+      <select id="access-request-role" name="role"></select>
+      <label for="access-request-message"><b>Message</b> (optional)</label>
+      <textarea id="access-request-message" name="message" rows="3" maxlength="1000">${pending ? escapeHtml(request.message) : ''}</textarea>
+      <div id="form-message" class="form-message"></div>
+      <button type="submit" class="auth-submit">${pending ? 'Update Request' : 'Request Access'}</button>
+    </form>
+    <footer class="auth-footer">
+      <a href="/files">Go to your files</a>
+    </footer>`,
+  )
+
+  const form = document.querySelector<HTMLFormElement>('#access-request-form')
+  const roleSelect = document.querySelector<HTMLSelectElement>('#access-request-role')
+  if (!form || !roleSelect)
+    return
+
+  // ownership is transferred, not requested.
+  const selected = pending ? request.role : 'reader'
+  try {
+    const roles = await fetchRoles()
+    roleSelect.innerHTML = roles
+      .filter(role => role.base !== 'owner')
+      .map(role => `<option value="${escapeHtml(role.name)}" ${role.name === selected ? 'selected' : ''}>${escapeHtml(role.name)}</option>`)
+      .join('')
+  }
+  catch {
+    roleSelect.innerHTML = '<option value="reader">reader</option>'
+  }
+
+  form.addEventListener('submit', async (event) => {
+    event.preventDefault()
+    const formData = new FormData(form)
+
+    try {
+      await requestAccess(unitId, String(formData.get('role') ?? ''), String(formData.get('message') ?? ''))
+      attachFormMessage('Your request was sent, you will be able to open the sheet once an owner approves it.', false)
+    }
+    catch (error) {
+      attachFormMessage((error as Error).message)
+    }
+  })
+}
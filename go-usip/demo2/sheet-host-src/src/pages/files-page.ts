@@ -1,13 +1,31 @@
+import { renderAccessRequests } from '../components/access-requests-panel'
 import { wireInviteDialog } from '../components/invite-dialog'
 import { openMembersDialog } from '../components/members-dialog'
-import { logout } from '../services/auth-service'
+import { renderPendingInvites } from '../components/pending-invites'
+import { openShareLinksDialog } from '../components/share-links-dialog'
+import { logout, me } from '../services/auth-service'
 import {
+  copyFile,
   createSheet,
   deleteFiles,
+  exportFile,
   fetchFiles,
   importSheet,
+  publishFile,
+  removeFromList,
+  renameFile,
 } from '../services/files-service'
-import type { FileItem } from '../types/files'
+import { createFolder, deleteFolder, moveFiles, renameFolder } from '../services/folders-service'
+import { followJob } from '../services/jobs-service'
+import { baseRole, fetchRoles } from '../services/roles-service'
+import { fetchTrash, purgeFile, restoreFile } from '../services/trash-service'
+import { createWorkspace, moveToWorkspace } from '../services/workspaces-service'
+import type { APIError } from '../types/api'
+import type { FileActions, FilesResp } from '../types/files'
+import type { FolderItem } from '../types/folders'
+import type { Job } from '../types/jobs'
+import type { RoleItem } from '../types/roles'
+import type { WorkspaceItem } from '../types/workspaces'
 import { escapeHtml } from '../utils/html'
 
 function renderFilesShell() {
@@ -32,8 +50,21 @@ function renderFilesShell() {
       <div class="demo-actions">
         <button id="new-btn" class="demo-btn-primary" type="button">+ New File</button>
         <button id="import-btn" class="demo-btn-secondary" type="button">Import File</button>
-        <button id="delete-btn" class="demo-btn-danger" type="button">Delete Selected</button>
+        <button id="new-folder-btn" class="demo-btn-secondary" type="button">+ New Folder</button>
+        <button id="new-workspace-btn" class="demo-btn-secondary" type="button">+ New Workspace</button>
+        <button id="workspace-invite-btn" class="demo-btn-secondary invite-btn" type="button" hidden>Add Members</button>
+        <button id="delete-btn" class="demo-btn-danger" type="button">Move to Trash</button>
+        <button id="remove-btn" class="demo-btn-secondary" type="button">Remove from My List</button>
+        <a id="trash-link" class="demo-btn-secondary" href="/files?view=trash">Trash</a>
+        <span class="move-group">
+          <select id="move-target" aria-label="Move selected files to"></select>
+          <button id="move-btn" class="demo-btn-secondary" type="button">Move Selected</button>
+        </span>
       </div>
+      <p id="service-notice" class="service-notice" role="status"></p>
+      <p id="job-status" class="job-status" aria-live="polite"></p>
+      <div id="invites-panel" class="demo-card invites-panel" hidden></div>
+      <div id="access-requests-panel" class="demo-card access-requests-panel" hidden></div>
       <input type="file" id="file-input" style="display:none;" />
       <div id="div-form" class="demo-card">
         <form id="new-form" enctype="multipart/form-data">
@@ -46,7 +77,11 @@ function renderFilesShell() {
         </form>
       </div>
       <div class="demo-card">
-        <div class="demo-list-head"><h2>File list</h2></div>
+        <div class="demo-list-head">
+          <h2>File list</h2>
+          <select id="workspace-switch" aria-label="Workspace"></select>
+          <nav id="breadcrumbs" class="breadcrumbs" aria-label="Folder path"></nav>
+        </div>
         <div id="files-container" class="file-list"></div>
       </div>
       <dialog id="dialog" class="dialog-panel">
@@ -58,9 +93,18 @@ function renderFilesShell() {
           <label for="select-role">Role</label>
           <select id="select-role">
             <option value="reader">Reader</option>
+            <option value="commenter">Commenter</option>
             <option value="editor">Editor</option>
           </select>
         </div>
+        <div class="dialog-role-row" id="invite-recipients-row">
+          <label for="invite-recipients">Emails or usernames</label>
+          <input id="invite-recipients" type="text" placeholder="ann@example.com, bob">
+        </div>
+        <div class="dialog-role-row" id="invite-expiry-row">
+          <label for="invite-expires-at">Access until</label>
+          <input id="invite-expires-at" type="datetime-local" title="Access of the selected users ends then, never when empty; users with access which never ends cannot get a higher role until a date">
+        </div>
         <div id="dialog-msg" class="dialog-list"></div>
         <div class="dialog-actions">
           <button id="dialog-ok-btn" class="demo-btn-primary" type="button">Invite</button>
@@ -71,6 +115,52 @@ function renderFilesShell() {
   `
 }
 
+const jobStatusText: Record<Job['status'], string> = {
+  uploaded: 'uploaded, waiting to start',
+  queued: 'queued',
+  pending: 'in progress',
+  done: 'done',
+  failed: 'failed',
+}
+
+const jobLabel: Record<Job['kind'], string> = {
+  import: 'Import',
+  export: 'Export',
+  copy: 'Copy',
+}
+
+function showJobStatus(job: Job) {
+  const el = document.querySelector<HTMLParagraphElement>('#job-status')
+  if (!el)
+    return
+  const label = jobLabel[job.kind] ?? job.kind
+  el.textContent = `${label} ${jobStatusText[job.status] ?? job.status}${job.error ? `: ${job.error}` : ''}`
+}
+
+// applyActions turns off the actions universer is needed for while it is unavailable.
+function applyActions(actions: FileActions) {
+  const notice = document.querySelector<HTMLParagraphElement>('#service-notice')
+  const newBtn = document.querySelector<HTMLButtonElement>('#new-btn')
+  const importBtn = document.querySelector<HTMLButtonElement>('#import-btn')
+
+  if (newBtn)
+    newBtn.disabled = !actions.create
+  if (importBtn)
+    importBtn.disabled = !actions.import
+  document.querySelectorAll<HTMLButtonElement>('.export-btn').forEach((btn) => {
+    btn.disabled = !actions.export
+  })
+  document.querySelectorAll<HTMLButtonElement>('.copy-btn').forEach((btn) => {
+    btn.disabled = !actions.copy
+  })
+
+  if (notice) {
+    notice.textContent = actions.create && actions.import && actions.export && actions.copy
+      ? ''
+      : 'The document service is temporarily unavailable. Creating, importing, exporting and copying files is disabled for now.'
+  }
+}
+
 function wireAvatar(userId: string) {
   const avatar = document.querySelector<HTMLImageElement>('#user-avatar')
   if (!avatar)
@@ -96,7 +186,7 @@ function wireFileRowToggle(fileContainer: HTMLDivElement) {
   })
 }
 
-function wireCommonActions(fileContainer: HTMLDivElement, currentUserId: string) {
+function wireCommonActions(filesResp: FilesResp) {
   const logoutBtn = document.querySelector<HTMLButtonElement>('#logout-btn')
   const formWrap = document.querySelector<HTMLDivElement>('#div-form')
   const newBtn = document.querySelector<HTMLButtonElement>('#new-btn')
@@ -105,6 +195,7 @@ function wireCommonActions(fileContainer: HTMLDivElement, currentUserId: string)
   const importBtn = document.querySelector<HTMLButtonElement>('#import-btn')
   const fileInput = document.querySelector<HTMLInputElement>('#file-input')
   const deleteBtn = document.querySelector<HTMLButtonElement>('#delete-btn')
+  const removeBtn = document.querySelector<HTMLButtonElement>('#remove-btn')
 
   logoutBtn?.addEventListener('click', async () => {
     await logout()
@@ -117,7 +208,12 @@ function wireCommonActions(fileContainer: HTMLDivElement, currentUserId: string)
   newForm?.addEventListener('submit', async (event) => {
     event.preventDefault()
     const name = String(new FormData(newForm).get('name') ?? '')
-    const resp = await createSheet(name)
+    const resp = await createSheet(name, filesResp.workspaceId)
+    if (!resp.ok) {
+      const payload = (await resp.json().catch(() => ({}))) as APIError
+      alert(`Create failed: ${payload.error ?? `request failed: ${resp.status}`}`)
+      return
+    }
     if (resp.redirected) {
       location.href = resp.url
       return
@@ -135,71 +231,458 @@ function wireCommonActions(fileContainer: HTMLDivElement, currentUserId: string)
       return
     }
 
-    const resp = await importSheet(file)
-    if (resp.redirected) {
-      location.href = resp.url
-      return
+    try {
+      const job = await followJob(await importSheet(file, filesResp.workspaceId), showJobStatus)
+      if (job.status === 'failed') {
+        alert(`Import failed: ${job.error ?? 'unknown error'}`)
+        return
+      }
+      if (job.openUrl) {
+        location.href = job.openUrl
+        return
+      }
+      location.reload()
+    }
+    catch (err) {
+      alert(`Import failed: ${(err as Error).message}`)
     }
-    location.reload()
   })
 
   deleteBtn?.addEventListener('click', async () => {
     const ids = Array.from(document.querySelectorAll<HTMLInputElement>('.fileCheckbox:checked')).map(checkbox => checkbox.value)
     if (!ids.length) {
-      alert('Please select files to delete')
+      alert('Please select files to move to the trash')
       return
     }
 
-    await deleteFiles(ids)
+    const resp = await deleteFiles(ids)
+    if (!resp.ok) {
+      const payload = (await resp.json().catch(() => ({}))) as APIError
+      alert(`Move to trash failed: ${payload.error ?? `request failed: ${resp.status}`}`)
+      return
+    }
     location.reload()
   })
 
-  wireInviteDialog(fileContainer, currentUserId)
+  // files shared with the user leave their list, the owner and other collaborators keep them.
+  removeBtn?.addEventListener('click', async () => {
+    const ids = Array.from(document.querySelectorAll<HTMLInputElement>('.fileCheckbox:checked')).map(checkbox => Number(checkbox.value))
+    if (!ids.length) {
+      alert('Please select files to remove from your list')
+      return
+    }
+    try {
+      await removeFromList(ids)
+      location.reload()
+    }
+    catch (err) {
+      alert(`Remove failed: ${(err as Error).message}`)
+    }
+  })
+
+  wireInviteDialog(document, filesResp.userId)
+}
+
+function folderUrl(folderId: number) {
+  return folderId ? `/files?folder=${folderId}` : '/files'
+}
+
+function workspaceUrl(workspaceId: number) {
+  return workspaceId ? `/files?workspace=${workspaceId}` : '/files'
+}
+
+function renderBreadcrumbs(breadcrumbs: FolderItem[], workspace?: WorkspaceItem) {
+  const nav = document.querySelector<HTMLElement>('#breadcrumbs')
+  if (!nav)
+    return
+
+  if (workspace) {
+    nav.innerHTML = `<span aria-current="page">${escapeHtml(workspace.name)}</span>`
+    return
+  }
+
+  const crumbs = [{ id: 0, name: 'All files' }, ...breadcrumbs]
+  nav.innerHTML = crumbs.map((crumb, index) => index === crumbs.length - 1
+    ? `<span aria-current="page">${escapeHtml(crumb.name)}</span>`
+    : `<a href="${folderUrl(crumb.id)}">${escapeHtml(crumb.name)}</a>`,
+  ).join('<span class="breadcrumbs-sep">/</span>')
+}
+
+function renderFolderRows(folders: FolderItem[]) {
+  return folders.map(folder => `
+    <div class="file-row folder-row hover-effect" data-folder-id="${folder.id}">
+      <span class="folder-mark" aria-hidden="true"></span>
+      <div class="file-name">
+        <a href="${folderUrl(folder.id)}">${escapeHtml(folder.name)}</a>
+      </div>
+      <span class="file-role-badge role-folder">folder</span>
+      <label class="file-updated">${escapeHtml(folder.updatedAt)}</label>
+      <div class="file-actions">
+        <button class="demo-btn-secondary rename-folder-btn" type="button" data-folder-id="${folder.id}" data-folder-name="${escapeHtml(folder.name)}">Rename</button>
+        <button class="demo-btn-danger delete-folder-btn" type="button" data-folder-id="${folder.id}">Delete</button>
+      </div>
+    </div>
+  `).join('')
+}
+
+// wireWorkspaces fills the workspace switcher and handles workspace creation,
+// owners of the current workspace get the button adding members.
+function wireWorkspaces(filesResp: FilesResp, workspace?: WorkspaceItem) {
+  const switcher = document.querySelector<HTMLSelectElement>('#workspace-switch')
+  const newWorkspaceBtn = document.querySelector<HTMLButtonElement>('#new-workspace-btn')
+  const inviteBtn = document.querySelector<HTMLButtonElement>('#workspace-invite-btn')
+  const newFolderBtn = document.querySelector<HTMLButtonElement>('#new-folder-btn')
+
+  if (switcher) {
+    const options = [{ id: 0, name: 'My files' }, ...filesResp.workspaces]
+    switcher.innerHTML = options
+      .map(option => `<option value="${option.id}"${option.id === filesResp.workspaceId ? ' selected' : ''}>${escapeHtml(option.name)}</option>`)
+      .join('')
+    switcher.addEventListener('change', () => {
+      location.href = workspaceUrl(Number(switcher.value))
+    })
+  }
+
+  // workspaces have no folders.
+  if (newFolderBtn)
+    newFolderBtn.hidden = Boolean(workspace)
+  if (inviteBtn && workspace?.role === 'owner') {
+    inviteBtn.hidden = false
+    inviteBtn.dataset.workspaceId = String(workspace.id)
+  }
+
+  newWorkspaceBtn?.addEventListener('click', async () => {
+    const name = prompt('Workspace name')?.trim()
+    if (!name)
+      return
+    try {
+      const created = await createWorkspace(name)
+      location.href = workspaceUrl(created.id)
+    }
+    catch (err) {
+      alert(`Create workspace failed: ${(err as Error).message}`)
+    }
+  })
+}
+
+type MoveTarget = {
+  value: string
+  name: string
+}
+
+// moveTargets lists where the selected files can go: the top level, a folder above the current one
+// or a subfolder when browsing personal files, and every other workspace the user can edit.
+function moveTargets(filesResp: FilesResp, roles: RoleItem[]): MoveTarget[] {
+  const targets: MoveTarget[] = []
+  if (filesResp.workspaceId) {
+    targets.push({ value: 'workspace:0', name: 'My files' })
+  }
+  else {
+    const ancestors = filesResp.breadcrumbs.slice(0, -1)
+    const folders = [
+      ...(filesResp.folderId ? [{ id: 0, name: 'All files' }] : []),
+      ...ancestors,
+      ...filesResp.folders,
+    ]
+    folders.forEach(folder => targets.push({ value: `folder:${folder.id}`, name: folder.name }))
+  }
+
+  filesResp.workspaces
+    .filter(workspace => workspace.id !== filesResp.workspaceId && ['owner', 'editor'].includes(baseRole(roles, workspace.role)))
+    .forEach(workspace => targets.push({ value: `workspace:${workspace.id}`, name: `Workspace: ${workspace.name}` }))
+  return targets
+}
+
+// wireFolders handles the folder rows, folder creation and moving the selected files.
+function wireFolders(filesResp: FilesResp, roles: RoleItem[]) {
+  const newFolderBtn = document.querySelector<HTMLButtonElement>('#new-folder-btn')
+  const moveTarget = document.querySelector<HTMLSelectElement>('#move-target')
+  const moveBtn = document.querySelector<HTMLButtonElement>('#move-btn')
+
+  newFolderBtn?.addEventListener('click', async () => {
+    const name = prompt('Folder name')?.trim()
+    if (!name)
+      return
+    try {
+      await createFolder(name, filesResp.folderId)
+      location.reload()
+    }
+    catch (err) {
+      alert(`Create folder failed: ${(err as Error).message}`)
+    }
+  })
+
+  document.querySelectorAll<HTMLButtonElement>('.rename-folder-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const name = prompt('New folder name', btn.dataset.folderName ?? '')?.trim()
+      if (!name)
+        return
+      try {
+        await renameFolder(Number(btn.dataset.folderId), name)
+        location.reload()
+      }
+      catch (err) {
+        alert(`Rename failed: ${(err as Error).message}`)
+      }
+    })
+  })
+
+  document.querySelectorAll<HTMLButtonElement>('.delete-folder-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      if (!confirm('Delete this folder and its subfolders? The files inside move up one level.'))
+        return
+      try {
+        await deleteFolder(Number(btn.dataset.folderId))
+        location.reload()
+      }
+      catch (err) {
+        alert(`Delete failed: ${(err as Error).message}`)
+      }
+    })
+  })
+
+  if (moveTarget) {
+    const targets = moveTargets(filesResp, roles)
+    moveTarget.innerHTML = targets
+      .map(target => `<option value="${target.value}">${escapeHtml(target.name)}</option>`)
+      .join('')
+    if (moveBtn)
+      moveBtn.disabled = targets.length === 0
+  }
+
+  moveBtn?.addEventListener('click', async () => {
+    const ids = Array.from(document.querySelectorAll<HTMLInputElement>('.fileCheckbox:checked')).map(checkbox => Number(checkbox.value))
+    if (!ids.length || !moveTarget) {
+      alert('Please select files to move')
+      return
+    }
+    const [kind, id] = moveTarget.value.split(':')
+    try {
+      if (kind === 'workspace') {
+        for (const fileId of ids)
+          await moveToWorkspace(fileId, Number(id))
+      }
+      else {
+        await moveFiles(ids, Number(id))
+      }
+      location.reload()
+    }
+    catch (err) {
+      alert(`Move failed: ${(err as Error).message}`)
+    }
+  })
+}
+
+// renderTrash lists the trashed files of the user in place of the file list,
+// they are purged for good after the retention period of the server.
+async function renderTrash() {
+  const fileContainer = document.querySelector<HTMLDivElement>('#files-container')
+  const nav = document.querySelector<HTMLElement>('#breadcrumbs')
+  if (!fileContainer)
+    return
+
+  document.querySelectorAll<HTMLElement>('.demo-actions, #workspace-switch').forEach((el) => {
+    el.hidden = true
+  })
+  document.querySelector<HTMLButtonElement>('#logout-btn')?.addEventListener('click', async () => {
+    await logout()
+    location.href = '/login'
+  })
+  wireAvatar((await me()).user.userId)
+  if (nav)
+    nav.innerHTML = `<a href="/files">All files</a><span class="breadcrumbs-sep">/</span><span aria-current="page">Trash</span>`
+
+  const trash = await fetchTrash()
+  fileContainer.innerHTML = trash.files.length
+    ? trash.files.map(file => `
+      <div class="file-row hover-effect" data-file-id="${file.id}">
+        <div class="file-name">${escapeHtml(file.name)}</div>
+        <label class="file-updated">Deleted ${escapeHtml(file.deletedAt)}</label>
+        <div class="file-actions">
+          <button class="demo-btn-secondary restore-btn" type="button" data-file-id="${file.id}">Restore</button>
+          <button class="demo-btn-danger purge-btn" type="button" data-file-id="${file.id}">Delete Forever</button>
+        </div>
+      </div>
+    `).join('')
+    : '<p class="file-list-empty">The trash is empty.</p>'
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.restore-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      try {
+        await restoreFile(Number(btn.dataset.fileId))
+        location.reload()
+      }
+      catch (err) {
+        alert(`Restore failed: ${(err as Error).message}`)
+      }
+    })
+  })
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.purge-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      if (!confirm('Delete this file forever? It cannot be restored.'))
+        return
+      try {
+        await purgeFile(Number(btn.dataset.fileId))
+        location.reload()
+      }
+      catch (err) {
+        alert(`Delete failed: ${(err as Error).message}`)
+      }
+    })
+  })
 }
 
 export async function renderFilesPage() {
   renderFilesShell()
-  const filesResp = await fetchFiles()
+  const params = new URLSearchParams(location.search)
+  if (params.get('view') === 'trash') {
+    await renderTrash()
+    return
+  }
+  const folderId = Number(params.get('folder') ?? 0) || 0
+  const workspaceId = Number(params.get('workspace') ?? 0) || 0
+  const [filesResp, roles] = await Promise.all([fetchFiles(folderId, workspaceId), fetchRoles()])
+  const invitesPanel = document.querySelector<HTMLElement>('#invites-panel')
+  if (invitesPanel)
+    renderPendingInvites(invitesPanel)
+  const accessRequestsPanel = document.querySelector<HTMLElement>('#access-requests-panel')
+  if (accessRequestsPanel)
+    renderAccessRequests(accessRequestsPanel)
+  const workspace = filesResp.workspaces.find(item => item.id === filesResp.workspaceId)
 
   const fileContainer = document.querySelector<HTMLDivElement>('#files-container')
   if (!fileContainer)
     return
 
-  fileContainer.innerHTML = filesResp.files.map((file) => {
-    const role = normalizeRole(file)
+  renderBreadcrumbs(filesResp.breadcrumbs, workspace)
+  fileContainer.innerHTML = renderFolderRows(filesResp.folders) + filesResp.files.map((file) => {
+    const role = escapeHtml(file.role)
     return `
     <div class="file-row hover-effect" data-file-id="${file.id}">
       <input type="checkbox" name="fileIds" class="fileCheckbox file-check" value="${file.id}"/>
       <div class="file-name">
         ${file.openUrl ? `<a href="${file.openUrl}">${escapeHtml(file.name)}.xlsx</a>` : escapeHtml(file.name)}
       </div>
-      <span class="file-role-badge role-${role}">${role}</span>
+      <span class="file-role-badge role-${baseRole(roles, file.role)}">${role}${file.published ? ' · public' : ''}</span>
       <label class="file-updated">${escapeHtml(file.updatedAt)}</label>
       <div class="file-actions">
-        <button class="demo-btn-secondary members-btn" type="button" data-unit-id="${file.unitId}">Members</button>
-        <a class="file-action-link" href="${file.exportUrl}">Export</a>
-        ${role === 'owner' ? `<button class="demo-btn-secondary invite-btn" type="button" data-file-id="${file.id}">Invite</button>` : ''}
+        <button class="demo-btn-secondary members-btn" type="button" data-file-id="${file.id}">Members</button>
+        <button class="demo-btn-secondary export-btn" type="button" data-export-url="${file.exportUrl}">Export</button>
+        <button class="demo-btn-secondary copy-btn" type="button" data-file-id="${file.id}">Make a Copy</button>
+        ${file.permissions.includes('rename') ? `<button class="demo-btn-secondary rename-btn" type="button" data-file-id="${file.id}" data-name="${escapeHtml(file.name)}">Rename</button>` : ''}
+        ${file.permissions.includes('share') ? `<button class="demo-btn-secondary invite-btn" type="button" data-file-id="${file.id}">Invite</button>` : ''}
+        ${file.permissions.includes('publish') ? `<button class="demo-btn-secondary publish-btn" type="button" data-file-id="${file.id}" data-published="${file.published}" data-open-url="${file.openUrl}">${file.published ? 'Unpublish' : 'Publish'}</button>` : ''}
+        ${file.permissions.includes('manage') ? `<button class="demo-btn-secondary links-btn" type="button" data-file-id="${file.id}">Links</button>` : ''}
       </div>
     </div>
   `
   }).join('')
 
   wireAvatar(filesResp.userId)
+  applyActions(filesResp.actions)
   wireFileRowToggle(fileContainer)
-  wireCommonActions(fileContainer, filesResp.userId)
+  wireCommonActions(filesResp)
+  wireWorkspaces(filesResp, workspace)
+  wireFolders(filesResp, roles)
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.export-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const exportUrl = btn.dataset.exportUrl ?? ''
+      if (!exportUrl)
+        return
+      btn.disabled = true
+      try {
+        const job = await followJob(await exportFile(exportUrl), showJobStatus)
+        if (job.status === 'failed' || !job.downloadUrl) {
+          alert(`Export failed: ${job.error ?? 'unknown error'}`)
+          return
+        }
+        location.href = job.downloadUrl
+      }
+      catch (err) {
+        alert(`Export failed: ${(err as Error).message}`)
+      }
+      finally {
+        btn.disabled = !filesResp.actions.export
+      }
+    })
+  })
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.rename-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const fileId = Number(btn.dataset.fileId)
+      const name = prompt('Rename file', btn.dataset.name ?? '')?.trim()
+      if (!fileId || !name)
+        return
+      try {
+        await renameFile(fileId, name)
+        location.reload()
+      }
+      catch (err) {
+        alert(`Rename failed: ${(err as Error).message}`)
+      }
+    })
+  })
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.copy-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const fileId = Number(btn.dataset.fileId)
+      if (!fileId)
+        return
+      btn.disabled = true
+      try {
+        const job = await followJob(await copyFile(fileId, '', filesResp.workspaceId), showJobStatus)
+        if (job.status === 'failed') {
+          alert(`Copy failed: ${job.error ?? 'unknown error'}`)
+          return
+        }
+        location.reload()
+      }
+      catch (err) {
+        alert(`Copy failed: ${(err as Error).message}`)
+      }
+      finally {
+        btn.disabled = !filesResp.actions.copy
+      }
+    })
+  })
 
   fileContainer.querySelectorAll<HTMLButtonElement>('.members-btn').forEach((btn) => {
     btn.addEventListener('click', async () => {
-      const unitId = btn.dataset.unitId ?? ''
-      if (!unitId)
+      const fileId = Number(btn.dataset.fileId)
+      if (!fileId)
         return
-      await openMembersDialog(unitId)
+      await openMembersDialog(fileId)
+    })
+  })
+
+  fileContainer.querySelectorAll<HTMLButtonElement>('.publish-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const fileId = Number(btn.dataset.fileId)
+      const published = btn.dataset.published !== 'true'
+      if (!fileId)
+        return
+      if (!published && !confirm('Unpublish this file? People without access lose it right away.'))
+        return
+      try {
+        await publishFile(fileId, published)
+        if (published)
+          prompt('Anyone with this link can read the file, without an account too', btn.dataset.openUrl ?? '')
+        location.reload()
+      }
+      catch (err) {
+        alert(`${published ? 'Publishing' : 'Unpublishing'} failed: ${(err as Error).message}`)
+      }
     })
   })
-}
 
-function normalizeRole(file: FileItem) {
-  if (file.role === 'owner' || file.role === 'editor' || file.role === 'reader')
-    return file.role
-  return 'reader'
+  fileContainer.querySelectorAll<HTMLButtonElement>('.links-btn').forEach((btn) => {
+    btn.addEventListener('click', async () => {
+      const fileId = Number(btn.dataset.fileId)
+      if (!fileId)
+        return
+      await openShareLinksDialog(fileId)
+    })
+  })
 }
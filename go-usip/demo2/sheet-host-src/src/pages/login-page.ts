@@ -1,4 +1,4 @@
-import { renderAuthShell, attachFormMessage } from '../components/auth-shell'
+import { renderAuthShell, attachFormMessage, nextPath } from '../components/auth-shell'
 import { login } from '../services/auth-service'
 
 export function renderLoginPage() {
@@ -15,7 +15,7 @@ export function renderLoginPage() {
     </form>
     <footer class="auth-footer">
       <span>No account yet?</span>
-      <a href="/register">Go to register</a>
+      <a href="/register${location.search}">Go to register</a>
     </footer>`,
   )
 
@@ -32,7 +32,7 @@ export function renderLoginPage() {
         String(formData.get('username') ?? ''),
         String(formData.get('password') ?? ''),
       )
-      location.href = '/files'
+      location.href = nextPath()
     }
     catch (error) {
       attachFormMessage((error as Error).message)
@@ -1,7 +1,9 @@
-import { renderAuthShell, attachFormMessage } from '../components/auth-shell'
+import { renderAuthShell, attachFormMessage, nextPath } from '../components/auth-shell'
 import { register } from '../services/auth-service'
 
 export function renderRegisterPage() {
+  // an invite is only granted to the address it was sent to.
+  const invited = new URLSearchParams(location.search).has('invite')
   renderAuthShell(
     'Create Account',
     'Join and start using your workspace in seconds.',
@@ -12,12 +14,14 @@ export function renderRegisterPage() {
       <input id="register-username" type="text" name="username" required>
       <label for="register-password"><b>Password</b></label>
       <input id="register-password" type="password" name="password" required>
+      <label for="register-email"><b>Email</b> ${invited ? '(the address you were invited at)' : '(optional)'}</label>
+      <input id="register-email" type="email" name="email" ${invited ? 'required' : ''}>
       <div id="form-message" class="form-message"></div>
       <button type="submit" class="auth-submit">Register</button>
     </form>
     <footer class="auth-footer">
       <span>Already have an account?</span>
-      <a href="/login">Go to login</a>
+      <a href="/login${location.search}">Go to login</a>
     </footer>`,
   )
 
@@ -34,8 +38,10 @@ export function renderRegisterPage() {
         String(formData.get('nickname') ?? ''),
         String(formData.get('username') ?? ''),
         String(formData.get('password') ?? ''),
+        String(formData.get('email') ?? ''),
+        new URLSearchParams(location.search).get('invite') ?? '',
       )
-      location.href = '/files'
+      location.href = nextPath()
     }
     catch (error) {
       attachFormMessage((error as Error).message)
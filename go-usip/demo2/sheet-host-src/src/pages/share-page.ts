@@ -0,0 +1,56 @@
+import { attachFormMessage, renderAuthShell } from '../components/auth-shell'
+import { fetchShareLinkInfo, redeemShareLink } from '../services/share-links-service'
+import { escapeHtml } from '../utils/html'
+
+// renderSharePage redeems the share link of /share/{token}, asking for its password if it has one;
+// users who are not logged in are sent to the login page first and come back here.
+export async function renderSharePage(token: string) {
+  let info
+  try {
+    info = await fetchShareLinkInfo(token)
+  }
+  catch (error) {
+    // apiFetch is already sending the user to the login page.
+    if ((error as Error).message === 'unauthorized')
+      return
+    renderAuthShell(
+      'Link Unavailable',
+      escapeHtml((error as Error).message),
+      `<footer class="auth-footer"><a href="/files">Go to your files</a></footer>`,
+    )
+    return
+  }
+
+  renderAuthShell(
+    'Shared File',
+    `This link gives you <b>${escapeHtml(info.role)}</b> access to a file${info.expiresAt ? `, until ${escapeHtml(info.expiresAt)}` : ''}.`,
+    `<form id="share-form" class="auth-form">
+      ${info.hasPassword
+        ? `<label for="share-password"><b>Password</b></label>
+      <input id="share-password" type="password" name="password" required>`
+        : ''}
+      <div id="form-message" class="form-message"></div>
+      <button type="submit" class="auth-submit">Open File</button>
+    </form>
+    <footer class="auth-footer">
+      <a href="/files">Go to your files</a>
+    </footer>`,
+  )
+
+  const form = document.querySelector<HTMLFormElement>('#share-form')
+  if (!form)
+    return
+
+  form.addEventListener('submit', async (event) => {
+    event.preventDefault()
+    const formData = new FormData(form)
+
+    try {
+      const resp = await redeemShareLink(token, String(formData.get('password') ?? ''))
+      location.href = resp.openUrl || '/files'
+    }
+    catch (error) {
+      attachFormMessage((error as Error).message)
+    }
+  })
+}
@@ -1,16 +1,47 @@
 import { setupUniver } from '../setup-univer'
 import { openMembersDialog } from '../components/members-dialog'
+import { fetchAccessStatus } from '../services/access-requests-service'
+import { guest } from '../services/auth-service'
+import { fetchFiles } from '../services/files-service'
+import { renderAccessRequestPage } from './access-request-page'
 
-export function renderSheetPage() {
+export async function renderSheetPage() {
   const app = document.querySelector<HTMLDivElement>('#app')
   if (!app)
     return
 
+  // visitors without an account read published sheets as guests, the others log in first.
+  const unitId = new URLSearchParams(window.location.search).get('unit') ?? ''
+  let isGuest = false
+  try {
+    isGuest = (await guest(unitId)).guest
+  }
+  catch {
+    location.href = `/login?next=${encodeURIComponent(location.pathname + location.search)}`
+    return
+  }
+
+  // users without access ask the owners for it instead of opening an editor universer refuses.
+  if (!isGuest && unitId) {
+    try {
+      const status = await fetchAccessStatus(unitId)
+      if (!status.role) {
+        await renderAccessRequestPage(unitId, status)
+        return
+      }
+    }
+    catch {
+      // unknown units open like before and universer reports them.
+    }
+  }
+
   app.innerHTML = `
     <div class="sheet-shell">
       <div class="sheet-toolbar">
-        <div class="sheet-toolbar-left">Sheet</div>
-        <button id="sheet-members-btn" class="demo-btn-secondary" type="button">Members</button>
+        <div class="sheet-toolbar-left">Sheet${isGuest ? ' · read-only' : ''}</div>
+        ${isGuest
+          ? '<a class="demo-btn-secondary" href="/login">Login</a>'
+          : '<button id="sheet-members-btn" class="demo-btn-secondary" type="button">Members</button>'}
       </div>
       <div id="univer"></div>
     </div>
@@ -20,11 +51,16 @@ export function renderSheetPage() {
 
   const btn = document.querySelector<HTMLButtonElement>('#sheet-members-btn')
   btn?.addEventListener('click', async () => {
-    const unitId = new URLSearchParams(window.location.search).get('unit') ?? ''
     if (!unitId) {
       alert('Current sheet has no unit id')
       return
     }
-    await openMembersDialog(unitId)
+    const { files } = await fetchFiles()
+    const file = files.find(item => item.unitId === unitId)
+    if (!file) {
+      alert('Current sheet is not in your file list')
+      return
+    }
+    await openMembersDialog(file.id)
   })
 }
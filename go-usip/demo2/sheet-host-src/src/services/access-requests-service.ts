@@ -0,0 +1,29 @@
+import type { AccessRequestItem, AccessRequestsResp, AccessStatusResp } from '../types/access-requests'
+import { apiFetch } from './http'
+
+// fetchAccessStatus returns the role of the user on the sheet of unitId and their last access request.
+export async function fetchAccessStatus(unitId: string) {
+  return apiFetch<AccessStatusResp>(`/api/access-requests/unit/${encodeURIComponent(unitId)}`)
+}
+
+export async function requestAccess(unitId: string, role: string, message: string) {
+  return apiFetch<AccessRequestItem>('/api/access-requests', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ unitId, role, message }),
+  })
+}
+
+// fetchAccessRequests lists the pending requests on the files the user owns.
+export async function fetchAccessRequests() {
+  const payload = await apiFetch<AccessRequestsResp>('/api/access-requests')
+  return payload.requests
+}
+
+export async function approveAccessRequest(requestId: number) {
+  return apiFetch<void>(`/api/access-requests/${requestId}/approve`, { method: 'POST' })
+}
+
+export async function denyAccessRequest(requestId: number) {
+  return apiFetch<void>(`/api/access-requests/${requestId}/deny`, { method: 'POST' })
+}
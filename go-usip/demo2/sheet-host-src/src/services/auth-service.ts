@@ -1,4 +1,4 @@
-import type { AuthResp } from '../types/auth'
+import type { AuthResp, GuestResp } from '../types/auth'
 import { apiFetch } from './http'
 
 export async function login(username: string, password: string) {
@@ -9,14 +9,32 @@ export async function login(username: string, password: string) {
   })
 }
 
-export async function register(nickname: string, username: string, password: string) {
+// register creates an account, inviteToken grants the file of an invite sent to email.
+export async function register(nickname: string, username: string, password: string, email = '', inviteToken = '') {
   return apiFetch<AuthResp>('/api/auth/register', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ nickname, username, password }),
+    body: JSON.stringify({ nickname, username, password, email, inviteToken }),
   })
 }
 
+export async function me() {
+  return apiFetch<AuthResp>('/api/auth/me')
+}
+
 export async function logout() {
   return apiFetch<{ ok: boolean }>('/api/auth/logout', { method: 'POST' })
 }
+
+// guest returns the logged in user, or a guest identity for reading unitId when it is published.
+// It calls fetch directly: visitors without an account are not sent to the login page.
+export async function guest(unitId: string) {
+  const resp = await fetch('/api/auth/guest', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ unitId }),
+  })
+  if (!resp.ok)
+    throw new Error(`request failed: ${resp.status}`)
+  return (await resp.json()) as GuestResp
+}
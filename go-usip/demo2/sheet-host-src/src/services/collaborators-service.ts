@@ -1,13 +1,27 @@
 import type { CollaboratorsResp } from '../types/collaborators'
 import { apiFetch } from './http'
 
-export async function fetchCollaborators(unitId: string) {
-  const payload = await apiFetch<CollaboratorsResp>('/usip/collaborators', {
+export async function fetchCollaborators(fileId: number) {
+  const payload = await apiFetch<CollaboratorsResp>(`/api/files/${fileId}/collaborators`)
+  return payload.collaborators
+}
+
+export async function transferOwnership(fileId: number, userId: string) {
+  return apiFetch<void>(`/api/files/${fileId}/transfer`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ unitIDs: [unitId] }),
+    body: JSON.stringify({ userId }),
   })
+}
+
+export async function updateCollaboratorRole(fileId: number, userId: string, role: string) {
+  return apiFetch<void>(`/api/files/${fileId}/collaborators/${encodeURIComponent(userId)}`, {
+    method: 'PATCH',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ role }),
+  })
+}
 
-  const target = payload.collaborators.find(item => item.unitID === unitId)
-  return target?.subjects ?? []
+export async function removeCollaborator(fileId: number, userId: string) {
+  return apiFetch<void>(`/api/files/${fileId}/collaborators/${encodeURIComponent(userId)}`, { method: 'DELETE' })
 }
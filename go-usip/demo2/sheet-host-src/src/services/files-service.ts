@@ -1,27 +1,58 @@
-import type { FilesResp, UserListResp } from '../types/files'
+import type { FileItem, FilesResp, UserListResp } from '../types/files'
+import type { Job } from '../types/jobs'
 import { apiFetch } from './http'
 
-export async function fetchFiles() {
-  return apiFetch<FilesResp>('/api/files')
+export async function fetchFiles(folderId = 0, workspaceId = 0) {
+  return apiFetch<FilesResp>(`/api/files?folderId=${folderId}&workspaceId=${workspaceId}`)
 }
 
 export async function fetchPeople(next = 0) {
   return apiFetch<UserListResp>(`/user/people?next=${next}&size=10`)
 }
 
-export async function createSheet(name: string) {
+export async function createSheet(name: string, workspaceId = 0) {
   const formData = new FormData()
   formData.set('name', name)
   formData.set('type', 'sheet')
+  formData.set('workspaceId', String(workspaceId))
   return fetch('/file/new', { method: 'POST', body: formData })
 }
 
-export async function importSheet(file: File) {
+export async function importSheet(file: File, workspaceId = 0) {
   const formData = new FormData()
   formData.append('file', file)
   formData.append('type', 'sheet')
+  formData.append('workspaceId', String(workspaceId))
   formData.append('name', file.name.replace(/\.xlsx$/i, ''))
-  return fetch('/file/import', { method: 'POST', body: formData })
+  return apiFetch<Job>('/file/import', { method: 'POST', body: formData })
+}
+
+export async function exportFile(exportUrl: string) {
+  return apiFetch<Job>(exportUrl)
+}
+
+export async function renameFile(fileId: number, name: string) {
+  return apiFetch<FileItem>(`/api/files/${fileId}`, {
+    method: 'PATCH',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ name }),
+  })
+}
+
+export async function publishFile(fileId: number, published: boolean) {
+  return apiFetch<void>(`/api/files/${fileId}/publish`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ published }),
+  })
+}
+
+export async function copyFile(fileId: number, name = '', workspaceId = 0) {
+  return apiFetch<Job>(`/api/files/${fileId}/copy`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ name, workspaceId }),
+  })
 }
 
 export async function deleteFiles(fileIds: string[]) {
@@ -30,10 +61,19 @@ export async function deleteFiles(fileIds: string[]) {
   return fetch(`/file?${params.toString()}`, { method: 'DELETE' })
 }
 
-export async function inviteUsers(fileId: number, userIds: string[], role: string) {
+export async function removeFromList(fileIds: number[]) {
+  return apiFetch<void>('/api/files/remove', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ fileIds }),
+  })
+}
+
+// inviteUsers shares the file with userIds, until expiresAt when it is set.
+export async function inviteUsers(fileId: number, userIds: string[], role: string, expiresAt?: string) {
   return fetch('/file/join', {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ fileId, userIds, role }),
+    body: JSON.stringify({ fileId, userIds, role, expiresAt }),
   })
 }
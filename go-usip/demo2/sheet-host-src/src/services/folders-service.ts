@@ -0,0 +1,30 @@
+import type { FolderItem } from '../types/folders'
+import { apiFetch } from './http'
+
+export async function createFolder(name: string, parentId: number) {
+  return apiFetch<FolderItem>('/api/folders', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ name, parentId }),
+  })
+}
+
+export async function renameFolder(folderId: number, name: string) {
+  return apiFetch<FolderItem>(`/api/folders/${folderId}`, {
+    method: 'PATCH',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ name }),
+  })
+}
+
+export async function deleteFolder(folderId: number) {
+  return apiFetch<void>(`/api/folders/${folderId}`, { method: 'DELETE' })
+}
+
+export async function moveFiles(fileIds: number[], folderId: number) {
+  return apiFetch<void>('/api/files/move', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ fileIds, folderId }),
+  })
+}
@@ -4,8 +4,9 @@ export async function apiFetch<T>(input: RequestInfo | URL, init?: RequestInit):
   const resp = await fetch(input, init)
 
   if (resp.status === 401) {
+    // the login page sends the user back to where they were, e.g. a share link.
     if (location.pathname !== '/login')
-      location.href = '/login'
+      location.href = `/login?next=${encodeURIComponent(location.pathname + location.search)}`
     throw new Error('unauthorized')
   }
 
@@ -0,0 +1,25 @@
+import type { InvitesResp, SentInvitesResp } from '../types/invites'
+import { apiFetch } from './http'
+
+// sendInvites invites email addresses or usernames to a file.
+export async function sendInvites(fileId: number, recipients: string[], role: string) {
+  const payload = await apiFetch<SentInvitesResp>(`/api/files/${fileId}/invites`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ recipients, role }),
+  })
+  return payload.invites
+}
+
+export async function fetchInvites() {
+  const payload = await apiFetch<InvitesResp>('/api/invites')
+  return payload.invites
+}
+
+export async function acceptInvite(inviteId: number) {
+  return apiFetch<void>(`/api/invites/${inviteId}/accept`, { method: 'POST' })
+}
+
+export async function declineInvite(inviteId: number) {
+  return apiFetch<void>(`/api/invites/${inviteId}/decline`, { method: 'POST' })
+}
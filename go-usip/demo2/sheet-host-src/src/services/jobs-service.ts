@@ -0,0 +1,46 @@
+import type { Job } from '../types/jobs'
+import { apiFetch } from './http'
+
+export async function fetchJob(id: string) {
+  return apiFetch<Job>(`/api/jobs/${id}`)
+}
+
+function isFinished(job: Job) {
+  return job.status === 'done' || job.status === 'failed'
+}
+
+export async function waitForJob(job: Job, onUpdate?: (job: Job) => void, intervalMs = 1000): Promise<Job> {
+  let current = job
+  while (!isFinished(current)) {
+    await new Promise(resolve => setTimeout(resolve, intervalMs))
+    current = await fetchJob(current.id)
+    onUpdate?.(current)
+  }
+  return current
+}
+
+// followJob streams the job status from the server, falling back to polling
+// when the event stream is unavailable.
+export function followJob(job: Job, onUpdate?: (job: Job) => void): Promise<Job> {
+  onUpdate?.(job)
+  if (isFinished(job))
+    return Promise.resolve(job)
+  if (typeof EventSource === 'undefined')
+    return waitForJob(job, onUpdate)
+
+  return new Promise((resolve) => {
+    const source = new EventSource(`/api/jobs/${job.id}/events`)
+    source.addEventListener('status', (event) => {
+      const current = JSON.parse((event as MessageEvent<string>).data) as Job
+      onUpdate?.(current)
+      if (isFinished(current)) {
+        source.close()
+        resolve(current)
+      }
+    })
+    source.onerror = () => {
+      source.close()
+      resolve(waitForJob(job, onUpdate))
+    }
+  })
+}
@@ -0,0 +1,15 @@
+import type { RoleItem, RolesResp } from '../types/roles'
+import { apiFetch } from './http'
+
+let roles: Promise<RoleItem[]> | undefined
+
+// fetchRoles returns the built-in and custom roles, highest first, loaded once per page.
+export function fetchRoles() {
+  roles ??= apiFetch<RolesResp>('/api/roles').then(resp => resp.roles)
+  return roles
+}
+
+// baseRole returns the built-in role a custom role acts as.
+export function baseRole(items: RoleItem[], role: string) {
+  return items.find(item => item.name === role)?.base ?? role
+}
@@ -0,0 +1,37 @@
+import type {
+  ShareLinkCreateReq,
+  ShareLinkInfo,
+  ShareLinkItem,
+  ShareLinksResp,
+  ShareRedeemResp,
+} from '../types/share-links'
+import { apiFetch } from './http'
+
+export async function fetchShareLinks(fileId: number) {
+  const payload = await apiFetch<ShareLinksResp>(`/api/files/${fileId}/links`)
+  return payload.links
+}
+
+export async function createShareLink(fileId: number, req: ShareLinkCreateReq) {
+  return apiFetch<ShareLinkItem>(`/api/files/${fileId}/links`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify(req),
+  })
+}
+
+export async function revokeShareLink(fileId: number, linkId: number) {
+  return apiFetch<void>(`/api/files/${fileId}/links/${linkId}`, { method: 'DELETE' })
+}
+
+export async function fetchShareLinkInfo(token: string) {
+  return apiFetch<ShareLinkInfo>(`/api/share/${encodeURIComponent(token)}`)
+}
+
+export async function redeemShareLink(token: string, password: string) {
+  return apiFetch<ShareRedeemResp>(`/api/share/${encodeURIComponent(token)}`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ password }),
+  })
+}
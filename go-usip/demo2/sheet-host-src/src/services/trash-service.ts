@@ -0,0 +1,14 @@
+import type { TrashResp } from '../types/trash'
+import { apiFetch } from './http'
+
+export async function fetchTrash() {
+  return apiFetch<TrashResp>('/api/trash')
+}
+
+export async function restoreFile(fileId: number) {
+  return apiFetch<void>(`/api/trash/${fileId}/restore`, { method: 'POST' })
+}
+
+export async function purgeFile(fileId: number) {
+  return apiFetch<void>(`/api/trash/${fileId}`, { method: 'DELETE' })
+}
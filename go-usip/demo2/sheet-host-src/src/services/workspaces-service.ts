@@ -0,0 +1,26 @@
+import type { WorkspaceItem } from '../types/workspaces'
+import { apiFetch } from './http'
+
+export async function createWorkspace(name: string) {
+  return apiFetch<WorkspaceItem>('/api/workspaces', {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ name }),
+  })
+}
+
+export async function addWorkspaceMembers(workspaceId: number, userIds: string[], role: string) {
+  return apiFetch<void>(`/api/workspaces/${workspaceId}/members`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ userIds, role }),
+  })
+}
+
+export async function moveToWorkspace(fileId: number, workspaceId: number) {
+  return apiFetch<void>(`/api/files/${fileId}/workspace`, {
+    method: 'POST',
+    headers: { 'Content-Type': 'application/json' },
+    body: JSON.stringify({ workspaceId }),
+  })
+}
@@ -0,0 +1,24 @@
+export type AccessRequestItem = {
+  id: number
+  fileId: number
+  fileName: string
+  // userId, nickname and avatar are the requester.
+  userId: string
+  nickname: string
+  avatar: string
+  role: string
+  message: string
+  status: 'pending' | 'approved' | 'denied'
+  createdAt: string
+}
+
+export type AccessRequestsResp = {
+  requests: AccessRequestItem[]
+}
+
+export type AccessStatusResp = {
+  fileId: number
+  // role is empty when the user has no access.
+  role: string
+  request: AccessRequestItem | null
+}
@@ -7,3 +7,9 @@ export type User = {
 export type AuthResp = {
   user: User
 }
+
+export type GuestResp = {
+  user: User
+  // guest is false for logged in users, who keep their identity.
+  guest: boolean
+}
@@ -6,12 +6,12 @@ export type CollaboratorSubject = {
 
 export type CollaboratorItem = {
   subject: CollaboratorSubject
-  role: 'owner' | 'editor' | 'reader' | string
+  role: string
+  granted?: boolean
+  // expiresAt is when the grant ends, missing for grants which never expire.
+  expiresAt?: string
 }
 
 export type CollaboratorsResp = {
-  collaborators: Array<{
-    unitID: string
-    subjects: CollaboratorItem[]
-  }>
+  collaborators: CollaboratorItem[]
 }
@@ -1,17 +1,39 @@
+import type { FolderItem } from './folders'
+import type { WorkspaceItem } from './workspaces'
+
 export type FileItem = {
   id: number
   name: string
   unitId: string
   unitType: number
-  role: 'owner' | 'editor' | 'reader'
+  role: string
+  // permissions are the actions the role allows on the file, e.g. rename or share.
+  permissions: string[]
+  sourceFileId?: number
+  // published files can be read through openUrl by anyone, without an account too.
+  published: boolean
   updatedAt: string
   openUrl: string
   exportUrl: string
 }
 
+export type FileActions = {
+  create: boolean
+  import: boolean
+  export: boolean
+  copy: boolean
+}
+
 export type FilesResp = {
   userId: string
+  workspaceId: number
+  workspaces: WorkspaceItem[]
+  folderId: number
+  breadcrumbs: FolderItem[]
+  folders: FolderItem[]
   files: FileItem[]
+  universer: 'closed' | 'open' | 'half-open'
+  actions: FileActions
 }
 
 export type UserListResp = {
@@ -0,0 +1,6 @@
+export type FolderItem = {
+  id: number
+  name: string
+  parentId: number
+  updatedAt: string
+}
@@ -1,5 +1,5 @@
 export type InviteSelection = {
   fileId: number
   userIds: string[]
-  role: 'reader' | 'editor'
+  role: string
 }
@@ -0,0 +1,23 @@
+export type InviteItem = {
+  id: number
+  fileId: number
+  fileName: string
+  role: string
+  invitedBy: string
+  createdAt: string
+}
+
+export type InvitesResp = {
+  invites: InviteItem[]
+}
+
+export type SentInvite = {
+  id: number
+  userId?: string
+  email?: string
+  role: string
+}
+
+export type SentInvitesResp = {
+  invites: SentInvite[]
+}
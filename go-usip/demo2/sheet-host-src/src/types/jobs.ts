@@ -0,0 +1,13 @@
+export type JobStatus = 'uploaded' | 'queued' | 'pending' | 'done' | 'failed'
+
+export type Job = {
+  id: string
+  kind: 'import' | 'export' | 'copy'
+  status: JobStatus
+  error?: string
+  fileId?: number
+  openUrl?: string
+  downloadUrl?: string
+  createdAt: string
+  updatedAt: string
+}
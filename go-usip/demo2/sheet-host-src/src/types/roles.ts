@@ -0,0 +1,9 @@
+export type RoleItem = {
+  name: string
+  // base is the built-in role a custom role acts as.
+  base: 'owner' | 'editor' | 'commenter' | 'reader'
+}
+
+export type RolesResp = {
+  roles: RoleItem[]
+}
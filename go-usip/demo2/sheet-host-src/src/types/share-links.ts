@@ -0,0 +1,36 @@
+export type ShareLinkItem = {
+  id: number
+  url: string
+  role: string
+  createdBy: string
+  expiresAt?: string
+  hasPassword: boolean
+  maxUses: number
+  uses: number
+  createdAt: string
+}
+
+export type ShareLinksResp = {
+  links: ShareLinkItem[]
+}
+
+export type ShareLinkCreateReq = {
+  role: string
+  // expiresAt is an RFC 3339 time, the link never expires without it.
+  expiresAt?: string
+  password?: string
+  // maxUses is 0 for no limit.
+  maxUses: number
+}
+
+export type ShareLinkInfo = {
+  role: string
+  hasPassword: boolean
+  expiresAt?: string
+}
+
+export type ShareRedeemResp = {
+  fileId: number
+  role: string
+  openUrl: string
+}
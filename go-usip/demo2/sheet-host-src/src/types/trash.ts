@@ -0,0 +1,10 @@
+export type TrashItem = {
+  id: number
+  name: string
+  unitType: number
+  deletedAt: string
+}
+
+export type TrashResp = {
+  files: TrashItem[]
+}
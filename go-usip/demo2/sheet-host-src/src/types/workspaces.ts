@@ -0,0 +1,6 @@
+export type WorkspaceItem = {
+  id: number
+  name: string
+  role: string
+  updatedAt: string
+}
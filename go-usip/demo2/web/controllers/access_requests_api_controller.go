@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"go-usip/datamodels"
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+// AccessRequestsAPIController lets users who open a sheet they cannot see ask its owners for access,
+// and owners answer the requests on their files.
+type AccessRequestsAPIController struct {
+	Ctx iris.Context
+
+	Service     services.AccessRequestService
+	FileService services.FileService
+	UserService services.UserService
+	Session     *sessions.Session
+}
+
+type accessRequestItemResp struct {
+	ID       uint   `json:"id"`
+	FileId   uint   `json:"fileId"`
+	FileName string `json:"fileName"`
+	// UserId, Nickname and Avatar are the requester.
+	UserId    string `json:"userId"`
+	Nickname  string `json:"nickname"`
+	Avatar    string `json:"avatar"`
+	Role      string `json:"role"`
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type accessRequestsListResp struct {
+	Requests []accessRequestItemResp `json:"requests"`
+}
+
+type accessStatusResp struct {
+	FileId uint `json:"fileId"`
+	// Role is empty when the user has no access.
+	Role    string                 `json:"role"`
+	Request *accessRequestItemResp `json:"request"`
+}
+
+type accessRequestReq struct {
+	UnitId  string `json:"unitId"`
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+func accessRequestItem(request datamodels.AccessRequest) accessRequestItemResp {
+	return accessRequestItemResp{
+		ID:        request.ID,
+		FileId:    request.FileId,
+		UserId:    request.UserId,
+		Role:      string(request.Role),
+		Message:   request.Message,
+		Status:    string(request.Status),
+		CreatedAt: request.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// Get handles GET: /api/access-requests, the pending requests on the files the user owns, oldest first.
+func (c *AccessRequestsAPIController) Get() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	requests, err := c.Service.GetPending(userID)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := accessRequestsListResp{Requests: make([]accessRequestItemResp, 0, len(requests))}
+	for _, request := range requests {
+		// requests on trashed files wait until the file is restored.
+		file, found := c.FileService.GetByFileId(request.FileId)
+		if !found {
+			continue
+		}
+		item := accessRequestItem(request)
+		item.FileName = file.Name
+		if requester, found := c.UserService.GetByID(request.UserId); found {
+			item.Nickname = requester.Nickname
+			item.Avatar = avatarURL(requester.UserId)
+		}
+		resp.Requests = append(resp.Requests, item)
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+// GetUnitBy handles GET: /api/access-requests/unit/{unitId}, the role of the user on the sheet
+// and their last request on it, for the sheet page to offer asking for access.
+func (c *AccessRequestsAPIController) GetUnitBy(unitId string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	status, err := c.Service.Status(userID, unitId)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := accessStatusResp{FileId: status.FileId, Role: string(status.Role)}
+	if status.Request != nil {
+		item := accessRequestItem(*status.Request)
+		resp.Request = &item
+	}
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+// Post handles POST: /api/access-requests, asks the owners of the sheet of unitId for a role.
+func (c *AccessRequestsAPIController) Post() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req accessRequestReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	request, err := c.Service.Request(services.AccessRequestReq{
+		UserId:  userID,
+		UnitId:  req.UnitId,
+		Role:    datamodels.Role(req.Role),
+		Message: req.Message,
+	})
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusCreated)
+	c.Ctx.JSON(accessRequestItem(request))
+	return nil
+}
+
+// PostByApprove handles POST: /api/access-requests/{id}/approve, grants the requested role, owners only.
+func (c *AccessRequestsAPIController) PostByApprove(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Approve(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+// PostByDeny handles POST: /api/access-requests/{id}/deny, owners only.
+func (c *AccessRequestsAPIController) PostByDeny(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Deny(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+// AdminAPIController serves the users listed under admins in the config file.
+type AdminAPIController struct {
+	Ctx iris.Context
+
+	OwnershipService services.OwnershipService
+	Session          *sessions.Session
+}
+
+type adminTransferReq struct {
+	FromUserId string `json:"fromUserId"`
+	ToUserId   string `json:"toUserId"`
+}
+
+type adminTransferResp struct {
+	Transferred int `json:"transferred"`
+}
+
+// PostTransfer handles POST: /api/admin/transfer, moves every file a user owns to another user,
+// e.g. when offboarding them.
+func (c *AdminAPIController) PostTransfer() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req adminTransferReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || req.FromUserId == "" || req.ToUserId == "" {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	transferred, err := c.OwnershipService.TransferAll(userID, req.FromUserId, req.ToUserId)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.JSON(adminTransferResp{Transferred: transferred})
+	return nil
+}
@@ -12,8 +12,10 @@ import (
 type AuthAPIController struct {
 	Ctx iris.Context
 
-	Service services.UserService
-	Session *sessions.Session
+	Service       services.UserService
+	FileService   services.FileService
+	InviteService services.InviteService
+	Session       *sessions.Session
 }
 
 type apiErrorResp struct {
@@ -60,6 +62,49 @@ func (c *AuthAPIController) GetMe() mvc.Result {
 	return nil
 }
 
+type authGuestReq struct {
+	UnitId string `json:"unitId"`
+}
+
+type authGuestResp struct {
+	User authUserResp `json:"user"`
+	// Guest tells the user has no account and only reads published files.
+	Guest bool `json:"guest"`
+}
+
+// PostGuest handles POST: /api/auth/guest, gives visitors without an account opening a published unit
+// a guest identity, which universer is told through /usip/credential. Logged in users keep theirs.
+func (c *AuthAPIController) PostGuest() mvc.Result {
+	if userID, ok := isLoggedIn(c.Session); ok {
+		if user, found := c.Service.GetByID(userID); found {
+			c.Ctx.JSON(authGuestResp{User: buildAuthUserResp(user)})
+			return nil
+		}
+		c.Session.Delete(userIDKey)
+	}
+
+	var req authGuestReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || req.UnitId == "" {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	// unpublished units are reported as not found, like the files guests cannot read.
+	guestId := c.Session.GetStringDefault(guestIDKey, "")
+	if guestId == "" {
+		guestId = datamodels.GenerateGuestId()
+	}
+	if role, _ := c.FileService.GetUnitRole(req.UnitId, guestId); role == "" {
+		return writeServiceError(c.Ctx, services.ErrFileNotFound)
+	}
+
+	c.Session.Set(guestIDKey, guestId)
+	c.Ctx.JSON(authGuestResp{
+		User:  authUserResp{UserId: guestId, Nickname: datamodels.GuestNickname},
+		Guest: true,
+	})
+	return nil
+}
+
 type authLoginReq struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -85,21 +130,39 @@ type authRegisterReq struct {
 	Nickname string `json:"nickname"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Email    string `json:"email"`
+	// InviteToken is the token of the invite email the user registers from.
+	InviteToken string `json:"inviteToken"`
 }
 
+// PostRegister handles POST: /api/auth/register, registering with an invite token
+// and the email address it was sent to grants the role of the invite.
+// The other invites sent to the email address become pending invites of the new user.
 func (c *AuthAPIController) PostRegister() mvc.Result {
 	var req authRegisterReq
 	if err := c.Ctx.ReadJSON(&req); err != nil {
 		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
 	}
+	if req.InviteToken != "" {
+		if _, err := c.InviteService.Lookup(req.InviteToken, req.Email); err != nil {
+			return writeServiceError(c.Ctx, err)
+		}
+	}
 
 	user, err := c.Service.Create(req.Password, datamodels.User{
 		Nickname: req.Nickname,
 		Username: req.Username,
+		Email:    req.Email,
 	})
 	if err != nil {
 		return writeAPIError(c.Ctx, iris.StatusBadRequest, err.Error())
 	}
+	if req.InviteToken != "" {
+		// the account exists already, a failure only loses the invite and is logged by the service.
+		_ = c.InviteService.Redeem(user.UserId, req.InviteToken)
+	}
+	// likewise, the invites stay with the address.
+	_ = c.InviteService.Attach(user.UserId, user.Email)
 
 	c.Session.Set(userIDKey, user.UserId)
 	c.Ctx.StatusCode(iris.StatusCreated)
@@ -6,6 +6,8 @@ import (
 	"go-usip/datamodels"
 	"go-usip/services"
 	"io"
+	"strconv"
+	"time"
 
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/mvc"
@@ -22,6 +24,9 @@ type FileController struct {
 	Ctx iris.Context
 
 	Service services.FileService
+	// Universer tells whether universer is reachable, see services.UniverserBreaker.
+	Universer services.UniverserBreaker
+	Trash     services.TrashService
 
 	// Session, binded using dependency injection from the main.go.
 	Session *sessions.Session
@@ -44,17 +49,16 @@ func (c *FileController) PostNew() mvc.Result {
 
 	name := c.Ctx.FormValue("name")
 	unitType := c.Ctx.FormValue("type")
-	file, err := c.Service.Create(services.CreateUnitRequest{
-		Name:   name,
-		Type:   unitType,
-		UserId: userId,
-		Cookie: c.Ctx.GetHeader("Cookie"),
+	workspaceId, _ := strconv.ParseUint(c.Ctx.FormValue("workspaceId"), 10, 64)
+	file, err := c.Service.Create(c.Ctx.Request().Context(), services.CreateUnitRequest{
+		Name:        name,
+		Type:        unitType,
+		UserId:      userId,
+		WorkspaceId: uint(workspaceId),
+		Cookie:      c.Ctx.GetHeader("Cookie"),
 	})
 	if err != nil {
-		return mvc.Response{
-			Code: iris.StatusInternalServerError,
-			Text: err.Error(),
-		}
+		return writeServiceError(c.Ctx, err)
 	}
 
 	host := c.Ctx.Host()
@@ -81,6 +85,7 @@ func (c *FileController) PostImport() mvc.Result {
 	}
 
 	unitType := datamodels.FileTypeInt(c.Ctx.FormValue("type"))
+	workspaceId, _ := strconv.ParseUint(c.Ctx.FormValue("workspaceId"), 10, 64)
 	formfile, fileHeader, err := c.Ctx.FormFile("file")
 	if err != nil {
 		return mvc.Response{
@@ -89,33 +94,22 @@ func (c *FileController) PostImport() mvc.Result {
 		}
 	}
 
-	file, err := c.Service.Import(services.ImportReq{
-		FormFile: formfile,
-		UserId:   userId,
-		FileName: fileHeader.Filename,
-		FileSize: int(fileHeader.Size),
-		Type:     unitType,
-		Cookie:   c.Ctx.GetHeader("Cookie"),
+	job, err := c.Service.Import(c.Ctx.Request().Context(), services.ImportReq{
+		FormFile:    formfile,
+		UserId:      userId,
+		FileName:    fileHeader.Filename,
+		FileSize:    int(fileHeader.Size),
+		Type:        unitType,
+		Cookie:      c.Ctx.GetHeader("Cookie"),
+		WorkspaceId: uint(workspaceId),
 	})
 	if err != nil {
-		return mvc.Response{
-			Code: iris.StatusInternalServerError,
-			Text: err.Error(),
-		}
+		return writeServiceError(c.Ctx, err)
 	}
 
-	host := c.Ctx.Host()
-
-	path := "/files"
-	switch unitType {
-	case datamodels.UnitTypeSheet:
-		path = getUnitHost(viper.GetString("univer.sheetHost"), host) + "/?type=2&unit=" + file.UnitId
-	}
-
-	return mvc.Response{
-		Code: iris.StatusFound,
-		Path: path,
-	}
+	c.Ctx.StatusCode(iris.StatusAccepted)
+	c.Ctx.JSON(buildJobResp(c.Ctx, job))
+	return nil
 }
 
 func (c *FileController) GetExport() mvc.Result {
@@ -134,18 +128,43 @@ func (c *FileController) GetExport() mvc.Result {
 		}
 	}
 
-	result, err := c.Service.Export(services.ExportReq{
+	if rejectWhileUnavailable(c.Ctx, c.Universer, "exporting unit") {
+		return nil
+	}
+
+	job, err := c.Service.Export(services.ExportReq{
 		FileId: uint(fileId),
 		UserId: userId,
 		Cookie: c.Ctx.GetHeader("Cookie"),
 	})
 	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusAccepted)
+	c.Ctx.JSON(buildJobResp(c.Ctx, job))
+	return nil
+}
+
+// GetExportDownload handles GET: /file/export/download?jobId=<id>.
+func (c *FileController) GetExportDownload() mvc.Result {
+	userId, ok := isLoggedIn(c.Session)
+	if !ok {
 		return mvc.Response{
-			Code: iris.StatusInternalServerError,
-			Text: err.Error(),
+			Code: iris.StatusUnauthorized,
 		}
 	}
 
+	result, err := c.Service.Download(c.Ctx.Request().Context(), services.DownloadReq{
+		JobId:  c.Ctx.URLParam("jobId"),
+		UserId: userId,
+		Cookie: c.Ctx.GetHeader("Cookie"),
+	})
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+	defer result.Reader.Close()
+
 	c.Ctx.Header("Content-Disposition", "attachment; filename="+result.FileName)
 	_, err = io.Copy(c.Ctx.ResponseWriter(), result.Reader)
 	if err != nil {
@@ -154,11 +173,11 @@ func (c *FileController) GetExport() mvc.Result {
 			Text: err.Error(),
 		}
 	}
-	defer result.Reader.Close()
 
 	return mvc.Response{}
 }
 
+// Delete handles DELETE: /file, moves files the user owns to the trash.
 func (c *FileController) Delete() mvc.Result {
 	userId, ok := isLoggedIn(c.Session)
 	if !ok {
@@ -178,12 +197,8 @@ func (c *FileController) Delete() mvc.Result {
 		}
 	}
 
-	err := c.Service.BatchDelete(userId, req.FileIds)
-	if err != nil {
-		return mvc.Response{
-			Code: iris.StatusInternalServerError,
-			Text: err.Error(),
-		}
+	if err := c.Trash.Trash(userId, req.FileIds); err != nil {
+		return writeServiceError(c.Ctx, err)
 	}
 
 	return mvc.Response{}
@@ -201,6 +216,8 @@ func (c *FileController) PostJoin() mvc.Result {
 		UserIds []string `json:"userIds"`
 		FileId  uint     `json:"fileId"`
 		Role    string   `json:"role"`
+		// ExpiresAt ends the grants, e.g. of a contractor, they never expire when omitted.
+		ExpiresAt *time.Time `json:"expiresAt"`
 	}
 	if err := c.Ctx.ReadJSON(&req); err != nil {
 		return mvc.Response{
@@ -209,26 +226,15 @@ func (c *FileController) PostJoin() mvc.Result {
 		}
 	}
 
-	if !c.Service.CheckPermission(services.CheckPermissionReq{
-		FileId: req.FileId,
-		UserId: userId,
-		Action: services.ActionJoin,
-	}) {
-		return mvc.Response{
-			Code: iris.StatusForbidden,
-		}
-	}
-
 	err := c.Service.Join(services.JoinReq{
-		FileId:  req.FileId,
-		Role:    datamodels.Role(req.Role),
-		UserIds: req.UserIds,
+		UserId:    userId,
+		FileId:    req.FileId,
+		Role:      datamodels.Role(req.Role),
+		UserIds:   req.UserIds,
+		ExpiresAt: req.ExpiresAt,
 	})
 	if err != nil {
-		return mvc.Response{
-			Code: iris.StatusInternalServerError,
-			Text: err.Error(),
-		}
+		return writeServiceError(c.Ctx, err)
 	}
 
 	return mvc.Response{}
@@ -4,6 +4,7 @@ import (
 	"go-usip/datamodels"
 	"go-usip/services"
 	"strconv"
+	"time"
 
 	"github.com/kataras/iris/v12"
 	"github.com/kataras/iris/v12/mvc"
@@ -14,69 +15,604 @@ import (
 type FilesAPIController struct {
 	Ctx iris.Context
 
-	Service services.FileService
-	Session *sessions.Session
+	Service          services.FileService
+	UserService      services.UserService
+	FolderService    services.FolderService
+	WorkspaceService services.WorkspaceService
+	OwnershipService services.OwnershipService
+	ShareLinkService services.ShareLinkService
+	InviteService    services.InviteService
+	Universer        services.UniverserBreaker
+	Session          *sessions.Session
 }
 
 type fileItemResp struct {
-	ID        uint   `json:"id"`
-	Name      string `json:"name"`
-	UnitId    string `json:"unitId"`
-	UnitType  int    `json:"unitType"`
-	Role      string `json:"role"`
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	UnitId   string `json:"unitId"`
+	UnitType int    `json:"unitType"`
+	Role     string `json:"role"`
+	// Permissions are the actions the role of the user allows on the file.
+	Permissions []services.Action `json:"permissions"`
+	// SourceFileId is the file this one is a copy of.
+	SourceFileId uint `json:"sourceFileId,omitempty"`
+	// Published files are read-only for anyone opening their OpenURL, without an account too.
+	Published bool   `json:"published"`
 	UpdatedAt string `json:"updatedAt"`
 	OpenURL   string `json:"openUrl"`
 	ExportURL string `json:"exportUrl"`
 }
 
+// fileActionsResp tells which actions are currently offered,
+// the ones calling universer are turned off while it is unavailable.
+type fileActionsResp struct {
+	Create bool `json:"create"`
+	Import bool `json:"import"`
+	Export bool `json:"export"`
+	Copy   bool `json:"copy"`
+}
+
 type filesListResp struct {
-	UserId string         `json:"userId"`
-	Files  []fileItemResp `json:"files"`
+	UserId      string          `json:"userId"`
+	WorkspaceId uint            `json:"workspaceId"`
+	Workspaces  []workspaceResp `json:"workspaces"`
+	FolderId    uint            `json:"folderId"`
+	Breadcrumbs []folderResp    `json:"breadcrumbs"`
+	Folders     []folderResp    `json:"folders"`
+	Files       []fileItemResp  `json:"files"`
+	Universer   string          `json:"universer"`
+	Actions     fileActionsResp `json:"actions"`
 }
 
+// Get handles GET: /api/files, the files of a personal folder (folderId),
+// or the files of a workspace (workspaceId) which has no folders.
 func (c *FilesAPIController) Get() mvc.Result {
 	userID, ok := isLoggedIn(c.Session)
 	if !ok {
 		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
 	}
 
-	files, _ := c.Service.GetByUserId(userID)
+	workspaces, err := c.WorkspaceService.GetByUserId(userID)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	var (
+		workspaceId = uint(c.Ctx.URLParamUint64("workspaceId"))
+		folderId    = uint(c.Ctx.URLParamUint64("folderId"))
+		breadcrumbs []datamodels.Folder
+		folders     []datamodels.Folder
+		files       []datamodels.File
+	)
+	if workspaceId != datamodels.NoWorkspaceId {
+		workspace, err := c.WorkspaceService.Get(userID, workspaceId)
+		if err != nil {
+			return writeServiceError(c.Ctx, err)
+		}
+		folderId = datamodels.RootFolderId
+		files, _ = c.Service.GetByWorkspaceId(workspace.ID)
+	} else {
+		breadcrumbs, err = c.FolderService.GetBreadcrumbs(userID, folderId)
+		if err != nil {
+			return writeServiceError(c.Ctx, err)
+		}
+		folders, err = c.FolderService.GetChildren(userID, folderId)
+		if err != nil {
+			return writeServiceError(c.Ctx, err)
+		}
+		files, _ = c.Service.GetByUserIdInFolder(userID, folderId)
+	}
+
 	host := c.Ctx.Host()
 	sheetHost := getUnitHost(viper.GetString("univer.sheetHost"), host)
 
+	available := c.Universer.Available()
 	resp := filesListResp{
-		UserId: userID,
-		Files:  make([]fileItemResp, 0, len(files)),
+		UserId:      userID,
+		WorkspaceId: workspaceId,
+		Workspaces:  buildWorkspacesResp(workspaces),
+		FolderId:    folderId,
+		Breadcrumbs: buildFoldersResp(breadcrumbs),
+		Folders:     buildFoldersResp(folders),
+		Files:       make([]fileItemResp, 0, len(files)),
+		Universer:   string(c.Universer.State()),
+		Actions: fileActionsResp{
+			Create: available,
+			Import: available,
+			Export: available,
+			Copy:   available,
+		},
 	}
 
 	for _, file := range files {
-		item := fileItemResp{
-			ID:        file.ID,
-			Name:      file.Name,
-			UnitId:    file.UnitId,
-			UnitType:  file.UnitType,
-			Role:      string(datamodels.RoleReader),
-			UpdatedAt: file.UpdatedAt.Format("2006-01-02 15:04:05"),
-			ExportURL: "/file/export?fileId=" + strconv.Itoa(int(file.ID)),
+		resp.Files = append(resp.Files, c.buildFileItemResp(file, userID, sheetHost))
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+func (c *FilesAPIController) buildFileItemResp(file datamodels.File, userID string, sheetHost string) fileItemResp {
+	role := c.Service.GetRole(file.ID, userID)
+	item := fileItemResp{
+		ID:           file.ID,
+		Name:         file.Name,
+		UnitId:       file.UnitId,
+		UnitType:     file.UnitType,
+		Role:         string(role),
+		Permissions:  services.AllowedActions(role),
+		SourceFileId: file.SourceFileId,
+		Published:    file.Published,
+		UpdatedAt:    file.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ExportURL:    "/file/export?fileId=" + strconv.Itoa(int(file.ID)),
+	}
+
+	if file.UnitType == datamodels.UnitTypeSheet {
+		item.OpenURL = sheetHost + "/?unit=" + file.UnitId + "&type=2"
+	}
+	return item
+}
+
+type fileRenameReq struct {
+	Name string `json:"name"`
+}
+
+// PatchBy handles PATCH: /api/files/{id}, renames a file the user can edit.
+func (c *FilesAPIController) PatchBy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req fileRenameReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	file, err := c.Service.Rename(userID, id, req.Name)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.JSON(c.buildFileItemResp(file, userID, getUnitHost(viper.GetString("univer.sheetHost"), c.Ctx.Host())))
+	return nil
+}
+
+type fileCopyReq struct {
+	Name        string `json:"name"`
+	WorkspaceId uint   `json:"workspaceId"`
+}
+
+// PostByCopy handles POST: /api/files/{id}/copy, queues the copy of a file the user can open
+// and returns the job, the body is optional.
+func (c *FilesAPIController) PostByCopy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req fileCopyReq
+	if c.Ctx.GetContentLength() > 0 {
+		if err := c.Ctx.ReadJSON(&req); err != nil {
+			return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
 		}
+	}
+
+	if rejectWhileUnavailable(c.Ctx, c.Universer, "copying unit") {
+		return nil
+	}
+
+	job, err := c.Service.Copy(services.CopyReq{
+		FileId:      id,
+		UserId:      userID,
+		Name:        req.Name,
+		WorkspaceId: req.WorkspaceId,
+		Cookie:      c.Ctx.GetHeader("Cookie"),
+	})
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusAccepted)
+	c.Ctx.JSON(buildJobResp(c.Ctx, job))
+	return nil
+}
+
+type filesMoveReq struct {
+	FileIds  []uint `json:"fileIds"`
+	FolderId uint   `json:"folderId"`
+}
+
+// PostMove handles POST: /api/files/move, places files of the user in a folder.
+func (c *FilesAPIController) PostMove() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req filesMoveReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || len(req.FileIds) == 0 {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.FolderService.MoveFiles(userID, req.FileIds, req.FolderId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type filesRemoveReq struct {
+	FileIds []uint `json:"fileIds"`
+}
+
+// PostRemove handles POST: /api/files/remove, drops files shared with the user from their list.
+func (c *FilesAPIController) PostRemove() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req filesRemoveReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || len(req.FileIds) == 0 {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Service.RemoveFromList(userID, req.FileIds); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type filesWorkspaceReq struct {
+	WorkspaceId uint `json:"workspaceId"`
+}
+
+// PostByWorkspace handles POST: /api/files/{id}/workspace, moves a file the user owns
+// into a workspace, workspaceId 0 makes it personal again.
+func (c *FilesAPIController) PostByWorkspace(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req filesWorkspaceReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Service.MoveToWorkspace(userID, id, req.WorkspaceId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type fileTransferReq struct {
+	UserId string `json:"userId"`
+}
+
+// PostByTransfer handles POST: /api/files/{id}/transfer, makes a collaborator owner of a file
+// the user owns, the user becomes an editor.
+func (c *FilesAPIController) PostByTransfer(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req fileTransferReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || req.UserId == "" {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.OwnershipService.Transfer(userID, id, req.UserId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type filePublishReq struct {
+	Published bool `json:"published"`
+}
+
+// PostByPublish handles POST: /api/files/{id}/publish, lets anyone read a file the user owns,
+// guests without an account included, or stops it.
+func (c *FilesAPIController) PostByPublish(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req filePublishReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Service.Publish(userID, id, req.Published); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type collaboratorSubjectResp struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
 
-		collaborators, found := c.Service.GetCollaborators(file.ID)
-		if found {
-			for _, collaborator := range collaborators {
-				if collaborator.UserId == userID && collaborator.Role != "" {
-					item.Role = string(collaborator.Role)
-					break
-				}
-			}
+type collaboratorItemResp struct {
+	Subject collaboratorSubjectResp `json:"subject"`
+	Role    string                  `json:"role"`
+	// Granted tells a file collaborator has a grant on the file which can be changed or revoked,
+	// workspace members without one get their role from the workspace.
+	Granted bool `json:"granted,omitempty"`
+	// ExpiresAt is when the grant ends, omitted for grants which never expire.
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+type collaboratorsListResp struct {
+	Collaborators []collaboratorItemResp `json:"collaborators"`
+}
+
+// GetByCollaborators handles GET: /api/files/{id}/collaborators.
+func (c *FilesAPIController) GetByCollaborators(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	collaborators, _ := c.Service.GetCollaborators(id)
+	member := false
+	userIds := make([]string, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		if collaborator.UserId == userID {
+			member = true
 		}
+		userIds = append(userIds, collaborator.UserId)
+	}
+	if !member {
+		return writeAPIError(c.Ctx, iris.StatusNotFound, "file not found")
+	}
 
-		if file.UnitType == datamodels.UnitTypeSheet {
-			item.OpenURL = sheetHost + "/?unit=" + file.UnitId + "&type=2"
+	users, _ := c.UserService.GetInIDs(userIds)
+	usersById := make(map[string]datamodels.User, len(users))
+	for _, user := range users {
+		usersById[user.UserId] = user
+	}
+
+	resp := collaboratorsListResp{
+		Collaborators: make([]collaboratorItemResp, 0, len(collaborators)),
+	}
+	for _, collaborator := range collaborators {
+		user, found := usersById[collaborator.UserId]
+		if !found {
+			continue
 		}
+		item := collaboratorItemResp{
+			Subject: collaboratorSubjectResp{
+				ID:     user.UserId,
+				Name:   user.Nickname,
+				Avatar: avatarURL(user.UserId),
+			},
+			Role:    string(collaborator.Role),
+			Granted: collaborator.ID != 0,
+		}
+		if collaborator.ExpiresAt != nil {
+			item.ExpiresAt = collaborator.ExpiresAt.Format("2006-01-02 15:04:05")
+		}
+		resp.Collaborators = append(resp.Collaborators, item)
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+type collaboratorUpdateReq struct {
+	Role datamodels.Role `json:"role"`
+}
+
+// PatchByCollaboratorsBy handles PATCH: /api/files/{id}/collaborators/{userId}, changes the role of a collaborator.
+func (c *FilesAPIController) PatchByCollaboratorsBy(id uint, collaboratorId string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req collaboratorUpdateReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Service.UpdateCollaborator(userID, id, collaboratorId, req.Role); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+// DeleteByCollaboratorsBy handles DELETE: /api/files/{id}/collaborators/{userId}, revokes the access of a collaborator.
+func (c *FilesAPIController) DeleteByCollaboratorsBy(id uint, collaboratorId string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.RemoveCollaborator(userID, id, collaboratorId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type shareLinkResp struct {
+	ID        uint   `json:"id"`
+	URL       string `json:"url"`
+	Role      string `json:"role"`
+	CreatedBy string `json:"createdBy"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	// HasPassword tells the link asks for a password, the password itself is never returned.
+	HasPassword bool   `json:"hasPassword"`
+	MaxUses     int    `json:"maxUses"`
+	Uses        int    `json:"uses"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+func buildShareLinkResp(link datamodels.ShareLink) shareLinkResp {
+	resp := shareLinkResp{
+		ID:          link.ID,
+		URL:         viper.GetString("host") + "/share/" + link.Token,
+		Role:        string(link.Role),
+		CreatedBy:   link.CreatedBy,
+		HasPassword: len(link.HashedPassword) > 0,
+		MaxUses:     link.MaxUses,
+		Uses:        link.Uses,
+		CreatedAt:   link.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if link.ExpiresAt != nil {
+		resp.ExpiresAt = link.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
+}
+
+type shareLinksListResp struct {
+	Links []shareLinkResp `json:"links"`
+}
+
+// GetByLinks handles GET: /api/files/{id}/links, the share links of a file the user owns.
+func (c *FilesAPIController) GetByLinks(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	links, err := c.ShareLinkService.GetByFileId(userID, id)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := shareLinksListResp{Links: make([]shareLinkResp, 0, len(links))}
+	for _, link := range links {
+		resp.Links = append(resp.Links, buildShareLinkResp(link))
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+type shareLinkCreateReq struct {
+	Role datamodels.Role `json:"role"`
+	// ExpiresAt is an RFC 3339 time, the link never expires without it.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	Password  string     `json:"password"`
+	MaxUses   int        `json:"maxUses"`
+}
+
+// PostByLinks handles POST: /api/files/{id}/links, creates a share link of a file the user owns.
+func (c *FilesAPIController) PostByLinks(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req shareLinkCreateReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	link, err := c.ShareLinkService.Create(services.CreateShareLinkReq{
+		UserId:    userID,
+		FileId:    id,
+		Role:      req.Role,
+		ExpiresAt: req.ExpiresAt,
+		Password:  req.Password,
+		MaxUses:   req.MaxUses,
+	})
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusCreated)
+	c.Ctx.JSON(buildShareLinkResp(link))
+	return nil
+}
+
+// DeleteByLinksBy handles DELETE: /api/files/{id}/links/{linkId}, revokes a share link,
+// the access it already granted is kept.
+func (c *FilesAPIController) DeleteByLinksBy(id uint, linkId uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.ShareLinkService.Revoke(userID, id, linkId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+type fileInvitesReq struct {
+	// Recipients are email addresses or usernames.
+	Recipients []string        `json:"recipients"`
+	Role       datamodels.Role `json:"role"`
+}
+
+type fileInviteResp struct {
+	ID     uint   `json:"id"`
+	UserId string `json:"userId,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Role   string `json:"role"`
+}
+
+type fileInvitesResp struct {
+	Invites []fileInviteResp `json:"invites"`
+}
+
+// PostByInvites handles POST: /api/files/{id}/invites, invites people by email address or username.
+// Existing users get a pending invite, unknown email addresses an email to register with.
+func (c *FilesAPIController) PostByInvites(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req fileInvitesReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	invites, err := c.InviteService.Invite(services.InviteReq{
+		UserId:     userID,
+		FileId:     id,
+		Recipients: req.Recipients,
+		Role:       req.Role,
+	})
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
 
-		resp.Files = append(resp.Files, item)
+	resp := fileInvitesResp{Invites: make([]fileInviteResp, 0, len(invites))}
+	for _, invite := range invites {
+		resp.Invites = append(resp.Invites, fileInviteResp{
+			ID:     invite.ID,
+			UserId: invite.UserId,
+			Email:  invite.Email,
+			Role:   string(invite.Role),
+		})
 	}
 
+	c.Ctx.StatusCode(iris.StatusCreated)
 	c.Ctx.JSON(resp)
 	return nil
 }
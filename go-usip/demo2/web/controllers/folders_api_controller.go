@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"go-usip/datamodels"
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+type FoldersAPIController struct {
+	Ctx iris.Context
+
+	Service services.FolderService
+	Session *sessions.Session
+}
+
+type folderResp struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	ParentId  uint   `json:"parentId"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func buildFolderResp(folder datamodels.Folder) folderResp {
+	return folderResp{
+		ID:        folder.ID,
+		Name:      folder.Name,
+		ParentId:  folder.ParentId,
+		UpdatedAt: folder.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func buildFoldersResp(folders []datamodels.Folder) []folderResp {
+	resp := make([]folderResp, 0, len(folders))
+	for _, folder := range folders {
+		resp = append(resp, buildFolderResp(folder))
+	}
+	return resp
+}
+
+type folderDetailResp struct {
+	Folder      folderResp   `json:"folder"`
+	Breadcrumbs []folderResp `json:"breadcrumbs"`
+	Folders     []folderResp `json:"folders"`
+}
+
+type folderCreateReq struct {
+	Name     string `json:"name"`
+	ParentId uint   `json:"parentId"`
+}
+
+// Post handles POST: /api/folders.
+func (c *FoldersAPIController) Post() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req folderCreateReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	folder, err := c.Service.Create(userID, req.Name, req.ParentId)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusCreated)
+	c.Ctx.JSON(buildFolderResp(folder))
+	return nil
+}
+
+// GetBy handles GET: /api/folders/{id}, the folder with its breadcrumbs and subfolders.
+func (c *FoldersAPIController) GetBy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	folder, err := c.Service.Get(userID, id)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+	breadcrumbs, err := c.Service.GetBreadcrumbs(userID, id)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+	children, err := c.Service.GetChildren(userID, id)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.JSON(folderDetailResp{
+		Folder:      buildFolderResp(folder),
+		Breadcrumbs: buildFoldersResp(breadcrumbs),
+		Folders:     buildFoldersResp(children),
+	})
+	return nil
+}
+
+type folderUpdateReq struct {
+	Name     *string `json:"name"`
+	ParentId *uint   `json:"parentId"`
+}
+
+// PatchBy handles PATCH: /api/folders/{id}, renames and/or moves the folder.
+func (c *FoldersAPIController) PatchBy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req folderUpdateReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	folder, err := c.Service.Get(userID, id)
+	if req.Name != nil && err == nil {
+		folder, err = c.Service.Rename(userID, id, *req.Name)
+	}
+	if req.ParentId != nil && err == nil {
+		folder, err = c.Service.Move(userID, id, *req.ParentId)
+	}
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.JSON(buildFolderResp(folder))
+	return nil
+}
+
+// DeleteBy handles DELETE: /api/folders/{id}.
+func (c *FoldersAPIController) DeleteBy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Delete(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
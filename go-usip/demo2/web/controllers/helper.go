@@ -1,13 +1,23 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
+	"go-usip/services"
+	"log"
 	"strings"
 
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
 	"github.com/kataras/iris/v12/sessions"
+	"github.com/spf13/viper"
 )
 
 const userIDKey = "UserID"
 
+// guestIDKey holds the guest id of a visitor without an account who opened a published file.
+const guestIDKey = "GuestID"
+
 func isLoggedIn(session *sessions.Session) (string, bool) {
 	userId := session.GetStringDefault(userIDKey, "")
 	return userId, userId != ""
@@ -28,3 +38,83 @@ func getUnitHost(v, host string) string {
 
 	return v
 }
+
+func avatarURL(userId string) string {
+	return fmt.Sprintf("%s/user/avatar/%s", viper.GetString("host"), userId)
+}
+
+// rejectWhileUnavailable answers 503 and returns true when the universer breaker is open.
+// Jobs queued meanwhile would only wait for universer, the user is told right away instead.
+func rejectWhileUnavailable(ctx iris.Context, breaker services.UniverserBreaker, op string) bool {
+	if breaker.Available() {
+		return false
+	}
+	writeServiceError(ctx, &services.UnavailableError{UniverserError: services.UniverserError{
+		Op:      op,
+		Message: "circuit breaker is open",
+	}})
+	return true
+}
+
+// writeServiceError answers a service error with the JSON error envelope,
+// the status depends on the error type, anything unknown is a 500.
+// 5xx errors are logged and answered with a generic message, their details stay on the server.
+func writeServiceError(ctx iris.Context, err error) mvc.Result {
+	status := serviceErrorStatus(err)
+	if status >= iris.StatusInternalServerError {
+		log.Printf("Error while handling %s %s: %v", ctx.Method(), ctx.Path(), err)
+		return writeAPIError(ctx, status, "internal error")
+	}
+	return writeAPIError(ctx, status, err.Error())
+}
+
+func serviceErrorStatus(err error) int {
+	var (
+		notFound  *services.NotFoundError
+		denied    *services.PermissionDeniedError
+		invalid   *services.InvalidFileError
+		quota     *services.QuotaExceededError
+		timeout   *services.TimeoutError
+		upstream  *services.UnavailableError
+		universer *services.UniverserError
+	)
+
+	switch {
+	case errors.Is(err, services.ErrFileNotFound), errors.Is(err, services.ErrJobNotFound),
+		errors.Is(err, services.ErrFolderNotFound), errors.Is(err, services.ErrWorkspaceNotFound),
+		errors.Is(err, services.ErrUserNotFound), errors.Is(err, services.ErrCollaboratorNotFound),
+		errors.Is(err, services.ErrShareLinkNotFound), errors.Is(err, services.ErrInviteNotFound),
+		errors.Is(err, services.ErrAccessRequestNotFound),
+		errors.As(err, &notFound):
+		return iris.StatusNotFound
+	case errors.Is(err, services.ErrEmptyName), errors.Is(err, services.ErrInvalidRole),
+		errors.Is(err, services.ErrSameOwner), errors.Is(err, services.ErrNotCollaborator),
+		errors.Is(err, services.ErrInvalidMaxUses), errors.Is(err, services.ErrExpiresInPast),
+		errors.Is(err, services.ErrNoRecipients), errors.Is(err, services.ErrInvalidEmail),
+		errors.Is(err, services.ErrMessageTooLong), errors.Is(err, services.ErrOwnerExpiry),
+		errors.Is(err, services.ErrInviteEmail):
+		return iris.StatusBadRequest
+	case errors.Is(err, services.ErrShareLinkExpired), errors.Is(err, services.ErrShareLinkUsedUp):
+		return iris.StatusGone
+	case errors.Is(err, services.ErrInvalidFolder), errors.Is(err, services.ErrLastOwner),
+		errors.Is(err, services.ErrOwnerRemove), errors.Is(err, services.ErrAlreadyGranted),
+		errors.Is(err, services.ErrJobNotFinished), errors.Is(err, services.ErrExpiringUpgrade):
+		return iris.StatusConflict
+	case errors.Is(err, services.ErrForbidden), errors.Is(err, services.ErrNotOwner),
+		errors.Is(err, services.ErrNotFileOwner), errors.Is(err, services.ErrShareLinkPassword),
+		errors.As(err, &denied):
+		return iris.StatusForbidden
+	case errors.As(err, &invalid):
+		return iris.StatusUnprocessableEntity
+	case errors.As(err, &quota):
+		return iris.StatusRequestEntityTooLarge
+	case errors.As(err, &timeout):
+		return iris.StatusGatewayTimeout
+	case errors.As(err, &upstream):
+		return iris.StatusServiceUnavailable
+	case errors.As(err, &universer):
+		return iris.StatusBadGateway
+	default:
+		return iris.StatusInternalServerError
+	}
+}
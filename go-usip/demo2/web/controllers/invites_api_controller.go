@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+// InvitesAPIController lets users answer the invites to files sent to their username or email.
+type InvitesAPIController struct {
+	Ctx iris.Context
+
+	Service     services.InviteService
+	FileService services.FileService
+	UserService services.UserService
+	Session     *sessions.Session
+}
+
+type inviteItemResp struct {
+	ID       uint   `json:"id"`
+	FileId   uint   `json:"fileId"`
+	FileName string `json:"fileName"`
+	Role     string `json:"role"`
+	// InvitedBy is the nickname of the user who sent the invite.
+	InvitedBy string `json:"invitedBy"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type invitesListResp struct {
+	Invites []inviteItemResp `json:"invites"`
+}
+
+// Get handles GET: /api/invites, the pending invites of the user, newest first.
+func (c *InvitesAPIController) Get() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	invites, err := c.Service.GetPending(userID)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := invitesListResp{Invites: make([]inviteItemResp, 0, len(invites))}
+	for _, invite := range invites {
+		// invites to trashed files wait until the file is restored.
+		file, found := c.FileService.GetByFileId(invite.FileId)
+		if !found {
+			continue
+		}
+		item := inviteItemResp{
+			ID:        invite.ID,
+			FileId:    invite.FileId,
+			FileName:  file.Name,
+			Role:      string(invite.Role),
+			CreatedAt: invite.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+		if inviter, found := c.UserService.GetByID(invite.InvitedBy); found {
+			item.InvitedBy = inviter.Nickname
+		}
+		resp.Invites = append(resp.Invites, item)
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+// PostByAccept handles POST: /api/invites/{id}/accept, grants the role of the invite,
+// users granted more already keep their role.
+func (c *InvitesAPIController) PostByAccept(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Accept(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+// PostByDecline handles POST: /api/invites/{id}/decline.
+func (c *InvitesAPIController) PostByDecline(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Decline(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
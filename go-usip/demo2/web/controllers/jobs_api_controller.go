@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-usip/datamodels"
+	"time"
+
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/spf13/viper"
+)
+
+type JobsAPIController struct {
+	Ctx iris.Context
+
+	Service services.JobService
+	Session *sessions.Session
+}
+
+type jobResp struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+	FileId      uint   `json:"fileId,omitempty"`
+	OpenURL     string `json:"openUrl,omitempty"`
+	DownloadURL string `json:"downloadUrl,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+}
+
+func buildJobResp(ctx iris.Context, job datamodels.Job) jobResp {
+	resp := jobResp{
+		ID:        job.JobId,
+		Kind:      string(job.Kind),
+		Status:    string(job.Status),
+		Error:     job.Error,
+		FileId:    job.FileId,
+		CreatedAt: job.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt: job.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+
+	if job.Status == datamodels.JobStatusDone {
+		switch job.Kind {
+		case datamodels.JobKindImport, datamodels.JobKindCopy:
+			if job.UnitType == datamodels.UnitTypeSheet {
+				resp.OpenURL = getUnitHost(viper.GetString("univer.sheetHost"), ctx.Host()) + "/?type=2&unit=" + job.Result
+			}
+		case datamodels.JobKindExport:
+			resp.DownloadURL = "/file/export/download?jobId=" + job.JobId
+		}
+	}
+	return resp
+}
+
+// GetBy handles GET: /api/jobs/{id}.
+func (c *JobsAPIController) GetBy(id string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	job, found := c.Service.Get(id)
+	if !found || job.UserId != userID {
+		return writeAPIError(c.Ctx, iris.StatusNotFound, "job not found")
+	}
+
+	c.Ctx.JSON(buildJobResp(c.Ctx, job))
+	return nil
+}
+
+const sseKeepAlive = 15 * time.Second
+
+// GetByEvents handles GET: /api/jobs/{id}/events.
+// It streams the job as server-sent `status` events, starting with its
+// current state and ending once it is done or failed.
+func (c *JobsAPIController) GetByEvents(id string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	// subscribe before reading the job so no change is missed in between.
+	events, cancel := c.Service.Subscribe(id)
+	defer cancel()
+
+	job, found := c.Service.Get(id)
+	if !found || job.UserId != userID {
+		return writeAPIError(c.Ctx, iris.StatusNotFound, "job not found")
+	}
+
+	c.Ctx.ContentType("text/event-stream")
+	c.Ctx.Header("Cache-Control", "no-cache")
+	c.Ctx.Header("Connection", "keep-alive")
+	c.Ctx.Header("X-Accel-Buffering", "no")
+
+	if !c.writeJobEvent(job) || job.Finished() {
+		return nil
+	}
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-c.Ctx.Request().Context().Done():
+			return nil
+		case <-keepAlive.C:
+			fmt.Fprint(c.Ctx.ResponseWriter(), ": keep-alive\n\n")
+			c.Ctx.ResponseWriter().Flush()
+		case ev := <-events:
+			job, found = c.Service.Get(ev.JobId)
+			if !found {
+				return nil
+			}
+			// the event carries the status it was published with,
+			// the stored job may have moved on already.
+			job.Status = ev.Status
+			job.Error = ev.Error
+			if !c.writeJobEvent(job) || job.Finished() {
+				return nil
+			}
+		}
+	}
+}
+
+func (c *JobsAPIController) writeJobEvent(job datamodels.Job) bool {
+	data, err := json.Marshal(buildJobResp(c.Ctx, job))
+	if err != nil {
+		return false
+	}
+
+	w := c.Ctx.ResponseWriter()
+	if _, err := fmt.Fprintf(w, "event: status\ndata: %s\n\n", data); err != nil {
+		return false
+	}
+	w.Flush()
+	return true
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"go-usip/datamodels"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+type RolesAPIController struct {
+	Ctx iris.Context
+
+	Session *sessions.Session
+}
+
+type roleResp struct {
+	Name string `json:"name"`
+	// Base is the built-in role a custom role acts as, the role itself for built-in roles.
+	Base string `json:"base"`
+}
+
+type rolesListResp struct {
+	Roles []roleResp `json:"roles"`
+}
+
+// Get handles GET: /api/roles, the built-in and custom roles, highest first.
+func (c *RolesAPIController) Get() mvc.Result {
+	if _, ok := isLoggedIn(c.Session); !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	roles := datamodels.Roles()
+	resp := rolesListResp{Roles: make([]roleResp, 0, len(roles))}
+	for _, role := range roles {
+		resp.Roles = append(resp.Roles, roleResp{
+			Name: string(role),
+			Base: string(role.Base()),
+		})
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+	"github.com/spf13/viper"
+)
+
+// ShareAPIController redeems the share links owners create under /api/files/{id}/links.
+type ShareAPIController struct {
+	Ctx iris.Context
+
+	Service          services.FileService
+	ShareLinkService services.ShareLinkService
+	Session          *sessions.Session
+}
+
+type shareLinkInfoResp struct {
+	Role        string `json:"role"`
+	HasPassword bool   `json:"hasPassword"`
+	ExpiresAt   string `json:"expiresAt,omitempty"`
+}
+
+// GetBy handles GET: /api/share/{token}, what the link grants, before asking for its password.
+func (c *ShareAPIController) GetBy(token string) mvc.Result {
+	if _, ok := isLoggedIn(c.Session); !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	link, err := c.ShareLinkService.Lookup(token)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := shareLinkInfoResp{
+		Role:        string(link.Role),
+		HasPassword: len(link.HashedPassword) > 0,
+	}
+	if link.ExpiresAt != nil {
+		resp.ExpiresAt = link.ExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+type shareRedeemReq struct {
+	Password string `json:"password"`
+}
+
+type shareRedeemResp struct {
+	FileId  uint   `json:"fileId"`
+	Role    string `json:"role"`
+	OpenURL string `json:"openUrl"`
+}
+
+// PostBy handles POST: /api/share/{token}, grants the user the role of the link,
+// users granted more already keep their role.
+func (c *ShareAPIController) PostBy(token string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req shareRedeemReq
+	if c.Ctx.GetContentLength() > 0 {
+		if err := c.Ctx.ReadJSON(&req); err != nil {
+			return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+		}
+	}
+
+	link, err := c.ShareLinkService.Redeem(userID, token, req.Password)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := shareRedeemResp{
+		FileId: link.FileId,
+		Role:   string(c.Service.GetRole(link.FileId, userID)),
+	}
+	if file, found := c.Service.GetByFileId(link.FileId); found {
+		resp.OpenURL = getUnitHost(viper.GetString("univer.sheetHost"), c.Ctx.Host()) + "/?unit=" + file.UnitId + "&type=2"
+	}
+	c.Ctx.JSON(resp)
+	return nil
+}
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+type TrashAPIController struct {
+	Ctx iris.Context
+
+	Service services.TrashService
+	Session *sessions.Session
+}
+
+type trashItemResp struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	UnitType  int    `json:"unitType"`
+	DeletedAt string `json:"deletedAt"`
+}
+
+type trashListResp struct {
+	Files []trashItemResp `json:"files"`
+}
+
+// Get handles GET: /api/trash, the trashed files the user owns.
+func (c *TrashAPIController) Get() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	files, err := c.Service.GetByUserId(userID)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	resp := trashListResp{Files: make([]trashItemResp, 0, len(files))}
+	for _, file := range files {
+		resp.Files = append(resp.Files, trashItemResp{
+			ID:        file.ID,
+			Name:      file.Name,
+			UnitType:  file.UnitType,
+			DeletedAt: file.DeletedAt.Time.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+// PostByRestore handles POST: /api/trash/{id}/restore.
+func (c *TrashAPIController) PostByRestore(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Restore(userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+// DeleteBy handles DELETE: /api/trash/{id}, purges the file and its universer unit.
+func (c *TrashAPIController) DeleteBy(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.Purge(c.Ctx.Request().Context(), userID, id); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go-usip/datamodels"
+	"go-usip/services"
+	"go-usip/usip"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+// UsipProvider serves the usip endpoints universer calls
+// from the user and file services.
+type UsipProvider struct {
+	UserService services.UserService
+	FileService services.FileService
+}
+
+func NewUsipProvider(userService services.UserService, fileService services.FileService) *UsipProvider {
+	return &UsipProvider{
+		UserService: userService,
+		FileService: fileService,
+	}
+}
+
+type sessionUserIDKey struct{}
+
+// WithSessionUser resolves the logged in user of the iris session, or its guest,
+// before handing the request to a net/http handler,
+// the user id is read back by UsipProvider.VerifyCredential.
+func WithSessionUser(sessManager *sessions.Sessions, next http.Handler) iris.Handler {
+	return func(ctx iris.Context) {
+		r := ctx.Request()
+		if userId, ok := sessionUser(sessManager.Start(ctx)); ok {
+			r = r.WithContext(context.WithValue(r.Context(), sessionUserIDKey{}, userId))
+		}
+		next.ServeHTTP(ctx.ResponseWriter(), r)
+	}
+}
+
+// sessionUser is the logged in user of the session, or its guest id when nobody logged in.
+func sessionUser(session *sessions.Session) (string, bool) {
+	if userId, ok := isLoggedIn(session); ok {
+		return userId, true
+	}
+	guestId := session.GetStringDefault(guestIDKey, "")
+	return guestId, guestId != ""
+}
+
+func toUsipUser(userId, nickname string) usip.UsipUser {
+	return usip.UsipUser{
+		UserId: userId,
+		Name:   nickname,
+		Avatar: avatarURL(userId),
+	}
+}
+
+func (p *UsipProvider) VerifyCredential(r *http.Request) (usip.UsipUser, error) {
+	userId, _ := r.Context().Value(sessionUserIDKey{}).(string)
+	if userId == "" {
+		return usip.UsipUser{}, usip.ErrUnauthorized
+	}
+	if datamodels.IsGuestId(userId) {
+		return toUsipUser(userId, datamodels.GuestNickname), nil
+	}
+
+	user, ok := p.UserService.GetByID(userId)
+	if !ok {
+		return usip.UsipUser{}, usip.ErrUnauthorized
+	}
+
+	return toUsipUser(user.UserId, user.Nickname), nil
+}
+
+func (p *UsipProvider) GetUsers(_ context.Context, userIds []string) ([]usip.UsipUser, error) {
+	var users []usip.UsipUser
+	for _, userId := range userIds {
+		if datamodels.IsGuestId(userId) {
+			users = append(users, toUsipUser(userId, datamodels.GuestNickname))
+		}
+	}
+
+	tmp, _ := p.UserService.GetInIDs(userIds)
+	for _, u := range tmp {
+		users = append(users, toUsipUser(u.UserId, u.Nickname))
+	}
+	return users, nil
+}
+
+func (p *UsipProvider) GetCollaborators(_ context.Context, unitId string) ([]usip.UsipCollaborator, error) {
+	collaborators, found := p.FileService.GetCollaboratorsByUnitId(unitId)
+	if !found {
+		return nil, usip.ErrUnitNotFound
+	}
+
+	subjects := make([]usip.UsipCollaborator, 0, len(collaborators))
+	for _, v := range collaborators {
+		user, found := p.UserService.GetByID(v.UserId)
+		if !found {
+			continue
+		}
+		subjects = append(subjects, usip.UsipCollaborator{
+			Subject: usip.UsipSubject{
+				ID:     user.UserId,
+				Name:   user.Nickname,
+				Avatar: avatarURL(user.UserId),
+			},
+			Role: usip.Role(v.Role.Base()),
+		})
+	}
+	return subjects, nil
+}
+
+func (p *UsipProvider) GetRole(_ context.Context, unitId, userId string) (usip.Role, error) {
+	role, found := p.FileService.GetUnitRole(unitId, userId)
+	if !found {
+		return "", usip.ErrUnitNotFound
+	}
+	return usip.Role(role.Base()), nil
+}
+
+func (p *UsipProvider) RecordEditTime(_ context.Context, unitId string, editTime time.Time) error {
+	err := p.FileService.UpdateEditTime(unitId, editTime.UnixMilli())
+	if errors.Is(err, services.ErrFileNotFound) {
+		return usip.ErrUnitNotFound
+	}
+	return err
+}
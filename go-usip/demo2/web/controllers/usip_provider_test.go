@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go-usip/datamodels"
+	"go-usip/migrations"
+	"go-usip/repositories"
+	"go-usip/services"
+	"go-usip/usip"
+	"go-usip/usip/conformance"
+	"go-usip/usip/conformance/conformancetest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/sessions"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// TestUsipConformance serves the usip endpoints the way main does, from a migrated sqlite database,
+// and runs the conformance suite against them with the session of a collaborator.
+func TestUsipConformance(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "demo.db")), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{SingularTable: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	userRepo := repositories.NewUserRepository(db)
+	fileRepo := repositories.NewFileRepository(db)
+	collaRepo := repositories.NewFileCollaboratorRepository(db)
+	memberRepo := repositories.NewWorkspaceMemberRepository(db)
+	uow := repositories.NewUnitOfWork(db)
+	// the usip endpoints neither draw avatars nor call universer.
+	userService := services.NewUserService(userRepo, nil)
+	fileService := services.NewFileService(fileRepo, collaRepo, memberRepo, uow, nil, nil)
+
+	user, err := userService.Create("p", datamodels.User{Nickname: "Ann", Username: "ann"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := fileRepo.Create(datamodels.File{Name: "Budget", UnitId: "unit1", UnitType: datamodels.UnitTypeSheet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collaRepo.Create(datamodels.FileCollaborator{FileId: file.ID, UserId: user.UserId, Role: datamodels.RoleOwner}); err != nil {
+		t.Fatal(err)
+	}
+
+	sessManager := sessions.New(sessions.Config{Cookie: "_on-premise"})
+	usipProvider := NewUsipProvider(userService, fileService)
+	app := iris.New()
+	app.Get("/login", func(ctx iris.Context) {
+		sessManager.Start(ctx).Set(userIDKey, user.UserId)
+	})
+	app.Any("/usip/{path:path}", WithSessionUser(sessManager, http.StripPrefix("/usip", usip.NewHandler(usip.Config{
+		Credentials:   usipProvider,
+		Users:         usipProvider,
+		Collaborators: usipProvider,
+		EditTimes:     usipProvider,
+		Secret:        "s3cret",
+	}))))
+	if err := app.Build(); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(app)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	var cookie string
+	for _, c := range resp.Cookies() {
+		cookie = c.Name + "=" + c.Value
+	}
+	if cookie == "" {
+		t.Fatal("no session cookie")
+	}
+
+	conformancetest.Test(t, conformance.Config{
+		BaseURL:           srv.URL + "/usip",
+		Secret:            "s3cret",
+		CredentialHeaders: map[string]string{"Cookie": cookie},
+		UnitID:            file.UnitId,
+		UserID:            user.UserId,
+	})
+}
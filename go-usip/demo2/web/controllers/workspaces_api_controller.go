@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"go-usip/datamodels"
+	"go-usip/services"
+
+	"github.com/kataras/iris/v12"
+	"github.com/kataras/iris/v12/mvc"
+	"github.com/kataras/iris/v12/sessions"
+)
+
+type WorkspacesAPIController struct {
+	Ctx iris.Context
+
+	Service     services.WorkspaceService
+	UserService services.UserService
+	Session     *sessions.Session
+}
+
+type workspaceResp struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func buildWorkspaceResp(workspace services.UserWorkspace) workspaceResp {
+	return workspaceResp{
+		ID:        workspace.ID,
+		Name:      workspace.Name,
+		Role:      string(workspace.Role),
+		UpdatedAt: workspace.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+func buildWorkspacesResp(workspaces []services.UserWorkspace) []workspaceResp {
+	resp := make([]workspaceResp, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		resp = append(resp, buildWorkspaceResp(workspace))
+	}
+	return resp
+}
+
+type workspacesListResp struct {
+	Workspaces []workspaceResp `json:"workspaces"`
+}
+
+// Get handles GET: /api/workspaces, the workspaces of the user with their role.
+func (c *WorkspacesAPIController) Get() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	workspaces, err := c.Service.GetByUserId(userID)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.JSON(workspacesListResp{Workspaces: buildWorkspacesResp(workspaces)})
+	return nil
+}
+
+type workspaceCreateReq struct {
+	Name string `json:"name"`
+}
+
+// Post handles POST: /api/workspaces, the user becomes its owner.
+func (c *WorkspacesAPIController) Post() mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req workspaceCreateReq
+	if err := c.Ctx.ReadJSON(&req); err != nil {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	workspace, err := c.Service.Create(userID, req.Name)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusCreated)
+	c.Ctx.JSON(buildWorkspaceResp(services.UserWorkspace{Workspace: workspace, Role: datamodels.RoleOwner}))
+	return nil
+}
+
+type workspaceMembersListResp struct {
+	Members []collaboratorItemResp `json:"members"`
+}
+
+// GetByMembers handles GET: /api/workspaces/{id}/members.
+func (c *WorkspacesAPIController) GetByMembers(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	members, err := c.Service.GetMembers(userID, id)
+	if err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	userIds := make([]string, 0, len(members))
+	for _, member := range members {
+		userIds = append(userIds, member.UserId)
+	}
+	users, _ := c.UserService.GetInIDs(userIds)
+	usersById := make(map[string]datamodels.User, len(users))
+	for _, user := range users {
+		usersById[user.UserId] = user
+	}
+
+	resp := workspaceMembersListResp{
+		Members: make([]collaboratorItemResp, 0, len(members)),
+	}
+	for _, member := range members {
+		user, found := usersById[member.UserId]
+		if !found {
+			continue
+		}
+		resp.Members = append(resp.Members, collaboratorItemResp{
+			Subject: collaboratorSubjectResp{
+				ID:     user.UserId,
+				Name:   user.Nickname,
+				Avatar: avatarURL(user.UserId),
+			},
+			Role: string(member.Role),
+		})
+	}
+
+	c.Ctx.JSON(resp)
+	return nil
+}
+
+type workspaceMembersAddReq struct {
+	UserIds []string        `json:"userIds"`
+	Role    datamodels.Role `json:"role"`
+}
+
+// PostByMembers handles POST: /api/workspaces/{id}/members, adds users or changes their role.
+func (c *WorkspacesAPIController) PostByMembers(id uint) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	var req workspaceMembersAddReq
+	if err := c.Ctx.ReadJSON(&req); err != nil || len(req.UserIds) == 0 {
+		return writeAPIError(c.Ctx, iris.StatusBadRequest, "invalid request body")
+	}
+
+	if err := c.Service.AddMembers(userID, id, req.UserIds, req.Role); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
+
+// DeleteByMembersBy handles DELETE: /api/workspaces/{id}/members/{userId}.
+func (c *WorkspacesAPIController) DeleteByMembersBy(id uint, memberId string) mvc.Result {
+	userID, ok := isLoggedIn(c.Session)
+	if !ok {
+		return writeAPIError(c.Ctx, iris.StatusUnauthorized, "unauthorized")
+	}
+
+	if err := c.Service.RemoveMember(userID, id, memberId); err != nil {
+		return writeServiceError(c.Ctx, err)
+	}
+
+	c.Ctx.StatusCode(iris.StatusNoContent)
+	return nil
+}
@@ -0,0 +1,60 @@
+// Command usip-conformance checks a running host against the USIP
+// conformance suite, e.g.
+//
+//	usip-conformance -base-url http://localhost:8080 -header "x-authorization: token:1" -unit unit1 -user 1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-usip/usip/conformance"
+)
+
+type headerFlags map[string]string
+
+func (h headerFlags) String() string {
+	return fmt.Sprint(map[string]string(h))
+}
+
+func (h headerFlags) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("header %q is not in \"Name: value\" form", value)
+	}
+	h[strings.TrimSpace(name)] = strings.TrimSpace(v)
+	return nil
+}
+
+func main() {
+	cfg := conformance.Config{CredentialHeaders: headerFlags{}}
+	flag.StringVar(&cfg.BaseURL, "base-url", "http://localhost:8080", "where the USIP endpoints are mounted")
+	flag.StringVar(&cfg.Secret, "secret", os.Getenv("USIP_SECRET"), "shared secret to sign requests with (default $USIP_SECRET)")
+	flag.Var(headerFlags(cfg.CredentialHeaders), "header", "`Name: value` header which makes /credential succeed, repeatable")
+	flag.StringVar(&cfg.UnitID, "unit", "", "a unit known to the host")
+	flag.StringVar(&cfg.UserID, "user", "", "a collaborator of -unit")
+	flag.DurationVar(&cfg.Timeout, "timeout", 0, "timeout of each request (default 10s)")
+	flag.Parse()
+
+	results, skipped := conformance.Run(cfg)
+
+	failed := 0
+	for _, r := range results {
+		if r.Passed() {
+			fmt.Printf("PASS %s\n", r.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s: %v\n", r.Name, r.Err)
+	}
+	for _, name := range skipped {
+		fmt.Printf("SKIP %s\n", name)
+	}
+
+	fmt.Printf("%d passed, %d failed, %d skipped\n", len(results)-failed, failed, len(skipped))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,278 @@
+package conformance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// unknownID is an id no host is expected to know.
+const unknownID = "usip-conformance-unknown"
+
+var validRoles = map[string]bool{
+	"owner":     true,
+	"editor":    true,
+	"commenter": true,
+	"reader":    true,
+}
+
+var checks = []check{
+	{name: "credential/unauthorized", run: checkCredentialUnauthorized},
+	{name: "credential/authorized", needs: []string{"credential"}, run: checkCredentialAuthorized},
+	{name: "userinfo/empty", run: checkUserinfoEmpty},
+	{name: "userinfo/known", needs: []string{"unit"}, run: checkUserinfoKnown},
+	{name: "collaborators/empty", run: checkCollaboratorsEmpty},
+	{name: "collaborators/known", needs: []string{"unit"}, run: checkCollaboratorsKnown},
+	{name: "role/unknown-unit", run: checkRoleUnknownUnit},
+	{name: "role/unknown-user", needs: []string{"unit"}, run: checkRoleUnknownUser},
+	{name: "role/known", needs: []string{"unit"}, run: checkRoleKnown},
+	{name: "unitedittime/known", needs: []string{"unit"}, run: checkUnitEditTime},
+	{name: "signature/missing", needs: []string{"secret"}, run: checkSignatureMissing},
+	{name: "signature/invalid", needs: []string{"secret"}, run: checkSignatureInvalid},
+	{name: "signature/replayed", needs: []string{"secret"}, run: checkSignatureReplayed},
+}
+
+func checkCredentialUnauthorized(c *client) error {
+	resp, err := c.do(http.MethodGet, "/credential", nil, requestOptions{})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusUnauthorized)
+}
+
+func checkCredentialAuthorized(c *client) error {
+	resp, err := c.do(http.MethodGet, "/credential", nil, requestOptions{credential: true})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	body, err := resp.object()
+	if err != nil {
+		return err
+	}
+	user, ok := body["user"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("missing object field \"user\" in %q", truncate(resp.body))
+	}
+	if id, _ := user["userID"].(string); id == "" {
+		return fmt.Errorf("missing string field \"user.userID\" in %q", truncate(resp.body))
+	}
+	return nil
+}
+
+func checkUserinfoEmpty(c *client) error {
+	resp, err := c.do(http.MethodPost, "/userinfo", map[string]interface{}{"userIDs": []string{unknownID}}, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	users, err := arrayField(resp, "users")
+	if err != nil {
+		return err
+	}
+	if len(users) != 0 {
+		return fmt.Errorf("got %d users for an unknown id, want none", len(users))
+	}
+	return nil
+}
+
+func checkUserinfoKnown(c *client) error {
+	resp, err := c.do(http.MethodPost, "/userinfo", map[string]interface{}{"userIDs": []string{c.cfg.UserID, unknownID}}, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	users, err := arrayField(resp, "users")
+	if err != nil {
+		return err
+	}
+	if len(users) != 1 {
+		return fmt.Errorf("got %d users, want exactly %q", len(users), c.cfg.UserID)
+	}
+	user, _ := users[0].(map[string]interface{})
+	if id, _ := user["userID"].(string); id != c.cfg.UserID {
+		return fmt.Errorf("got users[0].userID %q, want %q", id, c.cfg.UserID)
+	}
+	return nil
+}
+
+func checkCollaboratorsEmpty(c *client) error {
+	resp, err := c.do(http.MethodPost, "/collaborators", map[string]interface{}{"unitIDs": []string{unknownID}}, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	collaborators, err := arrayField(resp, "collaborators")
+	if err != nil {
+		return err
+	}
+	if len(collaborators) != 0 {
+		return fmt.Errorf("got %d collaborators for an unknown unit, want none", len(collaborators))
+	}
+	return nil
+}
+
+func checkCollaboratorsKnown(c *client) error {
+	resp, err := c.do(http.MethodPost, "/collaborators", map[string]interface{}{"unitIDs": []string{c.cfg.UnitID, unknownID}}, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	collaborators, err := arrayField(resp, "collaborators")
+	if err != nil {
+		return err
+	}
+	if len(collaborators) != 1 {
+		return fmt.Errorf("got %d units, want exactly %q", len(collaborators), c.cfg.UnitID)
+	}
+
+	unit, _ := collaborators[0].(map[string]interface{})
+	if id, _ := unit["unitID"].(string); id != c.cfg.UnitID {
+		return fmt.Errorf("got collaborators[0].unitID %q, want %q", id, c.cfg.UnitID)
+	}
+	// subjects may be omitted when empty.
+	subjects, _ := unit["subjects"].([]interface{})
+
+	for i, item := range subjects {
+		s, _ := item.(map[string]interface{})
+		subject, _ := s["subject"].(map[string]interface{})
+		if subject == nil {
+			return fmt.Errorf("missing object field \"subjects[%d].subject\"", i)
+		}
+		role, _ := s["role"].(string)
+		if !validRoles[role] {
+			return fmt.Errorf("got subjects[%d].role %q, want one of owner, editor, commenter, reader", i, role)
+		}
+		if id, _ := subject["id"].(string); id == c.cfg.UserID {
+			return nil
+		}
+	}
+	return fmt.Errorf("user %q is not among the subjects of %q", c.cfg.UserID, c.cfg.UnitID)
+}
+
+func checkRoleUnknownUnit(c *client) error {
+	return expectNoRole(c, unknownID, unknownID)
+}
+
+func checkRoleUnknownUser(c *client) error {
+	return expectNoRole(c, c.cfg.UnitID, unknownID)
+}
+
+func expectNoRole(c *client, unitId, userId string) error {
+	resp, err := c.do(http.MethodGet, roleQuery(unitId, userId), nil, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	body, err := resp.object()
+	if err != nil {
+		return err
+	}
+	if role, _ := body["role"].(string); role != "" {
+		return fmt.Errorf("got role %q, want none", role)
+	}
+	return nil
+}
+
+func checkRoleKnown(c *client) error {
+	resp, err := c.do(http.MethodGet, roleQuery(c.cfg.UnitID, c.cfg.UserID), nil, requestOptions{})
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return err
+	}
+
+	body, err := resp.object()
+	if err != nil {
+		return err
+	}
+	if id, _ := body["userID"].(string); id != c.cfg.UserID {
+		return fmt.Errorf("got userID %q, want %q", id, c.cfg.UserID)
+	}
+	if role, _ := body["role"].(string); !validRoles[role] {
+		return fmt.Errorf("got role %q, want one of owner, editor, commenter, reader", role)
+	}
+	return nil
+}
+
+func checkUnitEditTime(c *client) error {
+	resp, err := c.do(http.MethodPost, "/unitedittime", map[string]interface{}{
+		"unitID":         c.cfg.UnitID,
+		"editTimeUnixMs": time.Now().UnixMilli(),
+	}, requestOptions{})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusOK)
+}
+
+func checkSignatureMissing(c *client) error {
+	resp, err := c.do(http.MethodPost, "/userinfo", map[string]interface{}{"userIDs": []string{unknownID}}, requestOptions{unsigned: true})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusUnauthorized)
+}
+
+func checkSignatureInvalid(c *client) error {
+	resp, err := c.do(http.MethodPost, "/userinfo", map[string]interface{}{"userIDs": []string{unknownID}}, requestOptions{signature: "00"})
+	if err != nil {
+		return err
+	}
+	return expectStatus(resp, http.StatusUnauthorized)
+}
+
+func checkSignatureReplayed(c *client) error {
+	opts := requestOptions{nonce: newNonce()}
+	body := map[string]interface{}{"userIDs": []string{unknownID}}
+
+	resp, err := c.do(http.MethodPost, "/userinfo", body, opts)
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusOK); err != nil {
+		return fmt.Errorf("first request: %v", err)
+	}
+
+	resp, err = c.do(http.MethodPost, "/userinfo", body, opts)
+	if err != nil {
+		return err
+	}
+	if err := expectStatus(resp, http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("replayed request: %v", err)
+	}
+	return nil
+}
+
+// arrayField returns a top level array field, null or missing arrays are
+// rejected because universer expects an empty array.
+func arrayField(resp response, name string) ([]interface{}, error) {
+	body, err := resp.object()
+	if err != nil {
+		return nil, err
+	}
+	items, ok := body[name].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("missing array field %q in %q", name, truncate(resp.body))
+	}
+	return items, nil
+}
@@ -0,0 +1,115 @@
+package conformance
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-usip/usip"
+)
+
+// client calls the host the way universer does.
+type client struct {
+	cfg  Config
+	http *http.Client
+}
+
+type response struct {
+	status int
+	body   []byte
+}
+
+// object decodes the body as a JSON object, keeping field names as sent.
+func (r response) object() (map[string]interface{}, error) {
+	var v map[string]interface{}
+	if err := json.Unmarshal(r.body, &v); err != nil {
+		return nil, fmt.Errorf("body %q is not a JSON object: %v", truncate(r.body), err)
+	}
+	return v, nil
+}
+
+type requestOptions struct {
+	credential bool
+	unsigned   bool
+	nonce      string
+	signature  string
+}
+
+func (c *client) do(method, path string, body interface{}, opts requestOptions) (response, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return response{}, err
+		}
+	}
+
+	req, err := http.NewRequest(method, strings.TrimRight(c.cfg.BaseURL, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return response{}, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if opts.credential {
+		for name, value := range c.cfg.CredentialHeaders {
+			req.Header.Set(name, value)
+		}
+	}
+	if c.cfg.Secret != "" && !opts.unsigned {
+		c.sign(req, payload, opts)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{status: resp.StatusCode, body: data}, nil
+}
+
+func (c *client) sign(req *http.Request, payload []byte, opts requestOptions) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := opts.nonce
+	if nonce == "" {
+		nonce = newNonce()
+	}
+	signature := opts.signature
+	if signature == "" {
+		signature = usip.Sign(c.cfg.Secret, req.Method, req.URL.RequestURI(), timestamp, nonce, payload)
+	}
+
+	req.Header.Set(usip.HeaderTimestamp, timestamp)
+	req.Header.Set(usip.HeaderNonce, nonce)
+	req.Header.Set(usip.HeaderSignature, signature)
+}
+
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func roleQuery(unitId, userId string) string {
+	return "/role?" + url.Values{"unitID": {unitId}, "userID": {userId}}.Encode()
+}
+
+func truncate(b []byte) string {
+	if len(b) > 200 {
+		return string(b[:200]) + "..."
+	}
+	return string(b)
+}
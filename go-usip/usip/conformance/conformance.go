@@ -0,0 +1,89 @@
+// Package conformance checks that a host implements USIP the way universer
+// expects: status codes, JSON field names, empty results and unauthorized
+// handling of /credential, /userinfo, /collaborators, /role and /unitedittime.
+//
+// Use Run from a command, or conformancetest.Test from a go test of the host application.
+package conformance
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	// BaseURL is where the USIP endpoints are mounted, e.g. http://localhost:8090/usip.
+	BaseURL string
+	// Secret signs every request when the host verifies signatures.
+	Secret string
+	// CredentialHeaders make /credential succeed, e.g. a session cookie
+	// or the token header of the host.
+	CredentialHeaders map[string]string
+
+	// UnitID is a unit known to the host and UserID one of its collaborators.
+	UnitID string
+	UserID string
+
+	Timeout time.Duration
+}
+
+type Result struct {
+	Name string
+	Err  error
+}
+
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+type check struct {
+	name string
+	// needs lists the Config fields the check depends on.
+	needs []string
+	run   func(c *client) error
+}
+
+// Run executes every check which can run with cfg and reports the outcome of each,
+// the names of checks missing their fixtures are returned as skipped.
+func Run(cfg Config) (results []Result, skipped []string) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	c := &client{cfg: cfg, http: &http.Client{Timeout: cfg.Timeout}}
+
+	for _, ch := range checks {
+		if !hasFixtures(cfg, ch.needs) {
+			skipped = append(skipped, ch.name)
+			continue
+		}
+		results = append(results, Result{Name: ch.name, Err: ch.run(c)})
+	}
+	return results, skipped
+}
+
+func hasFixtures(cfg Config, needs []string) bool {
+	for _, need := range needs {
+		switch need {
+		case "secret":
+			if cfg.Secret == "" {
+				return false
+			}
+		case "credential":
+			if len(cfg.CredentialHeaders) == 0 {
+				return false
+			}
+		case "unit":
+			if cfg.UnitID == "" || cfg.UserID == "" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func expectStatus(resp response, want int) error {
+	if resp.status != want {
+		return fmt.Errorf("got status %d, want %d, body %q", resp.status, want, truncate(resp.body))
+	}
+	return nil
+}
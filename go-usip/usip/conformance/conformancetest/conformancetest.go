@@ -0,0 +1,29 @@
+// Package conformancetest runs the USIP conformance checks from a go test of the host application,
+// it is kept apart from conformance so commands using the checks do not link the testing package.
+package conformancetest
+
+import (
+	"testing"
+
+	"go-usip/usip/conformance"
+)
+
+// Test runs every check as a subtest of t.
+func Test(t *testing.T, cfg conformance.Config) {
+	t.Helper()
+
+	results, skipped := conformance.Run(cfg)
+	for _, r := range results {
+		r := r
+		t.Run(r.Name, func(t *testing.T) {
+			if r.Err != nil {
+				t.Fatal(r.Err)
+			}
+		})
+	}
+	for _, name := range skipped {
+		t.Run(name, func(t *testing.T) {
+			t.Skip("fixture not configured")
+		})
+	}
+}
@@ -0,0 +1,3 @@
+module go-usip/usip
+
+go 1.21.3
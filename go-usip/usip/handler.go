@@ -0,0 +1,164 @@
+package usip
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type Config struct {
+	Credentials   CredentialVerifier
+	Users         UserProvider
+	Collaborators CollaboratorProvider
+	// EditTimes is optional, /unitedittime is accepted and ignored without it.
+	EditTimes EditTimeRecorder
+
+	// Secret is shared with universer to sign every call, see RequireSignature.
+	// An empty secret disables verification.
+	Secret  string
+	MaxSkew time.Duration
+}
+
+// NewHandler returns the USIP endpoints universer calls on the host:
+//
+//	GET  /credential
+//	POST /userinfo
+//	POST /collaborators
+//	GET  /role?unitID=&userID=
+//	POST /unitedittime
+//
+// Paths are relative, mount the handler with http.StripPrefix when it does
+// not live at the root.
+func NewHandler(cfg Config) http.Handler {
+	h := &handler{cfg: cfg}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/credential", allow(http.MethodGet, h.credential))
+	mux.HandleFunc("/userinfo", allow(http.MethodPost, h.userinfo))
+	mux.HandleFunc("/collaborators", allow(http.MethodPost, h.collaborators))
+	mux.HandleFunc("/role", allow(http.MethodGet, h.role))
+	mux.HandleFunc("/unitedittime", allow(http.MethodPost, h.unitEditTime))
+
+	return RequireSignature(SignatureConfig{Secret: cfg.Secret, MaxSkew: cfg.MaxSkew}, mux)
+}
+
+type handler struct {
+	cfg Config
+}
+
+func allow(method string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (h *handler) credential(w http.ResponseWriter, r *http.Request) {
+	user, err := h.cfg.Credentials.VerifyCredential(r)
+	if err != nil {
+		if !errors.Is(err, ErrUnauthorized) {
+			log.Printf("usip: verify credential: %v", err)
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	writeJSON(w, UsipCredentialResp{User: user})
+}
+
+func (h *handler) userinfo(w http.ResponseWriter, r *http.Request) {
+	var req UsipUserinfoReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resp := UsipUserinfoResp{Users: []UsipUser{}}
+	if len(req.UserIds) > 0 {
+		users, err := h.cfg.Users.GetUsers(r.Context(), req.UserIds)
+		if err != nil {
+			log.Printf("usip: get users: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp.Users = append(resp.Users, users...)
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *handler) collaborators(w http.ResponseWriter, r *http.Request) {
+	var req UsipCollaboratorsReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resp := UsipCollaboratorsResp{Collaborators: []UsipUnitCollaborators{}}
+	for _, unitId := range req.UnitIds {
+		subjects, err := h.cfg.Collaborators.GetCollaborators(r.Context(), unitId)
+		if errors.Is(err, ErrUnitNotFound) {
+			continue
+		}
+		if err != nil {
+			log.Printf("usip: get collaborators of %s: %v", unitId, err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		resp.Collaborators = append(resp.Collaborators, UsipUnitCollaborators{
+			UnitId:   unitId,
+			Subjects: subjects,
+		})
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *handler) role(w http.ResponseWriter, r *http.Request) {
+	userId := r.FormValue("userID")
+	unitId := r.FormValue("unitID")
+
+	role, err := h.cfg.Collaborators.GetRole(r.Context(), unitId, userId)
+	if err != nil && !errors.Is(err, ErrUnitNotFound) {
+		log.Printf("usip: get role of %s on %s: %v", userId, unitId, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, UsipGetRoleResp{UserId: userId, Role: role})
+}
+
+func (h *handler) unitEditTime(w http.ResponseWriter, r *http.Request) {
+	var req UsipUpdateEditTimeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.cfg.EditTimes != nil {
+		err := h.cfg.EditTimes.RecordEditTime(r.Context(), req.UnitID, time.UnixMilli(req.EditTimeUnixMs))
+		if errors.Is(err, ErrUnitNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,40 @@
+package usip
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is returned by a CredentialVerifier when the request
+	// does not carry a valid host credential.
+	ErrUnauthorized = errors.New("usip: unauthorized")
+	// ErrUnitNotFound is returned by providers for units the host does not know.
+	ErrUnitNotFound = errors.New("usip: unit not found")
+)
+
+// CredentialVerifier resolves the host user behind a browser request
+// universer forwards to /credential.
+type CredentialVerifier interface {
+	VerifyCredential(r *http.Request) (UsipUser, error)
+}
+
+// UserProvider looks users up by id, unknown ids are skipped.
+type UserProvider interface {
+	GetUsers(ctx context.Context, userIds []string) ([]UsipUser, error)
+}
+
+// CollaboratorProvider reports who can access a unit and with which role.
+type CollaboratorProvider interface {
+	// GetCollaborators returns ErrUnitNotFound for unknown units.
+	GetCollaborators(ctx context.Context, unitId string) ([]UsipCollaborator, error)
+	// GetRole returns an empty role when the user has no access to the unit.
+	GetRole(ctx context.Context, unitId, userId string) (Role, error)
+}
+
+// EditTimeRecorder is told when a unit was last edited.
+type EditTimeRecorder interface {
+	RecordEditTime(ctx context.Context, unitId string, editTime time.Time) error
+}
@@ -0,0 +1,66 @@
+// Package usip implements the host side of USIP, the protocol universer uses to
+// ask the host application about users, collaborators and their roles on units.
+package usip
+
+// Role is the permission level universer enforces on a unit.
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleEditor    Role = "editor"
+	RoleCommenter Role = "commenter"
+	RoleReader    Role = "reader"
+)
+
+type UsipUser struct {
+	UserId string `json:"userID,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+}
+
+type UsipCredentialResp struct {
+	User UsipUser `json:"user,omitempty"`
+}
+
+type UsipUserinfoReq struct {
+	UserIds []string `json:"userIDs,omitempty"`
+}
+
+type UsipUserinfoResp struct {
+	Users []UsipUser `json:"users"`
+}
+
+type UsipGetRoleResp struct {
+	UserId string `json:"userID,omitempty"`
+	Role   Role   `json:"role,omitempty"`
+}
+
+type UsipSubject struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Avatar string `json:"avatar,omitempty"`
+	// Type   string `json:"type,omitempty"`
+}
+
+type UsipCollaborator struct {
+	Subject UsipSubject `json:"subject,omitempty"`
+	Role    Role        `json:"role,omitempty"`
+}
+
+type UsipUnitCollaborators struct {
+	UnitId   string             `json:"unitID,omitempty"`
+	Subjects []UsipCollaborator `json:"subjects,omitempty"`
+}
+
+type UsipCollaboratorsReq struct {
+	UnitIds []string `json:"unitIDs,omitempty"`
+}
+
+type UsipCollaboratorsResp struct {
+	Collaborators []UsipUnitCollaborators `json:"collaborators"`
+}
+
+type UsipUpdateEditTimeReq struct {
+	UnitID         string `json:"unitID"`
+	EditTimeUnixMs int64  `json:"editTimeUnixMs"`
+}